@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
 	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -32,6 +35,7 @@ func init() {
 	envFilePath := filepath.Join(usr.HomeDir, ".masa", "masa_bridge.env")
 	certFilePath := filepath.Join(usr.HomeDir, ".masa", "webhook-selfsigned-cert.pem")
 	certKeyFilePath := filepath.Join(usr.HomeDir, ".masa", "webhook - selfsigned - key.pem")
+	clientsFilePath := filepath.Join(usr.HomeDir, ".masa", "bridge_clients.json")
 
 	// Create the directories if they don't already exist
 	if _, err := os.Stat(filepath.Dir(envFilePath)); os.IsNotExist(err) {
@@ -46,6 +50,7 @@ func init() {
 		builder := strings.Builder{}
 		builder.WriteString(fmt.Sprintf("%s=%s\n", masa.Cert, certFilePath))
 		builder.WriteString(fmt.Sprintf("%s=%s\n", masa.CertPem, certKeyFilePath))
+		builder.WriteString(fmt.Sprintf("%s=%s\n", masa.BridgeClientsFile, clientsFilePath))
 		err = os.WriteFile(envFilePath, []byte(builder.String()), 0644)
 		if err != nil {
 			logrus.Fatal("could not write to .env file:", err)
@@ -55,24 +60,52 @@ func init() {
 	if err != nil {
 		logrus.Error("Error loading .env file")
 	}
+	// env files written by older versions do not name all files
+	defaults := map[string]string{
+		masa.BridgeClientsFile:    clientsFilePath,
+		masa.BridgeRoutesFile:     filepath.Join(usr.HomeDir, ".masa", "bridge_routes.json"),
+		masa.BridgeDeadLetterPath: filepath.Join(usr.HomeDir, ".masa", "bridge_dead_letter.jsonl"),
+		masa.KeyFileKey:           filepath.Join(usr.HomeDir, ".masa", "masa_bridge_key"),
+		masa.CADir:                filepath.Join(usr.HomeDir, ".masa", "ca"),
+	}
+	for key, value := range defaults {
+		if os.Getenv(key) == "" {
+			os.Setenv(key, value)
+		}
+	}
 }
 
 func main() {
-	certFile := os.Getenv(masa.Cert)
-	keyFile := os.Getenv(masa.CertPem)
-
-	// Check if the certificate and key files already exist
-	if _, err := os.Stat(certFile); os.IsNotExist(err) {
-		if _, err := os.Stat(keyFile); os.IsNotExist(err) {
-			// If not, generate them
-			if err := crypto.GenerateSelfSignedCert(certFile, keyFile); err != nil {
-				logrus.Fatal("Failed to generate self-signed certificate:", err)
-			}
-		}
+	// The bridge runs its own node to forward the webhooks into the network, it joins through the peerList bootnodes
+	ctx, cancel := context.WithCancel(context.Background())
+	privKey, _, _, err := crypto.GetOrCreatePrivateKey(os.Getenv(masa.KeyFileKey))
+	if err != nil {
+		logrus.Fatal(err)
 	}
-
-	err := masa.NewBridge()
+	portNbr, _ := strconv.Atoi(os.Getenv(masa.PortNbr))
+	udp, _ := strconv.ParseBool(os.Getenv("UDP"))
+	node, err := masa.NewOracleNode(ctx, privKey, portNbr, udp, true, false)
 	if err != nil {
+		logrus.Fatal(err)
+	}
+	if err := node.Start(); err != nil {
+		logrus.Fatal(err)
+	}
+	logrus.Infof("Bridge node started with ID: %s", node.GetMultiAddrs().String())
 
+	// Listen for SIGINT (CTRL+C)
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		<-c
+		cancel()
+	}()
+
+	err = masa.NewBridge(node)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if err := node.PubSubManager.Close(); err != nil {
+		logrus.Error(err)
 	}
 }
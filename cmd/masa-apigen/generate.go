@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/masa-finance/masa-oracle/pkg/api"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// generator writes the client methods and collects the packages they use.
+type generator struct {
+	buf     bytes.Buffer
+	imports map[string]string
+}
+
+// generateClient returns the formatted source of the client methods of the endpoints. Streaming endpoints are
+// left out, clients connect to them directly.
+func generateClient(endpoints []api.Endpoint) ([]byte, error) {
+	g := &generator{imports: map[string]string{
+		"context":  "context",
+		"net/http": "http",
+		"github.com/masa-finance/masa-oracle/pkg/api/v1": "v1",
+	}}
+	for _, e := range endpoints {
+		if e.Stream != "" {
+			continue
+		}
+		if err := g.method(e); err != nil {
+			return nil, fmt.Errorf("endpoint %s: %w", e.Name, err)
+		}
+	}
+
+	var file bytes.Buffer
+	file.WriteString("// Code generated by masa-apigen. DO NOT EDIT.\n\npackage client\n\nimport (\n")
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	// standard library first, like goimports
+	sort.Slice(paths, func(i, j int) bool {
+		iStd, jStd := !strings.Contains(strings.Split(paths[i], "/")[0], "."), !strings.Contains(strings.Split(paths[j], "/")[0], ".")
+		if iStd != jStd {
+			return iStd
+		}
+		return paths[i] < paths[j]
+	})
+	for i, path := range paths {
+		if i > 0 && strings.Contains(strings.Split(path, "/")[0], ".") && !strings.Contains(strings.Split(paths[i-1], "/")[0], ".") {
+			file.WriteString("\n")
+		}
+		if name := g.imports[path]; name != path[strings.LastIndex(path, "/")+1:] {
+			fmt.Fprintf(&file, "\t%s %q\n", name, path)
+		} else {
+			fmt.Fprintf(&file, "\t%q\n", path)
+		}
+	}
+	file.WriteString(")\n")
+	file.Write(g.buf.Bytes())
+	return format.Source(file.Bytes())
+}
+
+func (g *generator) method(e api.Endpoint) error {
+	args := []string{"ctx context.Context"}
+	path, pathParams := g.path(e.Path)
+	for _, name := range pathParams {
+		args = append(args, name+" string")
+	}
+	if e.Params != nil {
+		args = append(args, "params "+g.typeExpr(reflect.TypeOf(e.Params)))
+	}
+	if e.Body != nil {
+		args = append(args, "body "+g.typeExpr(reflect.TypeOf(e.Body)))
+	}
+
+	var results, resultArg, returns string
+	switch {
+	case e.Result == nil:
+		results, resultArg, returns = "error", "nil", "_, err := c.do"
+	case reflect.TypeOf(e.Result).Kind() == reflect.Slice && reflect.TypeOf(e.Result).Elem().Kind() != reflect.Uint8:
+		// lists return the meta data of the page
+		results, resultArg, returns = "("+g.typeExpr(reflect.TypeOf(e.Result))+", *v1.Meta, error)", "&result", "meta, err := c.do"
+	default:
+		results, resultArg, returns = "("+g.typeExpr(reflect.TypeOf(e.Result))+", error)", "&result", "_, err := c.do"
+	}
+
+	summary := e.Summary
+	if summary != "" {
+		summary = strings.ToLower(summary[:1]) + summary[1:]
+	}
+	fmt.Fprintf(&g.buf, "\n// %s %s, %s %s.\n", e.Name, summary, e.Method, "/v1"+e.Path)
+	fmt.Fprintf(&g.buf, "func (c *Client) %s(%s) %s {\n", e.Name, strings.Join(args, ", "), results)
+
+	query := "nil"
+	if e.Params != nil {
+		query = "query"
+		g.buf.WriteString("query := url.Values{}\n")
+		g.imports["net/url"] = "url"
+		if err := g.query(reflect.TypeOf(e.Params)); err != nil {
+			return err
+		}
+	}
+	body := "nil"
+	if e.Body != nil {
+		body = "body"
+	}
+	if e.Result != nil {
+		fmt.Fprintf(&g.buf, "var result %s\n", g.typeExpr(reflect.TypeOf(e.Result)))
+	}
+	fmt.Fprintf(&g.buf, "%s(ctx, %s, %s, %s, %s, %s)\n", returns, httpMethod(e.Method), path, query, body, resultArg)
+	switch {
+	case e.Result == nil:
+		g.buf.WriteString("return err\n")
+	case strings.HasPrefix(returns, "meta"):
+		g.buf.WriteString("return result, meta, err\n")
+	default:
+		g.buf.WriteString("return result, err\n")
+	}
+	g.buf.WriteString("}\n")
+	return nil
+}
+
+// path returns the expression of a path with gin parameters, escaping the parameters, and the parameter names.
+func (g *generator) path(path string) (string, []string) {
+	var parts, names []string
+	literal := ""
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		literal += "/"
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			parts = append(parts, fmt.Sprintf("%q", literal), "url.PathEscape("+name+")")
+			names = append(names, name)
+			literal = ""
+			g.imports["net/url"] = "url"
+		} else {
+			literal += segment
+		}
+	}
+	if literal != "" {
+		parts = append(parts, fmt.Sprintf("%q", literal))
+	}
+	return strings.Join(parts, " + "), names
+}
+
+// query writes the encoding of the form fields of a params struct, leaving out zero values.
+func (g *generator) query(t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("form")
+		if name == "" || name == "-" {
+			continue
+		}
+		value := "params." + field.Name
+		switch {
+		case field.Type == timeType:
+			g.imports["time"] = "time"
+			fmt.Fprintf(&g.buf, "if !%s.IsZero() {\nquery.Set(%q, %s.Format(time.RFC3339))\n}\n", value, name, value)
+		case field.Type == durationType:
+			fmt.Fprintf(&g.buf, "if %s != 0 {\nquery.Set(%q, %s.String())\n}\n", value, name, value)
+		case field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Bool:
+			g.imports["strconv"] = "strconv"
+			fmt.Fprintf(&g.buf, "if %s != nil {\nquery.Set(%q, strconv.FormatBool(*%s))\n}\n", value, name, value)
+		case field.Type.Kind() == reflect.String:
+			fmt.Fprintf(&g.buf, "if %s != \"\" {\nquery.Set(%q, string(%s))\n}\n", value, name, value)
+		case field.Type.Kind() == reflect.Bool:
+			fmt.Fprintf(&g.buf, "if %s {\nquery.Set(%q, \"true\")\n}\n", value, name)
+		case field.Type.Kind() >= reflect.Int && field.Type.Kind() <= reflect.Int64:
+			g.imports["strconv"] = "strconv"
+			fmt.Fprintf(&g.buf, "if %s != 0 {\nquery.Set(%q, strconv.FormatInt(int64(%s), 10))\n}\n", value, name, value)
+		case field.Type.Kind() >= reflect.Uint && field.Type.Kind() <= reflect.Uint64:
+			g.imports["strconv"] = "strconv"
+			fmt.Fprintf(&g.buf, "if %s != 0 {\nquery.Set(%q, strconv.FormatUint(uint64(%s), 10))\n}\n", value, name, value)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.String:
+			fmt.Fprintf(&g.buf, "for _, value := range %s {\nquery.Add(%q, string(value))\n}\n", value, name)
+		default:
+			return fmt.Errorf("query parameter %s has unsupported type %s", name, field.Type)
+		}
+	}
+	return nil
+}
+
+// typeExpr returns the Go expression of a type and imports the packages it refers to.
+func (g *generator) typeExpr(t reflect.Type) string {
+	g.addImports(t)
+	return t.String()
+}
+
+func (g *generator) addImports(t reflect.Type) {
+	if t.Name() != "" && t.PkgPath() != "" {
+		g.imports[t.PkgPath()] = t.String()[:strings.Index(t.String(), ".")]
+		return
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		g.addImports(t.Elem())
+	case reflect.Map:
+		g.addImports(t.Key())
+		g.addImports(t.Elem())
+	}
+}
+
+func httpMethod(method string) string {
+	switch method {
+	case http.MethodGet:
+		return "http.MethodGet"
+	case http.MethodPost:
+		return "http.MethodPost"
+	case http.MethodPut:
+		return "http.MethodPut"
+	case http.MethodPatch:
+		return "http.MethodPatch"
+	case http.MethodDelete:
+		return "http.MethodDelete"
+	}
+	return fmt.Sprintf("%q", method)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/masa-finance/masa-oracle/pkg/api"
+)
+
+func TestClientUpToDate(t *testing.T) {
+	code, err := generateClient(api.Endpoints())
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := os.ReadFile("../../pkg/client/client_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(code, current) {
+		t.Error("pkg/client/client_gen.go is out of date, run go generate ./pkg/client")
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Package main - masa-apigen
+
+masa-apigen generates the methods of the Go client in pkg/client from the v1 endpoints of pkg/api, and can write
+the OpenAPI document the node serves at /v1/openapi.json to a file. It runs through go generate in pkg/client.
+
+Example usage:
+masa-apigen -out pkg/client/client_gen.go -openapi openapi.json
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/masa-finance/masa-oracle/pkg/api"
+)
+
+func main() {
+	out := flag.String("out", "client_gen.go", "file to write the client methods to")
+	openAPI := flag.String("openapi", "", "file to write the OpenAPI document to")
+	flag.Parse()
+
+	endpoints := api.Endpoints()
+	code, err := generateClient(endpoints)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, code, 0644); err != nil {
+		log.Fatal(err)
+	}
+	if *openAPI != "" {
+		document, err := json.MarshalIndent(api.OpenAPI(endpoints), "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*openAPI, append(document, '\n'), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+
+	masa "github.com/masa-finance/masa-oracle/pkg"
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/client"
+	"github.com/masa-finance/masa-oracle/pkg/crypto"
+)
+
+const adminUsage = `Usage: masa-node admin <command> [flags] [arguments]
+
+Commands:
+  connect <multiaddr>       Dial a peer, the address ends in /p2p/<peer ID>
+  disconnect <peer ID>      Close the connections to a peer
+  ban <peer ID>             Ban a peer for -duration (24h by default) and disconnect it
+  unban <peer ID>           Lift the ban of a peer
+  bans                      List the banned peers
+  bootnodes                 List the bootnodes
+  bootnodes add <multiaddr> Connect to a bootnode and add it
+  bootnodes remove <multiaddr>
+                            Remove a bootnode
+  refresh                   Reconnect to the bootnodes and refresh the DHT
+
+The commands call the API of the running node, at -api (http://localhost:8080 by default, https with apiTLS) with
+the API key of -key or the apiKey environment variable, which needs the admin role in the apiAuthFile.
+`
+
+// apiFlags are the flags of the commands calling the node API.
+type apiFlags struct {
+	fs     *flag.FlagSet
+	apiURL *string
+	key    *string
+}
+
+func newAPIFlags(command string) apiFlags {
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	return apiFlags{
+		fs:     fs,
+		apiURL: fs.String("api", defaultAPIURL(), "URL of the node API"),
+		key:    fs.String("key", os.Getenv(masa.APIKey), "API key, with the admin role for admin commands"),
+	}
+}
+
+// client returns a client of the node API, trusting the local CA when the API uses TLS.
+func (f apiFlags) client() (*client.Client, error) {
+	// the node gives up dialing and refreshing after 30 seconds
+	httpClient := &http.Client{Timeout: time.Minute}
+	if apiTLS, _ := strconv.ParseBool(os.Getenv(masa.APITLS)); apiTLS {
+		caCerts, err := os.ReadFile(filepath.Join(os.Getenv(masa.CADir), crypto.CACertFileName))
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCerts) {
+			return nil, errors.New("no CA certificates found")
+		}
+		httpClient.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	}
+	return client.New(*f.apiURL, client.WithAPIKey(*f.key), client.WithHTTPClient(httpClient)), nil
+}
+
+func defaultAPIURL() string {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	if apiTLS, _ := strconv.ParseBool(os.Getenv(masa.APITLS)); apiTLS {
+		return "https://localhost:" + port
+	}
+	return "http://localhost:" + port
+}
+
+func handleAdmin(args []string) error {
+	if len(args) == 0 {
+		fmt.Print(adminUsage)
+		return errors.New("missing admin command")
+	}
+	command, args := args[0], args[1:]
+	flags := newAPIFlags("admin " + command)
+	duration := time.Duration(0)
+	reason := ""
+	if command == "ban" {
+		flags.fs.DurationVar(&duration, "duration", 0, "How long the peer is banned, 24h by default")
+		flags.fs.StringVar(&reason, "reason", "", "Why the peer is banned")
+	}
+	_ = flags.fs.Parse(args)
+	args = flags.fs.Args()
+	c, err := flags.client()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	argument := func(name string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("admin %s takes the %s", command, name)
+		}
+		return args[0], nil
+	}
+	switch command {
+	case "connect":
+		address, err := argument("multiaddress of the peer")
+		if err != nil {
+			return err
+		}
+		peer, err := c.ConnectPeer(ctx, v1.AddressRequest{Address: address})
+		if err != nil {
+			return err
+		}
+		color.Green("Connected to %s", peer.ID)
+	case "disconnect":
+		id, err := argument("peer ID")
+		if err != nil {
+			return err
+		}
+		if err := c.DisconnectPeer(ctx, id); err != nil {
+			return err
+		}
+		color.Green("Disconnected %s", id)
+	case "ban":
+		id, err := argument("peer ID")
+		if err != nil {
+			return err
+		}
+		request := v1.BanRequest{PeerID: id, Reason: reason}
+		if duration != 0 {
+			request.Duration = duration.String()
+		}
+		ban, err := c.BanPeer(ctx, request)
+		if err != nil {
+			return err
+		}
+		color.Yellow("Banned %s until %s", ban.PeerId, ban.Expires.Format(time.RFC3339))
+	case "unban":
+		id, err := argument("peer ID")
+		if err != nil {
+			return err
+		}
+		if err := c.UnbanPeer(ctx, id); err != nil {
+			return err
+		}
+		color.Green("Unbanned %s", id)
+	case "bans":
+		bans, _, err := c.ListBans(ctx)
+		if err != nil {
+			return err
+		}
+		for _, ban := range bans {
+			fmt.Printf("%s  until %s  %s\n", ban.PeerId, ban.Expires.Format(time.RFC3339), ban.Reason)
+		}
+	case "bootnodes":
+		return handleBootnodes(ctx, c, args)
+	case "refresh":
+		refresh, err := c.RefreshDHT(ctx)
+		if err != nil {
+			return err
+		}
+		color.Green("Refreshed the DHT: %d bootnodes connected, %d peers in the routing table", refresh.ConnectedBootnodes, refresh.RoutingTableSize)
+	default:
+		fmt.Print(adminUsage)
+		return fmt.Errorf("unknown admin command %q", command)
+	}
+	return nil
+}
+
+func handleBootnodes(ctx context.Context, c *client.Client, args []string) error {
+	if len(args) == 0 {
+		bootnodes, _, err := c.ListBootnodes(ctx)
+		if err != nil {
+			return err
+		}
+		for _, bootnode := range bootnodes {
+			fmt.Println(bootnode)
+		}
+		return nil
+	}
+	if len(args) != 2 {
+		return errors.New("admin bootnodes takes add or remove and a multiaddress")
+	}
+	switch args[0] {
+	case "add":
+		if err := c.AddBootnode(ctx, v1.AddressRequest{Address: args[1]}); err != nil {
+			return err
+		}
+		color.Green("Added bootnode %s", args[1])
+	case "remove":
+		if err := c.RemoveBootnode(ctx, v1.BootnodeParams{Address: args[1]}); err != nil {
+			return err
+		}
+		color.Green("Removed bootnode %s", args[1])
+	default:
+		return fmt.Errorf("unknown bootnodes command %q", args[0])
+	}
+	return nil
+}
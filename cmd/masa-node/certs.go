@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	masa "github.com/masa-finance/masa-oracle/pkg"
+	"github.com/masa-finance/masa-oracle/pkg/crypto"
+)
+
+const certsUsage = `Usage: masa-node certs <command> [flags]
+
+Commands:
+  init     Create the local CA in caDir, ~/.masa/ca by default
+  issue    Issue a server or client certificate signed by the local CA
+  bundle   Write the CA certificate bundle clients have to trust
+  status   Print when certificates expire
+`
+
+func handleCerts(args []string) error {
+	if len(args) == 0 {
+		fmt.Print(certsUsage)
+		return errors.New("missing certs command")
+	}
+	switch args[0] {
+	case "init":
+		return initCA(args[1:])
+	case "issue":
+		return issueCert(args[1:])
+	case "bundle":
+		return writeCABundle(args[1:])
+	case "status":
+		return certStatus(args[1:])
+	default:
+		fmt.Print(certsUsage)
+		return fmt.Errorf("unknown certs command %q", args[0])
+	}
+}
+
+func initCA(args []string) error {
+	fs := flag.NewFlagSet("certs init", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	ca, err := masa.LocalCA()
+	if err != nil {
+		return err
+	}
+	color.Green("Local CA %q in %s", ca.Cert.Subject.CommonName, os.Getenv(masa.CADir))
+	fmt.Printf("Valid until: %s\n", ca.Cert.NotAfter.Format(time.RFC3339))
+	return nil
+}
+
+func issueCert(args []string) error {
+	fs := flag.NewFlagSet("certs issue", flag.ExitOnError)
+	name := fs.String("name", "", "Common name of the certificate, clients are identified by it")
+	org := fs.String("org", "", "Organization of the certificate subject")
+	sans := fs.String("san", "", "Comma separated DNS names and IP addresses of a server certificate")
+	client := fs.Bool("client", false, "Issue a client certificate instead of a server certificate")
+	validity := fs.Duration("validity", crypto.DefaultCertValidity, "How long the certificate is valid")
+	out := fs.String("out", ".", "Directory to write <name>.pem and <name>-key.pem to")
+	_ = fs.Parse(args)
+
+	if *name == "" {
+		return errors.New("-name is required")
+	}
+	ca, err := masa.LocalCA()
+	if err != nil {
+		return err
+	}
+	names, ips := crypto.ParseSANs(strings.Split(*sans, ","))
+	opts := crypto.CertOptions{CommonName: *name, DNSNames: names, IPAddresses: ips, Validity: *validity, Client: *client}
+	if *org != "" {
+		opts.Organization = []string{*org}
+	}
+	certPath := filepath.Join(*out, *name+".pem")
+	keyPath := filepath.Join(*out, *name+"-key.pem")
+	if err := ca.IssueFiles(opts, certPath, keyPath); err != nil {
+		return err
+	}
+	color.Green("Issued %s and %s", certPath, keyPath)
+	return nil
+}
+
+func writeCABundle(args []string) error {
+	fs := flag.NewFlagSet("certs bundle", flag.ExitOnError)
+	out := fs.String("out", "ca-bundle.pem", "File to write the bundle to")
+	extra := fs.String("extra", "", "Comma separated PEM files of other CA certificates to include")
+	_ = fs.Parse(args)
+
+	ca, err := masa.LocalCA()
+	if err != nil {
+		return err
+	}
+	var files []string
+	if *extra != "" {
+		files = strings.Split(*extra, ",")
+	}
+	if err := ca.WriteBundle(*out, files...); err != nil {
+		return err
+	}
+	color.Green("Wrote CA bundle to %s", *out)
+	return nil
+}
+
+func certStatus(args []string) error {
+	fs := flag.NewFlagSet("certs status", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		dir := os.Getenv(masa.CADir)
+		files = []string{filepath.Join(dir, crypto.CACertFileName), filepath.Join(dir, "api.pem")}
+	}
+	for _, file := range files {
+		expiry, err := crypto.CertExpiry(file)
+		if err != nil {
+			fmt.Printf("%s: %v\n", file, err)
+			continue
+		}
+		left := time.Until(expiry).Round(time.Hour)
+		line := fmt.Sprintf("%s: valid until %s (%s left)", file, expiry.Format(time.RFC3339), left)
+		switch {
+		case left <= 0:
+			color.Red("%s: expired %s", file, expiry.Format(time.RFC3339))
+		case left < crypto.DefaultCertValidity/3:
+			color.Yellow(line)
+		default:
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
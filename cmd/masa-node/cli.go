@@ -11,24 +11,35 @@ import (
 	"github.com/sirupsen/logrus"
 
 	masa "github.com/masa-finance/masa-oracle/pkg"
+	"github.com/masa-finance/masa-oracle/pkg/crypto"
 )
 
 type Config struct {
-	Bootnodes []string `json:"bootnodes"`
+	Bootnodes      []string `json:"bootnodes"`
+	DerivationPath string   `json:"derivationPath,omitempty"`
 }
 
 var (
-	configFile    string
-	start         bool
-	portNbr       int
-	udp           bool
-	tcp           bool
-	signature     string
-	bootnodes     string
-	flagBootnodes string
-	data          string
-	stakeAmount   string
-	debug         bool
+	configFile     string
+	start          bool
+	portNbr        int
+	udp            bool
+	tcp            bool
+	signature      string
+	bootnodes      string
+	flagBootnodes  string
+	data           string
+	stakeAmount    string
+	debug          bool
+	passFile       string
+	plaintextKey   bool
+	writeEcdsaKey  bool
+	signerKind     string
+	signerEndpoint string
+	signerAddress  string
+	// signerKeystorePath and signerPassFile select the keystore of the keystore signer
+	signerKeystorePath string
+	signerPassFile     string
 )
 
 func init() {
@@ -43,6 +54,14 @@ func init() {
 	flag.StringVar(&data, "data", "", "The data to verify the signature against")
 	flag.StringVar(&stakeAmount, "stake", "", "Amount of tokens to stake")
 	flag.BoolVar(&debug, "debug", false, "Override some protections for debugging (temporary)")
+	flag.StringVar(&passFile, "passphraseFile", "", "File containing the passphrase of the encrypted node key")
+	flag.BoolVar(&plaintextKey, "plaintextKey", false, "Store the node key as plaintext hex instead of an encrypted keystore")
+	flag.BoolVar(&writeEcdsaKey, "writeEcdsaKey", false, "Write an unencrypted copy of the ECDSA key next to the node key")
+	flag.StringVar(&signerKind, "signer", "", "Signer used for staking and signing: memory, keystore or external")
+	flag.StringVar(&signerEndpoint, "signerEndpoint", "", "Clef endpoint of the external signer, e.g. ~/.clef/clef.ipc")
+	flag.StringVar(&signerAddress, "signerAddress", "", "Account of the external signer, defaults to its first account")
+	flag.StringVar(&signerKeystorePath, "signerKeystore", "", "V3 keystore of the keystore signer, separate from the node key")
+	flag.StringVar(&signerPassFile, "signerPassphraseFile", "", "File containing the passphrase of the signer keystore")
 	flag.Parse()
 
 	if start {
@@ -71,6 +90,22 @@ func init() {
 	}
 }
 
+// keyOptions returns the key options from the environment, overridden by any key flags given on the command line
+func keyOptions() crypto.KeyOptions {
+	opts := crypto.KeyOptionsFromEnv()
+	if passFile != "" {
+		opts.PassphraseFile = passFile
+	}
+	if plaintextKey {
+		opts.Plaintext = true
+		opts.WriteEcdsaKey = getEnvAsBool(crypto.WriteEcdsaKeyEnv, true)
+	}
+	if writeEcdsaKey {
+		opts.WriteEcdsaKey = true
+	}
+	return opts
+}
+
 func loadConfig(file string) (*Config, error) {
 	var config Config
 	configFile, err := os.ReadFile(file)
@@ -84,6 +119,37 @@ func loadConfig(file string) (*Config, error) {
 	return &config, nil
 }
 
+// saveDerivationPath records the BIP-32 derivation path of a mnemonic based node key in the config file, keeping
+// all other settings as they are
+func saveDerivationPath(file, path string) error {
+	settings := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) && path == "" {
+		return nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return err
+		}
+	}
+	if path == "" {
+		delete(settings, "derivationPath")
+	} else {
+		settings["derivationPath"], err = json.Marshal(path)
+		if err != nil {
+			return err
+		}
+	}
+	data, err = json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
 func getPort(name string) int {
 	valueStr := os.Getenv(name)
 	if value, err := strconv.Atoi(valueStr); err == nil {
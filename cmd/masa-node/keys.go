@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	libp2pCrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/term"
+
+	masa "github.com/masa-finance/masa-oracle/pkg"
+	"github.com/masa-finance/masa-oracle/pkg/crypto"
+)
+
+const keysUsage = `Usage: masa-node keys <command> [flags]
+
+Commands:
+  show     Print the peer ID and Ethereum address of the node key
+  export   Export the node key as protobuf, hex or keystore
+  import   Use an existing Ethereum or libp2p key as the node key
+  rotate   Replace the node key and announce the change signed by the old key
+  mnemonic Create a new node key from a new BIP-39 mnemonic
+  restore  Restore the node key from a BIP-39 mnemonic
+`
+
+func handleKeys(args []string) error {
+	if len(args) == 0 {
+		fmt.Print(keysUsage)
+		return errors.New("missing keys command")
+	}
+	keyFile := os.Getenv(masa.KeyFileKey)
+	switch args[0] {
+	case "show":
+		return showKey(keyFile, args[1:])
+	case "export":
+		return exportKey(keyFile, args[1:])
+	case "import":
+		return importKey(keyFile, args[1:])
+	case "rotate":
+		return rotateKey(keyFile, args[1:])
+	case "mnemonic":
+		return mnemonicKey(keyFile, args[1:])
+	case "restore":
+		return restoreKey(keyFile, args[1:])
+	default:
+		fmt.Print(keysUsage)
+		return fmt.Errorf("unknown keys command %q", args[0])
+	}
+}
+
+func newKeysFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("keys "+name, flag.ExitOnError)
+	fs.StringVar(&passFile, "passphraseFile", passFile, "File containing the passphrase of the encrypted node key")
+	return fs
+}
+
+func showKey(keyFile string, args []string) error {
+	fs := newKeysFlagSet("show")
+	_ = fs.Parse(args)
+
+	privKey, err := crypto.ReadPrivateKey(keyFile, keyOptions())
+	if err != nil {
+		return err
+	}
+	peerId, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		return err
+	}
+	ethAddress, err := crypto.Libp2pPubKeyToEthAddress(privKey.GetPublic())
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	format := crypto.FormatProtobuf
+	if crypto.IsKeystoreFile(data) {
+		format = crypto.FormatKeystore
+	}
+	fmt.Printf("Key file:         %s (%s)\n", keyFile, format)
+	fmt.Printf("Peer ID:          %s\n", peerId)
+	fmt.Printf("Ethereum address: %s\n", ethAddress)
+	if config, err := loadConfig(configFile); err == nil && config.DerivationPath != "" {
+		fmt.Printf("Derivation path:  %s\n", config.DerivationPath)
+	}
+	return nil
+}
+
+func exportKey(keyFile string, args []string) error {
+	fs := newKeysFlagSet("export")
+	format := fs.String("format", crypto.FormatKeystore, "Export format: protobuf, hex or keystore")
+	out := fs.String("out", "", "Write the exported key to this file instead of stdout")
+	exportPassFile := fs.String("exportPassphraseFile", "", "File containing the passphrase for an exported keystore")
+	_ = fs.Parse(args)
+
+	privKey, err := crypto.ReadPrivateKey(keyFile, keyOptions())
+	if err != nil {
+		return err
+	}
+	data, err := crypto.ExportPrivateKey(privKey, *format, crypto.KeyOptions{PassphraseFile: *exportPassFile})
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	if err := os.WriteFile(*out, data, 0600); err != nil {
+		return err
+	}
+	color.Green("Exported node key to %s", *out)
+	return nil
+}
+
+func importKey(keyFile string, args []string) error {
+	fs := newKeysFlagSet("import")
+	file := fs.String("file", "", "File containing a hex encoded Ethereum key, a libp2p key or a keystore, - for stdin. Without it the hex Ethereum key is prompted for")
+	importPassFile := fs.String("importPassphraseFile", "", "File containing the passphrase of an imported keystore")
+	force := fs.Bool("force", false, "Replace an existing node key, the old key file is kept as a backup")
+	_ = fs.Parse(args)
+
+	var data []byte
+	var err error
+	switch *file {
+	case "":
+		var key string
+		if key, err = readSecret("Private key"); err != nil {
+			return err
+		}
+		data = []byte(key)
+	case "-":
+		if data, err = io.ReadAll(os.Stdin); err != nil {
+			return err
+		}
+	default:
+		if data, err = os.ReadFile(*file); err != nil {
+			return err
+		}
+	}
+	privKey, err := crypto.ImportPrivateKey(data, crypto.KeyOptions{PassphraseFile: *importPassFile})
+	if err != nil {
+		return err
+	}
+
+	if err := replaceNodeKey(keyFile, privKey, *force); err != nil {
+		return err
+	}
+	if err := saveDerivationPath(configFile, ""); err != nil {
+		return err
+	}
+	ethAddress, err := crypto.Libp2pPubKeyToEthAddress(privKey.GetPublic())
+	if err != nil {
+		return err
+	}
+	color.Green("Imported node key for %s", ethAddress)
+	return nil
+}
+
+func rotateKey(keyFile string, args []string) error {
+	fs := newKeysFlagSet("rotate")
+	_ = fs.Parse(args)
+
+	rotation, err := crypto.RotatePrivateKey(keyFile, keyOptions())
+	if err != nil {
+		return err
+	}
+	// the new key is random, it can no longer be restored from a mnemonic
+	if err := saveDerivationPath(configFile, ""); err != nil {
+		return err
+	}
+	color.Green("Rotated node key")
+	fmt.Printf("Old peer ID:          %s\n", rotation.OldPeerId)
+	fmt.Printf("New peer ID:          %s\n", rotation.NewPeerId)
+	fmt.Printf("Old Ethereum address: %s\n", rotation.OldEthAddress)
+	fmt.Printf("New Ethereum address: %s\n", rotation.NewEthAddress)
+	fmt.Printf("The change will be announced when the node starts, see %s\n", crypto.KeyRotationFile(keyFile))
+	color.Yellow("Staking is tied to the Ethereum address, the new address has to be staked again")
+	return nil
+}
+
+func mnemonicKey(keyFile string, args []string) error {
+	fs := newKeysFlagSet("mnemonic")
+	path := fs.String("path", crypto.DefaultDerivationPath, "BIP-32 derivation path of the node key")
+	force := fs.Bool("force", false, "Replace an existing node key, the old key file is kept as a backup")
+	_ = fs.Parse(args)
+
+	mnemonic, err := crypto.NewMnemonic()
+	if err != nil {
+		return err
+	}
+	privKey, err := crypto.PrivateKeyFromMnemonic(mnemonic, "", *path)
+	if err != nil {
+		return err
+	}
+	if err := saveMnemonicKey(keyFile, privKey, *path, *force); err != nil {
+		return err
+	}
+	color.Yellow("Write down the mnemonic and keep it offline, it is the only way to recover the node key:")
+	fmt.Printf("\n%s\n\n", mnemonic)
+	return nil
+}
+
+func restoreKey(keyFile string, args []string) error {
+	fs := newKeysFlagSet("restore")
+	path := fs.String("path", "", "BIP-32 derivation path of the node key, defaults to the path in the config file")
+	mnemonicFile := fs.String("mnemonicFile", "", "File containing the mnemonic, otherwise it is read from stdin")
+	force := fs.Bool("force", false, "Replace an existing node key, the old key file is kept as a backup")
+	_ = fs.Parse(args)
+
+	if *path == "" {
+		*path = crypto.DefaultDerivationPath
+		if config, err := loadConfig(configFile); err == nil && config.DerivationPath != "" {
+			*path = config.DerivationPath
+		}
+	}
+	var mnemonic string
+	if *mnemonicFile != "" {
+		data, err := os.ReadFile(*mnemonicFile)
+		if err != nil {
+			return err
+		}
+		mnemonic = string(data)
+	} else {
+		var err error
+		if mnemonic, err = readSecret("Mnemonic"); err != nil {
+			return err
+		}
+	}
+	privKey, err := crypto.PrivateKeyFromMnemonic(mnemonic, "", *path)
+	if err != nil {
+		return err
+	}
+	return saveMnemonicKey(keyFile, privKey, *path, *force)
+}
+
+// readSecret prompts for the secret called name and reads a line from stdin, without echoing it when stdin is a
+// terminal.
+func readSecret(name string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Print(name + ": ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		return line, nil
+	}
+	fmt.Fprint(os.Stderr, name+": ")
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", strings.ToLower(name), err)
+	}
+	return string(data), nil
+}
+
+func saveMnemonicKey(keyFile string, privKey libp2pCrypto.PrivKey, path string, force bool) error {
+	if err := replaceNodeKey(keyFile, privKey, force); err != nil {
+		return err
+	}
+	if err := saveDerivationPath(configFile, path); err != nil {
+		return err
+	}
+	ethAddress, err := crypto.Libp2pPubKeyToEthAddress(privKey.GetPublic())
+	if err != nil {
+		return err
+	}
+	color.Green("Saved node key for %s derived at %s", ethAddress, path)
+	return nil
+}
+
+// replaceNodeKey saves privKey as the node key. An existing key is only replaced when force is set and is kept as
+// a backup next to the key file.
+func replaceNodeKey(keyFile string, privKey libp2pCrypto.PrivKey, force bool) error {
+	_, statErr := os.Stat(keyFile)
+	exists := statErr == nil
+	if exists && !force {
+		return fmt.Errorf("node key %s already exists, use -force to replace it", keyFile)
+	}
+	opts := keyOptions()
+	if !opts.Plaintext && opts.Passphrase == "" && opts.PassphraseFile == "" {
+		// ask once for both the backup and the new keystore
+		passphrase, err := crypto.GetPassphrase(opts, true)
+		if err != nil {
+			return err
+		}
+		opts.Passphrase = passphrase
+	}
+	if exists {
+		backupFile, err := crypto.BackupPrivateKey(keyFile, time.Now(), opts)
+		if err != nil {
+			return err
+		}
+		color.Yellow("Backed up existing node key to %s", backupFile)
+	}
+	if err := crypto.SavePrivateKey(keyFile, privKey, opts); err != nil {
+		return err
+	}
+	if err := os.Remove(keyFile + ".ecdsa"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -2,22 +2,32 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	masa "github.com/masa-finance/masa-oracle/pkg"
+	"github.com/masa-finance/masa-oracle/pkg/api"
+	"github.com/masa-finance/masa-oracle/pkg/auth"
 	"github.com/masa-finance/masa-oracle/pkg/cicd_helpers"
 	"github.com/masa-finance/masa-oracle/pkg/crypto"
 	"github.com/masa-finance/masa-oracle/pkg/routes"
+	"github.com/masa-finance/masa-oracle/pkg/rpc"
 	"github.com/masa-finance/masa-oracle/pkg/staking"
 	"github.com/masa-finance/masa-oracle/pkg/welcome"
 )
@@ -50,9 +60,53 @@ func init() {
 		logrus.Error("Error loading .env file")
 	}
 	os.Setenv(masa.NodeBackupPath, filepath.Join(usr.HomeDir, ".masa", masa.NodeBackupFileName))
+	if os.Getenv(masa.AdStorePath) == "" {
+		os.Setenv(masa.AdStorePath, filepath.Join(usr.HomeDir, ".masa", masa.AdStoreFileName))
+	}
+	if os.Getenv(masa.CADir) == "" {
+		os.Setenv(masa.CADir, filepath.Join(usr.HomeDir, ".masa", "ca"))
+	}
+	if os.Getenv(masa.APIAuditLog) == "" {
+		os.Setenv(masa.APIAuditLog, filepath.Join(usr.HomeDir, ".masa", "api_audit.jsonl"))
+	}
+	if os.Getenv(masa.BanListPath) == "" {
+		os.Setenv(masa.BanListPath, filepath.Join(usr.HomeDir, ".masa", masa.BanListFileName))
+	}
+	if os.Getenv(masa.WebhookQueuePath) == "" {
+		os.Setenv(masa.WebhookQueuePath, filepath.Join(usr.HomeDir, ".masa", masa.WebhookQueueFileName))
+	}
 }
 
 func main() {
+	if flag.Arg(0) == "certs" {
+		// Certificate commands run without starting the node
+		if err := handleCerts(flag.Args()[1:]); err != nil {
+			logrus.Fatal(err)
+		}
+		os.Exit(0)
+	}
+	if flag.Arg(0) == "admin" {
+		// Admin commands call the API of the running node
+		if err := handleAdmin(flag.Args()[1:]); err != nil {
+			logrus.Fatal(err)
+		}
+		os.Exit(0)
+	}
+	if flag.Arg(0) == "records" {
+		// Record commands call the API of the running node
+		if err := handleRecords(flag.Args()[1:]); err != nil {
+			logrus.Fatal(err)
+		}
+		os.Exit(0)
+	}
+	if flag.Arg(0) == "keys" {
+		// Key management commands run without starting the node
+		if err := handleKeys(flag.Args()[1:]); err != nil {
+			logrus.Fatal(err)
+		}
+		os.Exit(0)
+	}
+
 	// log the flags
 	bootnodesList := strings.Split(bootnodes, ",")
 	logrus.Infof("Bootnodes: %v", bootnodesList)
@@ -63,13 +117,18 @@ func main() {
 	// Create a cancellable context
 	ctx, cancel := context.WithCancel(context.Background())
 
-	privKey, ecdsaPrivKey, ethAddress, err := crypto.GetOrCreatePrivateKey(os.Getenv(masa.KeyFileKey))
+	privKey, _, _, err := crypto.GetOrCreatePrivateKeyWithOptions(os.Getenv(masa.KeyFileKey), keyOptions())
 	if err != nil {
 		logrus.Fatal(err)
 	}
+	nodeSigner, err := newSigner(privKey)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	ethAddress := nodeSigner.Address().Hex()
 	if stakeAmount != "" {
 		// Exit after staking, do not proceed to start the node
-		err = handleStaking(ecdsaPrivKey)
+		err = handleStaking(nodeSigner)
 		if err != nil {
 			logrus.Fatal(err)
 		}
@@ -86,8 +145,8 @@ func main() {
 		logrus.Warn("No staking event found for this address")
 	}
 
-	// Pass the isStaked flag to the NewOracleNode function
-	node, err := masa.NewOracleNode(ctx, privKey, portNbr, udp, tcp, isStaked)
+	// Pass the isStaked flag to the NewOracleNode function, the node signs with the same signer that staked
+	node, err := masa.NewOracleNode(ctx, privKey, portNbr, udp, tcp, isStaked, masa.WithSigner(nodeSigner))
 	if err != nil {
 		logrus.Fatal(err)
 	}
@@ -114,13 +173,29 @@ func main() {
 	go func() {
 		<-c
 		node.NodeTracker.DumpNodeData()
+		if err := node.AdStore.Save(); err != nil {
+			logrus.Error(err)
+		}
+		if err := node.PubSubManager.Close(); err != nil {
+			logrus.Error(err)
+		}
 		cancel()
 	}()
 
 	// BP: Add gin router to get peers (multiaddress) and get peer addresses
 	// @Bob - I am not sure if this is the right place for this to live if we end up building out more endpoints
-	router := routes.SetupRoutes(node)
-	go router.Run()
+	router, err := routes.SetupRoutes(node)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if apiTLS, _ := strconv.ParseBool(os.Getenv(masa.APITLS)); apiTLS {
+		go serveAPITLS(ctx, router)
+	} else {
+		go router.Run()
+	}
+	if port := os.Getenv(masa.GRPCPort); port != "" {
+		go serveGRPC(ctx, node, port)
+	}
 
 	<-ctx.Done()
 }
@@ -147,3 +222,55 @@ func setUpFiles(envFilePath, keyFilePath string) error {
 	}
 	return nil
 }
+
+// serveAPITLS serves the API over TLS on the port of the PORT environment variable, 8080 by default, with a
+// certificate issued by the local CA and renewed without restarting.
+func serveAPITLS(ctx context.Context, router http.Handler) {
+	dir := os.Getenv(masa.CADir)
+	certs, err := masa.NewServerCertManager(filepath.Join(dir, "api.pem"), filepath.Join(dir, "api-key.pem"), "masa-node-api")
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	go certs.Run(ctx, time.Hour)
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	server := &http.Server{Addr: ":" + port, Handler: router, TLSConfig: certs.TLSConfig()}
+	logrus.Infof("Serving the API over TLS on port %s, certificate valid until %s", port, certs.Expiry().Format(time.RFC3339))
+	if err := server.ListenAndServeTLS("", ""); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// serveGRPC serves the gRPC API on port until ctx is done, over TLS with a certificate of the local CA when the
+// HTTP API uses TLS too.
+func serveGRPC(ctx context.Context, node *masa.OracleNode, port string) {
+	authenticator, err := routes.NewAuthenticator()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	var opts []grpc.ServerOption
+	if apiTLS, _ := strconv.ParseBool(os.Getenv(masa.APITLS)); apiTLS {
+		dir := os.Getenv(masa.CADir)
+		certs, err := masa.NewServerCertManager(filepath.Join(dir, "grpc.pem"), filepath.Join(dir, "grpc-key.pem"), "masa-node-grpc")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		go certs.Run(ctx, time.Hour)
+		opts = append(opts, grpc.Creds(credentials.NewTLS(certs.TLSConfig())))
+	}
+	server := rpc.NewServer(api.NewAPI(node), authenticator, auth.NewAuditLog(os.Getenv(masa.APIAuditLog)), opts...)
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+	logrus.Infof("Serving the gRPC API on port %s", port)
+	if err := server.Serve(listener); err != nil {
+		logrus.Fatal(err)
+	}
+}
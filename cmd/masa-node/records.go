@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+)
+
+const recordsUsage = `Usage: masa-node records <command> [flags] [arguments]
+
+Commands:
+  put <name> <JSON value>   Sign a record of the node and store it on the DHT for -ttl (24h by default). The
+                            version is the one after the stored record's, or -version
+  get <peer ID> <name>      Look up the record of a peer on the DHT
+
+Records are stored under /masa/<peer ID>/<name> and only accepted from staked nodes. The commands call the API of
+the running node like the admin commands, putting records needs the publisher role.
+`
+
+func handleRecords(args []string) error {
+	if len(args) == 0 {
+		fmt.Print(recordsUsage)
+		return errors.New("missing records command")
+	}
+	command, args := args[0], args[1:]
+	flags := newAPIFlags("records " + command)
+	ttl := time.Duration(0)
+	version := uint64(0)
+	if command == "put" {
+		flags.fs.DurationVar(&ttl, "ttl", 0, "How long the record lives, 24h by default")
+		flags.fs.Uint64Var(&version, "version", 0, "Version of the record, the one after the stored record's by default")
+	}
+	_ = flags.fs.Parse(args)
+	args = flags.fs.Args()
+	c, err := flags.client()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "put":
+		if len(args) != 2 {
+			return errors.New("records put takes the name and the JSON value of the record")
+		}
+		if !json.Valid([]byte(args[1])) {
+			return errors.New("the value must be JSON, quote strings like '\"text\"'")
+		}
+		request := v1.RecordRequest{Value: json.RawMessage(args[1]), Version: version}
+		if ttl != 0 {
+			request.TTL = ttl.String()
+		}
+		record, err := c.PutRecord(ctx, args[0], request)
+		if err != nil {
+			return err
+		}
+		color.Green("Put %s version %d until %s", record.Key, record.Version, record.Expires.Format(time.RFC3339))
+	case "get":
+		if len(args) != 2 {
+			return errors.New("records get takes the peer ID and the name of the record")
+		}
+		record, err := c.GetRecord(ctx, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s  version %d  until %s\n%s\n", record.Key, record.Version, record.Expires.Format(time.RFC3339), record.Value)
+	default:
+		fmt.Print(recordsUsage)
+		return fmt.Errorf("unknown records command %q", command)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	libp2pCrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/sirupsen/logrus"
+
+	masa "github.com/masa-finance/masa-oracle/pkg"
+	"github.com/masa-finance/masa-oracle/pkg/crypto"
+	"github.com/masa-finance/masa-oracle/pkg/signer"
+)
+
+const (
+	signerMemory   = "memory"
+	signerKeystore = "keystore"
+	signerExternal = "external"
+)
+
+// newSigner creates the signer used for staking and all other signing, selected with --signer or SIGNER.
+// The memory signer signs with the node key, which the node always holds for its libp2p identity. The keystore
+// signer signs with the key of a separate keystore, the external signer keeps the staking key out of the process.
+func newSigner(privKey libp2pCrypto.PrivKey) (signer.Signer, error) {
+	signerType := signerKind
+	if signerType == "" {
+		signerType = os.Getenv("SIGNER")
+	}
+	switch signerType {
+	case "", signerMemory:
+		ecdsaPrivKey, err := crypto.Libp2pPrivateKeyToEcdsa(privKey)
+		if err != nil {
+			return nil, err
+		}
+		return signer.NewPrivateKeySigner(ecdsaPrivKey), nil
+	case signerKeystore:
+		path := signerKeystorePath
+		if path == "" {
+			path = os.Getenv("SIGNER_KEYSTORE")
+		}
+		if path == "" {
+			return nil, fmt.Errorf("the keystore signer requires --signerKeystore or SIGNER_KEYSTORE")
+		}
+		if filepath.Clean(path) == filepath.Clean(os.Getenv(masa.KeyFileKey)) {
+			return nil, fmt.Errorf("the keystore signer needs a keystore other than the node key %s", path)
+		}
+		opts := crypto.KeyOptions{PassphraseFile: signerPassFile}
+		if opts.PassphraseFile == "" {
+			opts.PassphraseFile = os.Getenv("SIGNER_PASSPHRASE_FILE")
+		}
+		passphrase, err := crypto.GetPassphrase(opts, false)
+		if err != nil {
+			return nil, err
+		}
+		s, err := signer.NewKeystoreSigner(path, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		logrus.Infof("Using keystore signer %s for %s", path, s.Address().Hex())
+		return s, nil
+	case signerExternal:
+		endpoint := signerEndpoint
+		if endpoint == "" {
+			endpoint = os.Getenv("SIGNER_ENDPOINT")
+		}
+		if endpoint == "" {
+			return nil, fmt.Errorf("the external signer requires --signerEndpoint or SIGNER_ENDPOINT")
+		}
+		address := signerAddress
+		if address == "" {
+			address = os.Getenv("SIGNER_ADDRESS")
+		}
+		s, err := signer.NewExternalSigner(endpoint, address)
+		if err != nil {
+			return nil, err
+		}
+		logrus.Infof("Using external signer %s for %s", endpoint, s.Address().Hex())
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown signer %q, expected %s, %s or %s", signerType, signerMemory, signerKeystore, signerExternal)
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"crypto/ecdsa"
 	"fmt"
 	"math/big"
 	"time"
@@ -9,10 +8,11 @@ import (
 	"github.com/fatih/color"
 	"github.com/sirupsen/logrus"
 
+	"github.com/masa-finance/masa-oracle/pkg/signer"
 	"github.com/masa-finance/masa-oracle/pkg/staking"
 )
 
-func handleStaking(privateKey *ecdsa.PrivateKey) error {
+func handleStaking(s signer.Signer) error {
 	// Staking logic
 	// Convert the stake amount to the smallest unit, assuming 18 decimal places
 	amountBigInt, ok := new(big.Int).SetString(stakeAmount, 10)
@@ -21,7 +21,7 @@ func handleStaking(privateKey *ecdsa.PrivateKey) error {
 	}
 	amountInSmallestUnit := new(big.Int).Mul(amountBigInt, big.NewInt(1e18))
 
-	stakingClient, err := staking.NewClient(privateKey)
+	stakingClient, err := staking.NewClient(s)
 	if err != nil {
 		return err
 	}
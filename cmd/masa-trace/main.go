@@ -0,0 +1,213 @@
+/*
+Package main - masa-trace
+
+masa-trace summarizes the pubsub trace files written by nodes started with the pubsubTracePath environment variable.
+Given the trace files of one or more nodes, including rotated files, it reports per topic:
+
+1. How many messages were published, delivered, rejected and received as duplicates, and the duplicate rate.
+
+2. The propagation latency from publishing to delivery on the other nodes, for messages published by a traced node.
+
+3. The graft and prune counts of the mesh.
+
+4. For every traced node, the share of the messages published by the other traced nodes it delivered. A node that
+never receives some topic stands out here.
+
+Example usage:
+masa-trace -topic /masa/gossip/v.0.0.3-alpha node1/trace.jsonl* node2/trace.jsonl*
+*/
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/masa-finance/masa-oracle/pkg/pubsub"
+)
+
+type topicSummary struct {
+	published  int
+	delivered  int
+	duplicates int
+	rejected   map[string]int
+	grafts     int
+	prunes     int
+	latencies  []time.Duration
+	// publishers maps the published message ids to the node publishing them
+	publishers map[string]string
+	// deliveries maps each node to the message ids it delivered
+	deliveries map[string]map[string]bool
+}
+
+func newTopicSummary() *topicSummary {
+	return &topicSummary{
+		rejected:   make(map[string]int),
+		publishers: make(map[string]string),
+		deliveries: make(map[string]map[string]bool),
+	}
+}
+
+func readTraces(files []string, topic string) ([]pubsub.TraceRecord, error) {
+	var records []pubsub.TraceRecord
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			var record pubsub.TraceRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				log.Printf("skipping %s:%d: %v", name, line, err)
+				continue
+			}
+			if topic == "" || record.Topic == topic {
+				records = append(records, record)
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Timestamp < records[j].Timestamp })
+	return records, nil
+}
+
+func summarize(records []pubsub.TraceRecord) map[string]*topicSummary {
+	summaries := make(map[string]*topicSummary)
+	published := make(map[string]int64)
+	for _, record := range records {
+		summary, ok := summaries[record.Topic]
+		if !ok {
+			summary = newTopicSummary()
+			summaries[record.Topic] = summary
+		}
+		if _, ok := summary.deliveries[record.Peer]; !ok {
+			summary.deliveries[record.Peer] = make(map[string]bool)
+		}
+		switch record.Type {
+		case pubsub.TracePublish:
+			summary.published++
+			summary.publishers[record.MessageID] = record.Peer
+			published[record.MessageID] = record.Timestamp
+		case pubsub.TraceDeliver:
+			summary.delivered++
+			summary.deliveries[record.Peer][record.MessageID] = true
+			// a node delivers its own messages too, that is not propagation
+			if at, ok := published[record.MessageID]; ok && summary.publishers[record.MessageID] != record.Peer {
+				summary.latencies = append(summary.latencies, time.Duration(record.Timestamp-at))
+			}
+		case pubsub.TraceDuplicate:
+			summary.duplicates++
+		case pubsub.TraceReject:
+			summary.rejected[record.Reason]++
+		case pubsub.TraceGraft:
+			summary.grafts++
+		case pubsub.TracePrune:
+			summary.prunes++
+		}
+	}
+	return summaries
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
+
+func printSummary(w *tabwriter.Writer, topic string, summary *topicSummary) {
+	fmt.Fprintf(w, "Topic %s\n", topic)
+	duplicateRate := 0.0
+	if summary.delivered > 0 {
+		duplicateRate = float64(summary.duplicates) / float64(summary.delivered)
+	}
+	fmt.Fprintf(w, "  published\tdelivered\tduplicates\tduplicate rate\tgrafts\tprunes\n")
+	fmt.Fprintf(w, "  %d\t%d\t%d\t%.2f\t%d\t%d\n", summary.published, summary.delivered, summary.duplicates,
+		duplicateRate, summary.grafts, summary.prunes)
+	for reason, count := range summary.rejected {
+		fmt.Fprintf(w, "  rejected (%s)\t%d\n", reason, count)
+	}
+	// each table is aligned on its own
+	_ = w.Flush()
+
+	latencies := summary.latencies
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	if len(latencies) > 0 {
+		fmt.Fprintf(w, "  latency\tp50\tp90\tp99\tmax\n")
+		fmt.Fprintf(w, "  %d deliveries\t%s\t%s\t%s\t%s\n", len(latencies), percentile(latencies, 0.5),
+			percentile(latencies, 0.9), percentile(latencies, 0.99), latencies[len(latencies)-1])
+		_ = w.Flush()
+	}
+
+	if summary.published > 0 {
+		fmt.Fprintf(w, "  node\tdelivered\tof published by others\tcoverage\n")
+		nodes := make([]string, 0, len(summary.deliveries))
+		for node := range summary.deliveries {
+			nodes = append(nodes, node)
+		}
+		sort.Strings(nodes)
+		for _, node := range nodes {
+			expected, delivered := 0, 0
+			for id, publisher := range summary.publishers {
+				if publisher == node {
+					continue
+				}
+				expected++
+				if summary.deliveries[node][id] {
+					delivered++
+				}
+			}
+			if expected == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "  %s\t%d\t%d\t%.0f%%\n", node, delivered, expected, 100*float64(delivered)/float64(expected))
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func main() {
+	topic := flag.String("topic", "", "Only summarize this topic")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-topic topic] trace-file...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	records, err := readTraces(flag.Args(), *topic)
+	if err != nil {
+		log.Fatal(err)
+	}
+	summaries := summarize(records)
+	topics := make([]string, 0, len(summaries))
+	for name := range summaries {
+		topics = append(topics, name)
+	}
+	sort.Strings(topics)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%d events in %d topics\n\n", len(records), len(topics))
+	for _, name := range topics {
+		printSummary(w, name, summaries[name])
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -119,7 +119,10 @@ func main() {
 
 	// BP: Add gin router to get peers (multiaddress) and get peer addresses
 	// @Bob - I am not sure if this is the right place for this to live if we end up building out more endpoints
-	router := routes.SetupRoutes(node)
+	router, err := routes.SetupRoutes(node)
+	if err != nil {
+		logrus.Fatal(err)
+	}
 	go router.Run()
 
 	<-ctx.Done()
@@ -2,31 +2,79 @@ package ad
 
 import (
 	"encoding/json"
+	"time"
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/sirupsen/logrus"
 )
 
+// Ad is a published ad. Legacy ads (Version 0) only have Content and Metadata, ads of SchemaVersion have the
+// structured fields. The structured fields are omitted when empty so legacy ads encode as they always did.
 type Ad struct {
-	Content  string
-	Metadata map[string]string
+	Version   int        `json:",omitempty"`
+	Title     string     `json:",omitempty"`
+	Body      string     `json:",omitempty"`
+	Tags      []string   `json:",omitempty"`
+	StartTime *time.Time `json:",omitempty"`
+	EndTime   *time.Time `json:",omitempty"`
+	Budget    *Budget    `json:",omitempty"`
+	Media     []MediaRef `json:",omitempty"`
+	Content   string
+	Metadata  map[string]string
+	// ExpiresAt is set by the publisher, ads without it or with a longer lifetime expire after the store's TTL
+	ExpiresAt   time.Time
+	ReceivedAt  time.Time
+	ContentHash string
+	// Publisher is the verified Ethereum address that signed the ad
+	Publisher string
+}
+
+// Budget is the amount an advertiser spends on an ad, as a decimal string to avoid rounding.
+type Budget struct {
+	Amount   string
+	Currency string
+}
+
+// MediaRef refers to an image or video by the SHA-256 hash of its content, so receivers can verify what they fetch.
+type MediaRef struct {
+	Hash string
+	Type string
+	URI  string `json:",omitempty"`
 }
 
 type SubscriptionHandler struct {
-	Ads     []Ad
+	Store   *Store
 	AdTopic *pubsub.Topic
 }
 
+func NewSubscriptionHandler(store *Store) *SubscriptionHandler {
+	return &SubscriptionHandler{Store: store}
+}
+
 // HandleMessage implement subscription handler here
 func (handler *SubscriptionHandler) HandleMessage(message *pubsub.Message) {
-	var ad Ad
-	err := json.Unmarshal(message.Data, &ad)
-	if err != nil {
-		logrus.Errorf("failed to unmarshal message: %v", err)
+	signedAd, ok := message.ValidatorData.(*SignedAd)
+	if !ok {
+		// the message did not pass through the ad validator, verify it here
+		signedAd = &SignedAd{}
+		if err := json.Unmarshal(message.Data, signedAd); err != nil {
+			logrus.Errorf("failed to unmarshal message: %v", err)
+			return
+		}
+		if err := signedAd.Verify(); err != nil {
+			logrus.Errorf("failed to verify ad: %v", err)
+			return
+		}
+	}
+	ad := signedAd.Ad
+	ad.Publisher = signedAd.Publisher
+	// the receiving node decides when an ad arrived
+	ad.ReceivedAt = time.Time{}
+	if !handler.Store.Add(ad) { // Add the ad to the store
+		logrus.Debugf("ignored duplicate or expired ad: %s", ad.Hash())
 		return
 	}
-	handler.Ads = append(handler.Ads, ad) // Add the ad to the list
 
 	// Handle the ad here
-	logrus.Infof("received ad: %v", ad)
+	logrus.Infof("received ad from %s: %v", ad.Publisher, ad)
 }
@@ -0,0 +1,73 @@
+package ad
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/masa-finance/masa-oracle/pkg/signer"
+)
+
+// SignedAd is the envelope ads are published in. The publisher signs the ad together with a timestamp and nonce
+// using personal_sign so receivers can check who published it and reject replays.
+type SignedAd struct {
+	Ad        Ad            `json:"ad"`
+	Publisher string        `json:"publisher"`
+	Timestamp time.Time     `json:"timestamp"`
+	Nonce     string        `json:"nonce"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// NewSignedAd wraps the ad in an envelope signed by s.
+func NewSignedAd(ad Ad, s signer.Signer) (*SignedAd, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	// receiver side fields are never part of a published ad
+	ad.ReceivedAt = time.Time{}
+	ad.ContentHash = ""
+	ad.Publisher = ""
+	signedAd := &SignedAd{
+		Ad:        ad,
+		Publisher: s.Address().Hex(),
+		Timestamp: time.Now().UTC(),
+		Nonce:     hex.EncodeToString(nonce),
+	}
+	payload, err := signedAd.signingPayload()
+	if err != nil {
+		return nil, err
+	}
+	signedAd.Signature, err = s.SignText(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign ad: %w", err)
+	}
+	return signedAd, nil
+}
+
+// Verify checks that the envelope was signed by its publisher.
+func (s *SignedAd) Verify() error {
+	if !common.IsHexAddress(s.Publisher) {
+		return fmt.Errorf("invalid publisher address %q", s.Publisher)
+	}
+	payload, err := s.signingPayload()
+	if err != nil {
+		return err
+	}
+	if !signer.VerifyText(common.HexToAddress(s.Publisher), payload, s.Signature) {
+		return errors.New("invalid ad signature")
+	}
+	return nil
+}
+
+func (s *SignedAd) signingPayload() ([]byte, error) {
+	unsigned := *s
+	unsigned.Signature = nil
+	return json.Marshal(unsigned)
+}
@@ -0,0 +1,161 @@
+package ad
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SortByReceivedAt = "receivedAt"
+	SortByExpiresAt  = "expiresAt"
+	SortByPublisher  = "publisher"
+)
+
+// Query selects ads from the store. Empty fields do not filter.
+type Query struct {
+	// Metadata matches ads having all the given key value pairs
+	Metadata map[string]string
+	// Publisher matches the verified publisher address, case insensitive
+	Publisher string
+	// Since and Until bound the time the ad was received
+	Since time.Time
+	Until time.Time
+	// Text matches ads whose content, title or body contains the text, case insensitive
+	Text string
+	// SortBy is one of the SortBy constants, SortByReceivedAt by default
+	SortBy     string
+	Descending bool
+	// Cursor continues after the last ad of a previous page
+	Cursor string
+	Limit  int
+}
+
+// Page is one page of query results. NextCursor is empty on the last page.
+type Page struct {
+	Ads        []Ad
+	NextCursor string
+	Total      int
+}
+
+type cursor struct {
+	Key  string `json:"k"`
+	Hash string `json:"h"`
+}
+
+// Query returns the ads matching q, sorted and paginated. Cursors stay valid while ads are added or removed.
+func (s *Store) Query(q Query) (Page, error) {
+	keyFn, err := sortKey(q.SortBy)
+	if err != nil {
+		return Page{}, err
+	}
+	var after *cursor
+	if q.Cursor != "" {
+		after, err = decodeCursor(q.Cursor)
+		if err != nil {
+			return Page{}, err
+		}
+	}
+
+	matches := make([]Ad, 0)
+	for _, ad := range s.List() {
+		if q.Matches(ad) {
+			matches = append(matches, ad)
+		}
+	}
+	less := func(keyA, hashA, keyB, hashB string) bool {
+		if q.Descending {
+			keyA, hashA, keyB, hashB = keyB, hashB, keyA, hashA
+		}
+		if keyA != keyB {
+			return keyA < keyB
+		}
+		return hashA < hashB
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return less(keyFn(matches[i]), matches[i].ContentHash, keyFn(matches[j]), matches[j].ContentHash)
+	})
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(matches), func(i int) bool {
+			return less(after.Key, after.Hash, keyFn(matches[i]), matches[i].ContentHash)
+		})
+	}
+	end := len(matches)
+	if q.Limit > 0 && start+q.Limit < end {
+		end = start + q.Limit
+	}
+	page := Page{
+		Ads:   matches[start:end],
+		Total: len(matches),
+	}
+	if end < len(matches) {
+		last := matches[end-1]
+		page.NextCursor = encodeCursor(cursor{Key: keyFn(last), Hash: last.ContentHash})
+	}
+	return page, nil
+}
+
+// Matches reports whether the ad passes the filters of the query, ignoring sorting and pagination.
+func (q *Query) Matches(ad Ad) bool {
+	for key, value := range q.Metadata {
+		if ad.Metadata[key] != value {
+			return false
+		}
+	}
+	if q.Publisher != "" && !strings.EqualFold(q.Publisher, ad.Publisher) {
+		return false
+	}
+	if !q.Since.IsZero() && ad.ReceivedAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && ad.ReceivedAt.After(q.Until) {
+		return false
+	}
+	if q.Text != "" {
+		text := strings.ToLower(q.Text)
+		if !strings.Contains(strings.ToLower(ad.Content), text) && !strings.Contains(strings.ToLower(ad.Title), text) &&
+			!strings.Contains(strings.ToLower(ad.Body), text) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortKey returns a function mapping an ad to a string that sorts in the requested order.
+func sortKey(sortBy string) (func(Ad) string, error) {
+	// fixed width so the formatted times sort lexically
+	const sortableTime = "2006-01-02T15:04:05.000000000Z"
+	switch sortBy {
+	case "", SortByReceivedAt:
+		return func(ad Ad) string { return ad.ReceivedAt.UTC().Format(sortableTime) }, nil
+	case SortByExpiresAt:
+		return func(ad Ad) string { return ad.ExpiresAt.UTC().Format(sortableTime) }, nil
+	case SortByPublisher:
+		return func(ad Ad) string { return strings.ToLower(ad.Publisher) }, nil
+	default:
+		return nil, fmt.Errorf("cannot sort by %q, expected %s, %s or %s", sortBy, SortByReceivedAt, SortByExpiresAt, SortByPublisher)
+	}
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &c, nil
+}
@@ -0,0 +1,58 @@
+package ad
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestQueryFilterAndPaginate(t *testing.T) {
+	store := NewStore(100, time.Hour, "")
+	start := time.Now().Add(-time.Minute)
+	for i := 0; i < 10; i++ {
+		category := "shoes"
+		if i%2 == 1 {
+			category = "hats"
+		}
+		store.Add(Ad{
+			Content:    fmt.Sprintf("Ad number %d", i),
+			Metadata:   map[string]string{"category": category},
+			Publisher:  "0xabc",
+			ReceivedAt: start.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	query := Query{Metadata: map[string]string{"category": "shoes"}, Descending: true, Limit: 2}
+	var contents []string
+	for {
+		page, err := store.Query(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 5 {
+			t.Fatalf("expected 5 matching ads, got %d", page.Total)
+		}
+		for _, ad := range page.Ads {
+			contents = append(contents, ad.Content)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	expected := []string{"Ad number 8", "Ad number 6", "Ad number 4", "Ad number 2", "Ad number 0"}
+	if fmt.Sprint(contents) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, contents)
+	}
+
+	page, err := store.Query(Query{Text: "NUMBER 3", Publisher: "0xABC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Ads) != 1 || page.Ads[0].Content != "Ad number 3" {
+		t.Errorf("expected the text and publisher filters to match one ad, got %v", page.Ads)
+	}
+	if _, err := store.Query(Query{SortBy: "content"}); err == nil {
+		t.Error("expected an unknown sort field to be rejected")
+	}
+}
@@ -0,0 +1,203 @@
+package ad
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	DefaultAdsPerMinute = 10
+	DefaultAdBurst      = 5
+	DefaultDailyQuota   = 1000
+)
+
+var (
+	ErrRateLimited   = errors.New("publisher exceeded the ad rate limit")
+	ErrQuotaExceeded = errors.New("publisher exceeded the daily ad quota")
+)
+
+// RateLimitConfig configures the limits applied to each publisher.
+type RateLimitConfig struct {
+	AdsPerMinute float64 `json:"adsPerMinute"`
+	Burst        int     `json:"burst"`
+	DailyQuota   int     `json:"dailyQuota"`
+	// StakeUnit enables stake weighting when set: a publisher's limits are multiplied by its stake divided by
+	// StakeUnit, at least once and at most MaxStakeMultiplier times.
+	StakeUnit          *big.Int `json:"stakeUnit,omitempty"`
+	MaxStakeMultiplier float64  `json:"maxStakeMultiplier"`
+}
+
+// PublisherStats reports how many ads of a publisher were accepted and rejected.
+type PublisherStats struct {
+	Publisher     string  `json:"publisher"`
+	Multiplier    float64 `json:"multiplier"`
+	Accepted      uint64  `json:"accepted"`
+	RateLimited   uint64  `json:"rateLimited"`
+	QuotaExceeded uint64  `json:"quotaExceeded"`
+	UsedToday     int     `json:"usedToday"`
+}
+
+type publisherLimit struct {
+	limiter *rate.Limiter
+	day     time.Time
+	stats   PublisherStats
+}
+
+// RateLimiter limits the rate and the daily number of ads per publisher. It is safe for concurrent use.
+type RateLimiter struct {
+	config     RateLimitConfig
+	stake      func(address string) (*big.Int, error)
+	mutex      sync.Mutex
+	publishers map[string]*publisherLimit
+}
+
+// NewRateLimiter creates a rate limiter. Zero config values select the defaults, stake returns the staked amount
+// of a publisher and is only used when config.StakeUnit is set.
+func NewRateLimiter(config RateLimitConfig, stake func(address string) (*big.Int, error)) *RateLimiter {
+	if config.AdsPerMinute <= 0 {
+		config.AdsPerMinute = DefaultAdsPerMinute
+	}
+	if config.Burst <= 0 {
+		config.Burst = DefaultAdBurst
+	}
+	if config.DailyQuota <= 0 {
+		config.DailyQuota = DefaultDailyQuota
+	}
+	if config.MaxStakeMultiplier < 1 {
+		config.MaxStakeMultiplier = 1
+	}
+	return &RateLimiter{
+		config:     config,
+		stake:      stake,
+		publishers: make(map[string]*publisherLimit),
+	}
+}
+
+// Allow records an ad of publisher and returns ErrRateLimited or ErrQuotaExceeded when it is over its limits.
+func (r *RateLimiter) Allow(publisher string) error {
+	_, err := r.Reserve(publisher)
+	return err
+}
+
+// Reservation is an ad counted against the limits of a publisher by Reserve.
+type Reservation struct {
+	limiter *RateLimiter
+	limit   *publisherLimit
+	tokens  *rate.Reservation
+	day     time.Time
+	once    sync.Once
+}
+
+// Reserve checks the limits of publisher and counts an ad in one step, so concurrent publishes cannot all pass the
+// check before any of them is counted. It returns ErrRateLimited or ErrQuotaExceeded when the ad is over the limits.
+// Publishers cancel the reservation when the ad could not be published, so failed publishes do not use the quota.
+func (r *RateLimiter) Reserve(publisher string) (*Reservation, error) {
+	multiplier := r.multiplier(publisher)
+	now := time.Now().UTC()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	limit := r.limitOf(publisher, multiplier, now)
+	if limit.stats.UsedToday >= int(float64(r.config.DailyQuota)*multiplier) {
+		limit.stats.QuotaExceeded++
+		return nil, ErrQuotaExceeded
+	}
+	tokens := limit.limiter.ReserveN(now, 1)
+	if !tokens.OK() || tokens.DelayFrom(now) > 0 {
+		tokens.CancelAt(now)
+		limit.stats.RateLimited++
+		return nil, ErrRateLimited
+	}
+	limit.stats.UsedToday++
+	limit.stats.Accepted++
+	return &Reservation{limiter: r, limit: limit, tokens: tokens, day: limit.day}, nil
+}
+
+// Cancel gives the reserved ad back to the limits of the publisher. Only the first call has an effect.
+func (res *Reservation) Cancel() {
+	res.once.Do(func() {
+		res.limiter.mutex.Lock()
+		defer res.limiter.mutex.Unlock()
+		res.tokens.Cancel()
+		if res.limit.day.Equal(res.day) {
+			res.limit.stats.UsedToday--
+		}
+		res.limit.stats.Accepted--
+	})
+}
+
+// limitOf returns the limits of publisher, scaled by multiplier and with the daily count of now. The caller holds
+// the mutex.
+func (r *RateLimiter) limitOf(publisher string, multiplier float64, now time.Time) *publisherLimit {
+	today := now.Truncate(24 * time.Hour)
+	key := strings.ToLower(publisher)
+	limit, ok := r.publishers[key]
+	perSecond := rate.Limit(r.config.AdsPerMinute * multiplier / 60)
+	burst := int(float64(r.config.Burst) * multiplier)
+	if !ok {
+		limit = &publisherLimit{
+			limiter: rate.NewLimiter(perSecond, burst),
+			stats:   PublisherStats{Publisher: publisher, Multiplier: multiplier},
+		}
+		r.publishers[key] = limit
+	} else if limit.stats.Multiplier != multiplier {
+		// the stake changed
+		limit.stats.Multiplier = multiplier
+		limit.limiter.SetLimitAt(now, perSecond)
+		limit.limiter.SetBurstAt(now, burst)
+	}
+	if !limit.day.Equal(today) {
+		limit.day = today
+		limit.stats.UsedToday = 0
+	}
+	return limit
+}
+
+// multiplier returns by how much the limits of publisher are scaled by its stake.
+func (r *RateLimiter) multiplier(publisher string) float64 {
+	if r.config.StakeUnit == nil || r.config.StakeUnit.Sign() <= 0 || r.stake == nil {
+		return 1
+	}
+	amount, err := r.stake(publisher)
+	if err != nil || amount == nil {
+		return 1
+	}
+	multiplier, _ := new(big.Float).Quo(new(big.Float).SetInt(amount), new(big.Float).SetInt(r.config.StakeUnit)).Float64()
+	if multiplier < 1 {
+		return 1
+	}
+	if multiplier > r.config.MaxStakeMultiplier {
+		return r.config.MaxStakeMultiplier
+	}
+	return multiplier
+}
+
+// Stats returns the counts of every publisher seen, the most rejected first.
+func (r *RateLimiter) Stats() []PublisherStats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	stats := make([]PublisherStats, 0, len(r.publishers))
+	for _, limit := range r.publishers {
+		stats = append(stats, limit.stats)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		rejectedI := stats[i].RateLimited + stats[i].QuotaExceeded
+		rejectedJ := stats[j].RateLimited + stats[j].QuotaExceeded
+		if rejectedI != rejectedJ {
+			return rejectedI > rejectedJ
+		}
+		return stats[i].Publisher < stats[j].Publisher
+	})
+	return stats
+}
+
+// Config returns the limits in effect.
+func (r *RateLimiter) Config() RateLimitConfig {
+	return r.config
+}
@@ -0,0 +1,109 @@
+package ad
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{AdsPerMinute: 1, Burst: 2, DailyQuota: 100}, nil)
+	for i := 0; i < 2; i++ {
+		if err := limiter.Allow("0xabc"); err != nil {
+			t.Fatalf("expected ad %d within the burst to be allowed, got %v", i, err)
+		}
+	}
+	if err := limiter.Allow("0xABC"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected the publisher to be rate limited, got %v", err)
+	}
+	if err := limiter.Allow("0xdef"); err != nil {
+		t.Errorf("expected other publishers not to be limited, got %v", err)
+	}
+
+	stats := limiter.Stats()
+	if len(stats) != 2 || stats[0].Publisher != "0xabc" || stats[0].Accepted != 2 || stats[0].RateLimited != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestRateLimiterQuota(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{AdsPerMinute: 6000, Burst: 100, DailyQuota: 3}, nil)
+	for i := 0; i < 3; i++ {
+		if err := limiter.Allow("0xabc"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := limiter.Allow("0xabc"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected the daily quota to be exceeded, got %v", err)
+	}
+}
+
+func TestRateLimiterStakeWeighted(t *testing.T) {
+	stakes := map[string]*big.Int{"0xabc": big.NewInt(300), "0xdef": big.NewInt(5000)}
+	limiter := NewRateLimiter(RateLimitConfig{
+		AdsPerMinute:       1,
+		Burst:              2,
+		DailyQuota:         100,
+		StakeUnit:          big.NewInt(100),
+		MaxStakeMultiplier: 10,
+	}, func(address string) (*big.Int, error) {
+		return stakes[address], nil
+	})
+	allowed := func(publisher string) int {
+		count := 0
+		for limiter.Allow(publisher) == nil {
+			count++
+		}
+		return count
+	}
+	if count := allowed("0xabc"); count != 6 {
+		t.Errorf("expected a stake of 3 units to triple the burst, got %d", count)
+	}
+	if count := allowed("0xdef"); count != 20 {
+		t.Errorf("expected the multiplier to be capped at 10, got %d", count)
+	}
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{AdsPerMinute: 6000, Burst: 100, DailyQuota: 2}, nil)
+	for i := 0; i < 5; i++ {
+		res, err := limiter.Reserve("0xabc")
+		if err != nil {
+			t.Fatalf("expected cancelled reservations to leave the quota, got %v", err)
+		}
+		res.Cancel()
+		res.Cancel()
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := limiter.Reserve("0xabc"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := limiter.Reserve("0xabc"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected the reserved ads to use the quota, got %v", err)
+	}
+	if stats := limiter.Stats(); stats[0].Accepted != 2 || stats[0].UsedToday != 2 {
+		t.Errorf("unexpected stats %+v", stats[0])
+	}
+}
+
+func TestRateLimiterReserveConcurrent(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{AdsPerMinute: 1, Burst: 3, DailyQuota: 100}, nil)
+	var wg sync.WaitGroup
+	var allowed atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := limiter.Reserve("0xabc"); err == nil {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed.Load() != 3 {
+		t.Errorf("expected only the burst of 3 concurrent ads to be allowed, got %d", allowed.Load())
+	}
+}
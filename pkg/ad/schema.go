@@ -0,0 +1,155 @@
+package ad
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// SchemaVersion is the version of the ad schema new ads are published with.
+const SchemaVersion = 1
+
+//go:embed schema/*.json
+var schemaFiles embed.FS
+
+// schemas holds the compiled JSON schema of each ad version, version 0 being the legacy format.
+var schemas = mustCompileSchemas()
+
+func mustCompileSchemas() map[int]*jsonschema.Schema {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+	compiled := make(map[int]*jsonschema.Schema)
+	for version := 0; version <= SchemaVersion; version++ {
+		name := fmt.Sprintf("schema/ad.v%d.json", version)
+		data, err := schemaFiles.ReadFile(name)
+		if err != nil {
+			panic(err)
+		}
+		if err := compiler.AddResource(name, bytes.NewReader(data)); err != nil {
+			panic(err)
+		}
+		compiled[version] = compiler.MustCompile(name)
+	}
+	return compiled
+}
+
+// Schema returns the JSON schema document of the given ad version.
+func Schema(version int) ([]byte, error) {
+	return schemaFiles.ReadFile(fmt.Sprintf("schema/ad.v%d.json", version))
+}
+
+// FieldError describes why the value at Field, a JSON pointer into the ad, is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of an ad.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		messages[i] = fmt.Sprintf("%s: %s", fieldError.Field, fieldError.Message)
+	}
+	return "invalid ad: " + strings.Join(messages, "; ")
+}
+
+// Decode validates data against the schema of its version and decodes it for publishing. Data without a Version is
+// decoded as a legacy ad. Validation failures are returned as a *ValidationError.
+func Decode(data []byte) (Ad, error) {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return Ad{}, &ValidationError{Errors: []FieldError{{Field: "/", Message: err.Error()}}}
+	}
+	if err := validateDocument(document); err != nil {
+		return Ad{}, err
+	}
+	var ad Ad
+	if err := json.Unmarshal(data, &ad); err != nil {
+		return Ad{}, err
+	}
+	if err := ad.validateFields(); err != nil {
+		return Ad{}, err
+	}
+	if ad.EndTime != nil && ad.EndTime.Before(time.Now()) {
+		return Ad{}, &ValidationError{Errors: []FieldError{{Field: "/EndTime", Message: "must be in the future"}}}
+	}
+	// a campaign's ad is not shown after the campaign ends
+	if ad.EndTime != nil && ad.ExpiresAt.IsZero() {
+		ad.ExpiresAt = *ad.EndTime
+	}
+	return ad, nil
+}
+
+// Validate checks the ad against the schema of its version.
+func (ad *Ad) Validate() error {
+	data, err := json.Marshal(ad)
+	if err != nil {
+		return err
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	if err := validateDocument(document); err != nil {
+		return err
+	}
+	return ad.validateFields()
+}
+
+func validateDocument(document interface{}) error {
+	version := 0
+	if object, ok := document.(map[string]interface{}); ok {
+		if v, ok := object["Version"].(float64); ok {
+			version = int(v)
+		}
+	}
+	schema, ok := schemas[version]
+	if !ok {
+		return &ValidationError{Errors: []FieldError{{Field: "/Version", Message: fmt.Sprintf("unsupported ad version %d", version)}}}
+	}
+	err := schema.Validate(document)
+	var schemaErr *jsonschema.ValidationError
+	if errors.As(err, &schemaErr) {
+		validationErr := &ValidationError{}
+		collectFieldErrors(schemaErr, validationErr)
+		return validationErr
+	}
+	return err
+}
+
+// collectFieldErrors flattens the leaves of the schema error tree, they name the offending fields.
+func collectFieldErrors(err *jsonschema.ValidationError, into *ValidationError) {
+	if len(err.Causes) == 0 {
+		field := err.InstanceLocation
+		if field == "" {
+			field = "/"
+		}
+		into.Errors = append(into.Errors, FieldError{Field: field, Message: err.Message})
+		return
+	}
+	for _, cause := range err.Causes {
+		collectFieldErrors(cause, into)
+	}
+}
+
+// validateFields checks the constraints a JSON schema can not express.
+func (ad *Ad) validateFields() error {
+	var fieldErrors []FieldError
+	if ad.StartTime != nil && ad.EndTime != nil && !ad.EndTime.After(*ad.StartTime) {
+		fieldErrors = append(fieldErrors, FieldError{Field: "/EndTime", Message: "must be after StartTime"})
+	}
+	if len(fieldErrors) > 0 {
+		return &ValidationError{Errors: fieldErrors}
+	}
+	return nil
+}
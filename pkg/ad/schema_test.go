@@ -0,0 +1,78 @@
+package ad
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeLegacyAd(t *testing.T) {
+	ad, err := Decode([]byte(`{"Content": "buy masa", "Metadata": {"category": "tokens"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ad.Version != 0 || ad.Content != "buy masa" || ad.Metadata["category"] != "tokens" {
+		t.Errorf("unexpected legacy ad %+v", ad)
+	}
+	// sha256 of {"Content":"buy masa","Metadata":{"category":"tokens"}}, the hash legacy ads always had
+	sum := sha256.Sum256([]byte(`{"Content":"buy masa","Metadata":{"category":"tokens"}}`))
+	if ad.Hash() != hex.EncodeToString(sum[:]) {
+		t.Error("expected the hash of legacy ads to be unchanged")
+	}
+}
+
+func TestDecodeAd(t *testing.T) {
+	end := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	ad, err := Decode([]byte(`{
+		"Version": 1,
+		"Title": "Masa",
+		"Body": "Buy masa",
+		"Tags": ["crypto", "defi"],
+		"EndTime": "` + end + `",
+		"Budget": {"Amount": "100.5", "Currency": "MASA"},
+		"Media": [{"Hash": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", "Type": "image/png"}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ad.ExpiresAt.IsZero() || !ad.ExpiresAt.Equal(*ad.EndTime) {
+		t.Errorf("expected the ad to expire at its end time, got %s", ad.ExpiresAt)
+	}
+	if err := ad.Validate(); err != nil {
+		t.Errorf("expected the decoded ad to validate, got %v", err)
+	}
+}
+
+func TestDecodeInvalidAd(t *testing.T) {
+	_, err := Decode([]byte(`{
+		"Version": 1,
+		"Body": "Buy masa",
+		"Tags": ["Not A Tag"],
+		"Budget": {"Amount": "-1", "Currency": "MASA"},
+		"StartTime": "2030-01-02T00:00:00Z",
+		"EndTime": "2030-01-01T00:00:00Z"
+	}`))
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, fieldError := range validationErr.Errors {
+		fields[fieldError.Field] = true
+	}
+	for _, field := range []string{"/", "/Tags/0", "/Budget/Amount"} {
+		if !fields[field] {
+			t.Errorf("expected an error for %s, got %v", field, validationErr.Errors)
+		}
+	}
+
+	_, err = Decode([]byte(`{"Version": 1, "Title": "Masa", "Body": "Buy masa", "StartTime": "2030-01-02T00:00:00Z", "EndTime": "2030-01-01T00:00:00Z"}`))
+	if !errors.As(err, &validationErr) || validationErr.Errors[0].Field != "/EndTime" {
+		t.Errorf("expected an error for /EndTime, got %v", err)
+	}
+	if _, err := Decode([]byte(`{"Version": 2, "Title": "Masa"}`)); !errors.As(err, &validationErr) {
+		t.Errorf("expected an unsupported version to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package ad
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/masa-finance/masa-oracle/pkg/atomicfile"
+)
+
+const (
+	DefaultMaxAds = 10000
+	DefaultAdTTL  = 7 * 24 * time.Hour
+)
+
+// Store holds the received ads. It is safe for concurrent use, holds at most maxSize ads evicting the oldest ones
+// first, drops ads once they expire and ignores ads whose content hash it already holds.
+type Store struct {
+	// OnAdd is called with every ad added to the store
+	OnAdd    func(Ad)
+	mutex    sync.RWMutex
+	ads      map[string]*list.Element
+	order    *list.List
+	maxSize  int
+	ttl      time.Duration
+	filePath string
+}
+
+// NewStore creates a store and loads the ads persisted at filePath, if any. A maxSize or ttl of zero selects the
+// defaults and an empty filePath disables persistence.
+func NewStore(maxSize int, ttl time.Duration, filePath string) *Store {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxAds
+	}
+	if ttl <= 0 {
+		ttl = DefaultAdTTL
+	}
+	store := &Store{
+		ads:      make(map[string]*list.Element),
+		order:    list.New(),
+		maxSize:  maxSize,
+		ttl:      ttl,
+		filePath: filePath,
+	}
+	if err := store.Load(); err != nil {
+		logrus.Errorf("Error loading ads: %v", err)
+	}
+	return store
+}
+
+// Hash returns the hash of the content and publisher of an ad, used to deduplicate ads. The same ad published by two
+// publishers is two ads.
+func (ad *Ad) Hash() string {
+	// the structured fields and the publisher are omitted when empty so legacy ads keep their hash
+	data, _ := json.Marshal(struct {
+		Version   int        `json:",omitempty"`
+		Title     string     `json:",omitempty"`
+		Body      string     `json:",omitempty"`
+		Tags      []string   `json:",omitempty"`
+		StartTime *time.Time `json:",omitempty"`
+		EndTime   *time.Time `json:",omitempty"`
+		Budget    *Budget    `json:",omitempty"`
+		Media     []MediaRef `json:",omitempty"`
+		Content   string
+		Metadata  map[string]string
+		Publisher string `json:",omitempty"`
+	}{ad.Version, ad.Title, ad.Body, ad.Tags, ad.StartTime, ad.EndTime, ad.Budget, ad.Media, ad.Content, ad.Metadata, ad.Publisher})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsExpired reports whether the ad expired at the given time.
+func (ad *Ad) IsExpired(now time.Time) bool {
+	return !ad.ExpiresAt.IsZero() && now.After(ad.ExpiresAt)
+}
+
+// Add stores the ad and reports whether it was added. Duplicates and expired ads are not added.
+func (s *Store) Add(ad Ad) bool {
+	now := time.Now()
+	if ad.ReceivedAt.IsZero() {
+		ad.ReceivedAt = now
+	}
+	if ad.ExpiresAt.IsZero() || ad.ExpiresAt.Sub(ad.ReceivedAt) > s.ttl {
+		ad.ExpiresAt = ad.ReceivedAt.Add(s.ttl)
+	}
+	if ad.IsExpired(now) {
+		return false
+	}
+	ad.ContentHash = ad.Hash()
+
+	s.mutex.Lock()
+	if _, exists := s.ads[ad.ContentHash]; exists {
+		s.mutex.Unlock()
+		return false
+	}
+	for s.order.Len() >= s.maxSize {
+		s.removeElement(s.order.Front())
+	}
+	s.ads[ad.ContentHash] = s.order.PushBack(&ad)
+	s.mutex.Unlock()
+
+	if s.OnAdd != nil {
+		s.OnAdd(ad)
+	}
+	return true
+}
+
+// Get returns the ad with the given content hash.
+func (s *Store) Get(hash string) (Ad, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	element, ok := s.ads[hash]
+	if !ok || element.Value.(*Ad).IsExpired(time.Now()) {
+		return Ad{}, false
+	}
+	return *element.Value.(*Ad), true
+}
+
+// List returns the ads that have not expired, oldest first.
+func (s *Store) List() []Ad {
+	now := time.Now()
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	ads := make([]Ad, 0, s.order.Len())
+	for element := s.order.Front(); element != nil; element = element.Next() {
+		ad := element.Value.(*Ad)
+		if !ad.IsExpired(now) {
+			ads = append(ads, *ad)
+		}
+	}
+	return ads
+}
+
+// Len returns the number of ads held, including expired ads that have not been pruned yet.
+func (s *Store) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.order.Len()
+}
+
+// Prune removes the expired ads and returns how many were removed.
+func (s *Store) Prune() int {
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	removed := 0
+	for element := s.order.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*Ad).IsExpired(now) {
+			s.removeElement(element)
+			removed++
+		}
+		element = next
+	}
+	return removed
+}
+
+func (s *Store) removeElement(element *list.Element) {
+	ad := s.order.Remove(element).(*Ad)
+	delete(s.ads, ad.ContentHash)
+}
+
+// Save writes the ads to the store's file.
+func (s *Store) Save() error {
+	if s.filePath == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.List())
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("writing %d ads to file: %s", s.Len(), s.filePath)
+	// a crash while writing leaves the previous file intact
+	return atomicfile.WriteFile(s.filePath, data, 0644)
+}
+
+// Load reads the ads from the store's file, skipping the ones that expired in the meantime.
+func (s *Store) Load() error {
+	if s.filePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var ads []Ad
+	if err := json.Unmarshal(data, &ads); err != nil {
+		return err
+	}
+	for _, ad := range ads {
+		s.Add(ad)
+	}
+	logrus.Infof("Loaded %d ads from file", s.Len())
+	return nil
+}
+
+// Run prunes expired ads and persists the store every interval until ctx is done.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if removed := s.Prune(); removed > 0 {
+				logrus.Debugf("Pruned %d expired ads", removed)
+			}
+			if err := s.Save(); err != nil {
+				logrus.Errorf("Error saving ads: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package ad
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreDeduplicatesAndEvicts(t *testing.T) {
+	store := NewStore(2, time.Hour, "")
+	if !store.Add(Ad{Content: "first"}) {
+		t.Fatal("expected first ad to be added")
+	}
+	if store.Add(Ad{Content: "first"}) {
+		t.Error("expected duplicate ad to be ignored")
+	}
+	store.Add(Ad{Content: "second"})
+	if !store.Add(Ad{Content: "second", Publisher: "0x2"}) {
+		t.Error("expected the same ad of another publisher to be added")
+	}
+	store.Add(Ad{Content: "third"})
+
+	ads := store.List()
+	if len(ads) != 2 || ads[0].Publisher != "0x2" || ads[1].Content != "third" {
+		t.Errorf("expected the oldest ad to be evicted, got %v", ads)
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	store := NewStore(10, time.Hour, "")
+	if store.Add(Ad{Content: "expired", ExpiresAt: time.Now().Add(-time.Minute)}) {
+		t.Error("expected an expired ad to be rejected")
+	}
+	store.Add(Ad{Content: "long", ExpiresAt: time.Now().Add(48 * time.Hour)})
+	ads := store.List()
+	if len(ads) != 1 || ads[0].ExpiresAt.After(time.Now().Add(time.Hour)) {
+		t.Errorf("expected the expiry to be capped at the store TTL, got %v", ads)
+	}
+}
+
+func TestStorePersistence(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "ads.json")
+	store := NewStore(10, time.Hour, filePath)
+	for i := 0; i < 3; i++ {
+		store.Add(Ad{Content: fmt.Sprintf("ad %d", i), Metadata: map[string]string{"i": fmt.Sprint(i)}})
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewStore(10, time.Hour, filePath)
+	if restored.Len() != 3 {
+		t.Errorf("expected 3 ads after restart, got %d", restored.Len())
+	}
+}
@@ -0,0 +1,106 @@
+package ad
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxAdClockSkew is how far the timestamp of a signed ad may be from the local clock. Nonces are remembered for
+// twice this window, so an envelope can not be replayed later.
+const MaxAdClockSkew = 5 * time.Minute
+
+// Validator is the pubsub validator of the ad topic. It rejects ads that are not correctly signed, are outside of
+// the clock skew window, do not match the ad schema, replay a nonce or whose publisher is not staked, and ignores
+// ads of publishers over their rate limit.
+type Validator struct {
+	// RateLimiter, when set, limits the ads accepted from each publisher. Ads published by LocalPeer are not
+	// limited here, the publishing API enforces the limits before publishing.
+	RateLimiter *RateLimiter
+	LocalPeer   peer.ID
+	isStaked    func(address string) (bool, error)
+	mutex       sync.Mutex
+	nonces      map[string]bool
+	// expiries holds the used nonces in the order they were seen, so the expired ones are pruned from its front
+	expiries []usedNonce
+}
+
+type usedNonce struct {
+	key  string
+	seen time.Time
+}
+
+// NewValidator creates the ad validator, isStaked is used to check the publisher of each ad.
+func NewValidator(isStaked func(address string) (bool, error)) *Validator {
+	return &Validator{
+		isStaked: isStaked,
+		nonces:   make(map[string]bool),
+	}
+}
+
+// Validate implements pubsub.ValidatorEx. The verified envelope is handed to the subscription handler through
+// the message's ValidatorData.
+func (v *Validator) Validate(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	var signedAd SignedAd
+	if err := json.Unmarshal(msg.Data, &signedAd); err != nil {
+		logrus.Debugf("rejected malformed ad from %s: %v", from, err)
+		return pubsub.ValidationReject
+	}
+	if err := signedAd.Verify(); err != nil {
+		logrus.Warnf("rejected ad from %s: %v", from, err)
+		return pubsub.ValidationReject
+	}
+	if err := signedAd.Ad.Validate(); err != nil {
+		logrus.Warnf("rejected ad from %s: %v", signedAd.Publisher, err)
+		return pubsub.ValidationReject
+	}
+	skew := time.Since(signedAd.Timestamp)
+	if skew > MaxAdClockSkew || skew < -MaxAdClockSkew {
+		logrus.Debugf("ignored ad from %s with timestamp %s", signedAd.Publisher, signedAd.Timestamp)
+		return pubsub.ValidationIgnore
+	}
+	staked, err := v.isStaked(signedAd.Publisher)
+	if err != nil {
+		logrus.Errorf("could not check the stake of ad publisher %s: %v", signedAd.Publisher, err)
+		return pubsub.ValidationIgnore
+	}
+	if !staked {
+		logrus.Warnf("rejected ad from unstaked publisher %s", signedAd.Publisher)
+		return pubsub.ValidationReject
+	}
+	if !v.useNonce(signedAd.Publisher, signedAd.Nonce, time.Now()) {
+		return pubsub.ValidationIgnore
+	}
+	if v.RateLimiter != nil && from != v.LocalPeer {
+		if err := v.RateLimiter.Allow(signedAd.Publisher); err != nil {
+			logrus.Debugf("ignored ad from %s: %v", signedAd.Publisher, err)
+			return pubsub.ValidationIgnore
+		}
+	}
+	msg.ValidatorData = &signedAd
+	return pubsub.ValidationAccept
+}
+
+// useNonce records the nonce of a publisher seen at now and reports whether it was unused. Nonces older than twice
+// the clock skew window are forgotten.
+func (v *Validator) useNonce(publisher, nonce string, now time.Time) bool {
+	key := strings.ToLower(publisher) + "/" + nonce
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	for len(v.expiries) > 0 && now.Sub(v.expiries[0].seen) > 2*MaxAdClockSkew {
+		delete(v.nonces, v.expiries[0].key)
+		v.expiries = v.expiries[1:]
+	}
+	if v.nonces[key] {
+		return false
+	}
+	v.nonces[key] = true
+	v.expiries = append(v.expiries, usedNonce{key: key, seen: now})
+	return true
+}
@@ -0,0 +1,82 @@
+package ad
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+
+	"github.com/masa-finance/masa-oracle/pkg/signer"
+)
+
+func newTestMessage(t *testing.T, v interface{}) *pubsub.Message {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &pubsub.Message{Message: &pb.Message{Data: data}}
+}
+
+func TestValidator(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := signer.NewPrivateKeySigner(key)
+	staked := map[string]bool{publisher.Address().Hex(): true}
+	validator := NewValidator(func(address string) (bool, error) {
+		return staked[address], nil
+	})
+
+	signedAd, err := NewSignedAd(Ad{Content: "buy masa"}, publisher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := newTestMessage(t, signedAd)
+	if result := validator.Validate(context.Background(), "", msg); result != pubsub.ValidationAccept {
+		t.Fatalf("expected a signed ad from a staked publisher to be accepted, got %v", result)
+	}
+	if verified, ok := msg.ValidatorData.(*SignedAd); !ok || verified.Publisher != publisher.Address().Hex() {
+		t.Error("expected the verified envelope in the validator data")
+	}
+	if result := validator.Validate(context.Background(), "", newTestMessage(t, signedAd)); result == pubsub.ValidationAccept {
+		t.Error("expected a replayed nonce to be refused")
+	}
+
+	tampered := *signedAd
+	tampered.Ad.Content = "sell masa"
+	if result := validator.Validate(context.Background(), "", newTestMessage(t, tampered)); result != pubsub.ValidationReject {
+		t.Errorf("expected a tampered ad to be rejected, got %v", result)
+	}
+
+	staked[publisher.Address().Hex()] = false
+	unstaked, err := NewSignedAd(Ad{Content: "buy masa"}, publisher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := validator.Validate(context.Background(), "", newTestMessage(t, unstaked)); result != pubsub.ValidationReject {
+		t.Errorf("expected an ad from an unstaked publisher to be rejected, got %v", result)
+	}
+}
+
+func TestValidatorForgetsExpiredNonces(t *testing.T) {
+	validator := NewValidator(nil)
+	start := time.Now()
+	if !validator.useNonce("0xAbC", "1", start) || validator.useNonce("0xabc", "1", start.Add(time.Minute)) {
+		t.Fatal("expected a nonce to be usable once")
+	}
+	later := start.Add(2*MaxAdClockSkew + time.Second)
+	if !validator.useNonce("0xabc", "2", later) {
+		t.Fatal("expected a new nonce to be usable")
+	}
+	if len(validator.nonces) != 1 || len(validator.expiries) != 1 {
+		t.Errorf("expected the expired nonce to be pruned, %d nonces remain", len(validator.nonces))
+	}
+	if !validator.useNonce("0xabc", "1", later) {
+		t.Error("expected an expired nonce to be usable again")
+	}
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+
+	masa "github.com/masa-finance/masa-oracle/pkg"
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/network"
+)
+
+// adminTimeout bounds the dials and the DHT refresh of the admin routes.
+const adminTimeout = 30 * time.Second
+
+// The admin routes change the network membership of the node at runtime, they require auth.RoleAdmin.
+
+func (api *API) connectPeer(c *gin.Context) (interface{}, *v1.Meta, error) {
+	var request v1.AddressRequest
+	if err := bindBody(c, &request); err != nil {
+		return nil, nil, err
+	}
+	addr, err := parseP2PAddress(request.Address)
+	if err != nil {
+		return nil, nil, err
+	}
+	if api.Node == nil || api.Node.Host == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started its host")
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), adminTimeout)
+	defer cancel()
+	info, err := api.Node.ConnectPeer(ctx, addr)
+	switch {
+	case errors.Is(err, network.ErrSelf):
+		return nil, nil, v1.InvalidRequest("cannot connect the node to itself")
+	case api.Node.BanList != nil && api.Node.BanList.IsBanned(info.ID):
+		return nil, nil, v1.Errorf(http.StatusConflict, v1.CodeConflict, "peer %s is banned, unban it first", info.ID)
+	case err != nil:
+		return nil, nil, v1.Errorf(http.StatusBadGateway, v1.CodeUnavailable, "failed to connect to %s: %v", info.ID, err)
+	}
+	return v1.Peer{ID: info.ID.String()}, nil, nil
+}
+
+func (api *API) disconnectPeer(c *gin.Context) (interface{}, *v1.Meta, error) {
+	id, err := peer.Decode(c.Param("id"))
+	if err != nil {
+		return nil, nil, v1.InvalidRequest("invalid peer ID: %s", err.Error())
+	}
+	if api.Node == nil || api.Node.Host == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started its host")
+	}
+	if len(api.Node.Host.Network().ConnsToPeer(id)) == 0 {
+		return nil, nil, v1.NotFound("not connected to peer %s", id)
+	}
+	if err := api.Node.DisconnectPeer(id); err != nil {
+		return nil, nil, v1.Internal(err)
+	}
+	return nil, nil, nil
+}
+
+func (api *API) listBans(c *gin.Context) (interface{}, *v1.Meta, error) {
+	if api.Node == nil || api.Node.BanList == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has no ban list")
+	}
+	bans := api.Node.BanList.List()
+	return bans, listMeta(bans), nil
+}
+
+func (api *API) banPeer(c *gin.Context) (interface{}, *v1.Meta, error) {
+	var request v1.BanRequest
+	if err := bindBody(c, &request); err != nil {
+		return nil, nil, err
+	}
+	id, err := peer.Decode(request.PeerID)
+	if err != nil {
+		return nil, nil, v1.InvalidRequest("invalid peer ID: %s", err.Error())
+	}
+	var duration time.Duration
+	if request.Duration != "" {
+		duration, err = time.ParseDuration(request.Duration)
+		if err != nil || duration <= 0 {
+			return nil, nil, v1.InvalidRequest("duration must be a positive duration like 1h")
+		}
+	}
+	if api.Node == nil || api.Node.BanList == nil || api.Node.Host == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has no ban list")
+	}
+	if id == api.Node.Host.ID() {
+		return nil, nil, v1.InvalidRequest("cannot ban the node itself")
+	}
+	ban, err := api.Node.BanPeer(id, duration, request.Reason)
+	if err != nil {
+		return nil, nil, v1.Internal(err)
+	}
+	return ban, nil, nil
+}
+
+func (api *API) unbanPeer(c *gin.Context) (interface{}, *v1.Meta, error) {
+	id, err := peer.Decode(c.Param("id"))
+	if err != nil {
+		return nil, nil, v1.InvalidRequest("invalid peer ID: %s", err.Error())
+	}
+	if api.Node == nil || api.Node.BanList == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has no ban list")
+	}
+	banned, err := api.Node.UnbanPeer(id)
+	if err != nil {
+		return nil, nil, v1.Internal(err)
+	}
+	if !banned {
+		return nil, nil, v1.NotFound("peer %s is not banned", id)
+	}
+	return nil, nil, nil
+}
+
+func (api *API) listBootnodes(c *gin.Context) (interface{}, *v1.Meta, error) {
+	if api.Node == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started")
+	}
+	bootnodes := make([]string, 0)
+	for _, addr := range api.Node.Bootnodes() {
+		bootnodes = append(bootnodes, addr.String())
+	}
+	return bootnodes, listMeta(bootnodes), nil
+}
+
+func (api *API) addBootnode(c *gin.Context) (interface{}, *v1.Meta, error) {
+	var request v1.AddressRequest
+	if err := bindBody(c, &request); err != nil {
+		return nil, nil, err
+	}
+	addr, err := parseP2PAddress(request.Address)
+	if err != nil {
+		return nil, nil, err
+	}
+	if api.Node == nil || api.Node.DHT == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started its DHT")
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), adminTimeout)
+	defer cancel()
+	err = api.Node.AddBootnode(ctx, addr)
+	switch {
+	case errors.Is(err, network.ErrSelf):
+		return nil, nil, v1.InvalidRequest("the node cannot be its own bootnode")
+	case err != nil:
+		return nil, nil, v1.Errorf(http.StatusBadGateway, v1.CodeUnavailable, "failed to connect to bootnode %s: %v", addr, err)
+	}
+	return nil, nil, nil
+}
+
+func (api *API) removeBootnode(c *gin.Context) (interface{}, *v1.Meta, error) {
+	var params v1.BootnodeParams
+	if err := bindQuery(c, &params); err != nil {
+		return nil, nil, err
+	}
+	addr, err := multiaddr.NewMultiaddr(params.Address)
+	if err != nil {
+		return nil, nil, v1.InvalidRequest("invalid address: %s", err.Error())
+	}
+	if api.Node == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started")
+	}
+	if !api.Node.RemoveBootnode(addr) {
+		return nil, nil, v1.NotFound("%s is not a bootnode", addr)
+	}
+	return nil, nil, nil
+}
+
+func (api *API) refreshDHT(c *gin.Context) (interface{}, *v1.Meta, error) {
+	if api.Node == nil || api.Node.DHT == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started its DHT")
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), adminTimeout)
+	defer cancel()
+	connected, err := api.Node.RefreshDHT(ctx)
+	if errors.Is(err, masa.ErrNotStarted) {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "%s", err.Error())
+	}
+	if err != nil {
+		return nil, nil, v1.Errorf(http.StatusBadGateway, v1.CodeUnavailable, "failed to refresh the DHT: %v", err)
+	}
+	return v1.DHTRefresh{ConnectedBootnodes: connected, RoutingTableSize: api.Node.DHT.RoutingTable().Size()}, nil, nil
+}
+
+// parseP2PAddress parses a multiaddress that names its peer.
+func parseP2PAddress(address string) (multiaddr.Multiaddr, error) {
+	addr, err := multiaddr.NewMultiaddr(address)
+	if err != nil {
+		return nil, v1.InvalidRequest("invalid address: %s", err.Error())
+	}
+	if _, err := peer.AddrInfoFromP2pAddr(addr); err != nil {
+		return nil, v1.InvalidRequest("the address must end in /p2p/<peer ID>")
+	}
+	return addr, nil
+}
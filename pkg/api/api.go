@@ -3,17 +3,20 @@ package api
 import (
 	"errors"
 	"fmt"
-	"io"
-	"math"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	masa "github.com/masa-finance/masa-oracle/pkg"
 	"github.com/masa-finance/masa-oracle/pkg/ad"
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/pubsub"
 )
 
+const maxAdsPageSize = 100
+
 type API struct {
 	Node *masa.OracleNode
 }
@@ -22,45 +25,36 @@ func NewAPI(node *masa.OracleNode) *API {
 	return &API{Node: node}
 }
 
+// GetNodeDataHandler returns a page of node data. It takes the filters, sorting and cursor of /v1/nodeData, with the
+// page number in pageNbr.
 func (api *API) GetNodeDataHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		pageNbr, err := GetPathInt(c, "pageNbr")
-		if err != nil {
-			pageNbr = 0
+		params := v1.NodeDataParams{PageSize: masa.PageSize}
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+			return
 		}
-		pageSize, err := GetPathInt(c, "pageSize")
-		if err != nil {
-			pageSize = masa.PageSize
+		if pageNbr, err := GetPathInt(c, "pageNbr"); err == nil {
+			params.Page = pageNbr
 		}
-
-		if api.Node == nil || api.Node.DHT == nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "An unexpected error occurred.",
-			})
+		data, meta, err := api.NodeDataPage(params)
+		var apiErr *v1.Error
+		if errors.As(err, &apiErr) {
+			c.JSON(apiErr.Status, gin.H{"success": false, "message": apiErr.Message})
 			return
 		}
-		allNodeData := api.Node.NodeTracker.GetAllNodeData()
-		totalRecords := len(allNodeData)
-		totalPages := int(math.Ceil(float64(totalRecords) / masa.PageSize))
-
-		startIndex := pageNbr * pageSize
-		endIndex := startIndex + pageSize
-		if endIndex > totalRecords {
-			endIndex = totalRecords
-		}
-		nodeDataPage := masa.NodeDataPage{
-			Data:         allNodeData[startIndex:endIndex],
-			PageNumber:   pageNbr,
-			TotalPages:   totalPages,
-			TotalRecords: totalRecords,
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "An unexpected error occurred."})
+			return
 		}
 		c.JSON(http.StatusOK, gin.H{
 			"success":      true,
-			"data":         nodeDataPage.Data,
-			"pageNbr":      nodeDataPage.PageNumber,
-			"total":        nodeDataPage.TotalRecords,
-			"totalRecords": nodeDataPage.TotalRecords,
+			"data":         data,
+			"pageNbr":      params.Page,
+			"totalPages":   meta.TotalPages,
+			"nextCursor":   meta.NextCursor,
+			"total":        meta.TotalCount,
+			"totalRecords": meta.TotalCount,
 		})
 	}
 }
@@ -135,51 +129,99 @@ func (api *API) GetPeerAddresses() gin.HandlerFunc {
 
 func (api *API) PostAd() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		bodyBytes, err := io.ReadAll(c.Request.Body)
+		body, err := c.GetRawData()
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 			return
 		}
-		if !api.Node.IsStaked {
-			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "node must be staked to be an ad publisher"})
+		signedAd, err := api.PublishAdJSON(body)
+		var apiErr *v1.Error
+		if errors.As(err, &apiErr) && apiErr.Details != nil {
+			c.JSON(apiErr.Status, gin.H{"success": false, "message": apiErr.Message, "errors": apiErr.Details})
 			return
 		}
-
-		if err := api.Node.PubSubManager.Publish(masa.AdTopic, bodyBytes); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.As(err, &apiErr) {
+			c.JSON(apiErr.Status, gin.H{"error": apiErr.Message})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"status": "Ad published"})
+		c.JSON(http.StatusOK, gin.H{"status": "Ad published", "publisher": signedAd.Publisher})
 	}
 }
 
+// GetAds returns the received ads. Query parameters:
+//
+//	metadata=key:value  only ads with this metadata, may be repeated
+//	publisher=0x...     only ads signed by this publisher
+//	since, until        RFC 3339 bounds of the time the ad was received
+//	q=text              only ads whose content, title or body contains the text
+//	sort, order         receivedAt, expiresAt or publisher; asc or desc
+//	cursor, limit       continue after a previous page; page size, at most maxAdsPageSize
 func (api *API) GetAds() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		handler, err := api.Node.PubSubManager.GetHandler(masa.AdTopic)
+		query, err := parseAdQuery(c)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
 			return
 		}
-
-		adHandler, ok := handler.(*ad.SubscriptionHandler)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "handler is not of type ad.SubscriptionHandler"})
+		page, err := api.Node.AdStore.Query(query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
 			return
 		}
+		c.JSON(http.StatusOK, gin.H{
+			"success":    true,
+			"data":       page.Ads,
+			"nextCursor": page.NextCursor,
+			"totalCount": page.Total,
+		})
+	}
+}
 
-		if len(adHandler.Ads) == 0 {
-			c.JSON(http.StatusOK, gin.H{"message": "No ads"})
-		} else {
-			c.JSON(http.StatusOK, adHandler.Ads)
+func parseAdQuery(c *gin.Context) (ad.Query, error) {
+	var params v1.AdsParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		return ad.Query{}, err
+	}
+	return adQuery(params)
+}
+
+// adQuery converts the query parameters of the ad routes, a zero Limit selects the default page size.
+func adQuery(params v1.AdsParams) (ad.Query, error) {
+	query := ad.Query{
+		Metadata:   make(map[string]string),
+		Publisher:  params.Publisher,
+		Since:      params.Since,
+		Until:      params.Until,
+		Text:       params.Q,
+		SortBy:     params.Sort,
+		Descending: params.Order == "desc",
+		Cursor:     params.Cursor,
+		Limit:      masa.PageSize,
+	}
+	for _, pair := range params.Metadata {
+		key, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			return query, fmt.Errorf("metadata filter %q must be key:value", pair)
 		}
+		query.Metadata[key] = value
 	}
+	if params.Order != "" && params.Order != "asc" && params.Order != "desc" {
+		return query, errors.New("order must be asc or desc")
+	}
+	if params.Limit != 0 {
+		if params.Limit < 1 || params.Limit > maxAdsPageSize {
+			return query, fmt.Errorf("limit must be between 1 and %d", maxAdsPageSize)
+		}
+		query.Limit = params.Limit
+	}
+	return query, nil
 }
 
 func (api *API) SubscribeToAds() gin.HandlerFunc {
-	handler := &ad.SubscriptionHandler{}
 	return func(c *gin.Context) {
+		handler := ad.NewSubscriptionHandler(api.Node.AdStore)
 		err := api.Node.PubSubManager.AddSubscription(masa.AdTopic, handler)
-		if err != nil {
+		if err != nil && !errors.Is(err, pubsub.ErrAlreadySubscribed) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
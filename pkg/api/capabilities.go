@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/auth"
+	"github.com/masa-finance/masa-oracle/pkg/network"
+)
+
+const (
+	defaultProviders = 20
+	maxProviders     = 100
+	// providersTimeout bounds the provider lookup and the probes of the providers
+	providersTimeout = 30 * time.Second
+	// probeScope is the scope of the providers endpoint probing the providers
+	probeScope = "probe"
+)
+
+func (api *API) listCapabilities(c *gin.Context) (interface{}, *v1.Meta, error) {
+	if api.Node == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started")
+	}
+	capabilities := append([]network.Capability{}, api.Node.Capabilities...)
+	return capabilities, listMeta(capabilities), nil
+}
+
+func (api *API) findProviders(c *gin.Context) (interface{}, *v1.Meta, error) {
+	capability, err := network.ParseCapability(c.Param("name"))
+	if err != nil {
+		return nil, nil, v1.InvalidRequest("%s", err.Error())
+	}
+	var params v1.ProvidersParams
+	if err := bindQuery(c, &params); err != nil {
+		return nil, nil, err
+	}
+	if params.Limit == 0 {
+		params.Limit = defaultProviders
+	}
+	if params.Limit < 1 || params.Limit > maxProviders {
+		return nil, nil, v1.InvalidRequest("limit must be between 1 and %d", maxProviders)
+	}
+	if params.Probe && !allowsScope(c, probeScope) {
+		return nil, nil, v1.Errorf(http.StatusForbidden, v1.CodeForbidden, "the caller may not probe providers")
+	}
+	if api.Node == nil || api.Node.DHT == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started its DHT")
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), providersTimeout)
+	defer cancel()
+	providers, err := api.Node.FindProviders(ctx, capability, params.Limit, params.Probe)
+	if err != nil {
+		return nil, nil, v1.Errorf(http.StatusBadGateway, v1.CodeUnavailable, "failed to find providers of %s: %v", capability, err)
+	}
+	return providers, listMeta(providers), nil
+}
+
+// callerRole returns the role of the caller authenticated by the auth middleware, RoleNone without it.
+func callerRole(c *gin.Context) auth.Role {
+	principal, _ := c.Value(auth.PrincipalKey).(auth.Principal)
+	return principal.Role
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+
+	"github.com/masa-finance/masa-oracle/pkg/events"
+)
+
+const (
+	eventsKeepAlive  = 30 * time.Second
+	eventsWriteLimit = 10 * time.Second
+	// TopicMissed tells a resuming client that events were dropped and it has to reload its state
+	TopicMissed = "missed"
+)
+
+var upgrader = websocket.Upgrader{
+	// the API serves dashboards on other origins and has no cookies to protect
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// parseEventsQuery reads the topics to stream, comma separated or repeated, and the sequence to resume after from
+// the since parameter or the Last-Event-ID header sent by reconnecting EventSource clients.
+func parseEventsQuery(c *gin.Context) (topics []string, since uint64, err error) {
+	for _, value := range c.QueryArray("topics") {
+		for _, topic := range strings.Split(value, ",") {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				topics = append(topics, topic)
+			}
+		}
+	}
+	resume := c.Query("since")
+	if resume == "" {
+		resume = c.GetHeader("Last-Event-ID")
+	}
+	if resume != "" {
+		since, err = strconv.ParseUint(resume, 10, 64)
+	}
+	return topics, since, err
+}
+
+// HiddenTopics returns the event topics gated by eventScopes that a caller may not stream, allowed tells whether
+// it has the role of a scope.
+func HiddenTopics(allowed func(scope string) bool) []string {
+	var hidden []string
+	for _, scope := range eventScopes {
+		if !allowed(scope.Name) {
+			hidden = append(hidden, scope.Name)
+		}
+	}
+	return hidden
+}
+
+// Visible reports whether an event of topic is streamed to a caller that may not stream the hidden topics.
+func Visible(topic string, hidden []string) bool {
+	for _, h := range hidden {
+		if events.InTopic(topic, h) {
+			return false
+		}
+	}
+	return true
+}
+
+// StreamEvents streams the node's events as server-sent events. Query parameters:
+//
+//	topics=peer,ad  only events of these topics: peer.connected, peer.disconnected, nodeData, ad, stake,
+//	                bridge and epoch.closed
+//	since=42        replay the events after this sequence first, Last-Event-ID is used when not set
+//
+// The bridge events are only streamed to callers with the role of their scope, admin by default.
+func (api *API) StreamEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		topics, since, err := parseEventsQuery(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "since must be an event sequence"})
+			return
+		}
+		hidden := HiddenTopics(func(scope string) bool { return allowsScope(c, scope) })
+		subscription, complete := api.Node.Events.Subscribe(topics, since)
+		defer subscription.Close()
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+		if !complete {
+			c.Render(-1, sse.Event{Event: TopicMissed, Data: gin.H{"since": since}})
+		}
+		keepAlive := time.NewTicker(eventsKeepAlive)
+		defer keepAlive.Stop()
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case event, ok := <-subscription.C:
+				if !ok {
+					// the client fell behind, it reconnects and resumes from its last event
+					return false
+				}
+				if !Visible(event.Topic, hidden) {
+					return true
+				}
+				c.Render(-1, sse.Event{Id: strconv.FormatUint(event.Sequence, 10), Event: event.Topic, Data: event})
+				return true
+			case <-keepAlive.C:
+				_, err := io.WriteString(w, ": keep-alive\n\n")
+				return err == nil
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+	}
+}
+
+// StreamEventsWebSocket streams the node's events as JSON messages over a WebSocket, it takes the same parameters
+// as StreamEvents.
+func (api *API) StreamEventsWebSocket() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		topics, since, err := parseEventsQuery(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "since must be an event sequence"})
+			return
+		}
+		hidden := HiddenTopics(func(scope string) bool { return allowsScope(c, scope) })
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			logrus.Debugf("websocket upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		subscription, complete := api.Node.Events.Subscribe(topics, since)
+		defer subscription.Close()
+
+		// the client does not send anything, reading detects when it goes away
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		write := func(v interface{}) error {
+			_ = conn.SetWriteDeadline(time.Now().Add(eventsWriteLimit))
+			return conn.WriteJSON(v)
+		}
+		if !complete {
+			if err := write(events.Event{Topic: TopicMissed, Time: time.Now().UTC(), Data: gin.H{"since": since}}); err != nil {
+				return
+			}
+		}
+		keepAlive := time.NewTicker(eventsKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case event, ok := <-subscription.C:
+				if !ok {
+					_ = conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client fell behind"), time.Now().Add(eventsWriteLimit))
+					return
+				}
+				if !Visible(event.Topic, hidden) {
+					continue
+				}
+				if err := write(event); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventsWriteLimit)); err != nil {
+					return
+				}
+			case <-closed:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/auth"
+)
+
+// OpenAPIVersion is the version of the API in the OpenAPI document, its major version matches v1.BasePath.
+const OpenAPIVersion = "1.0.0"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	bigIntType        = reflect.TypeOf(big.Int{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// OpenAPI returns the OpenAPI 3 document of the endpoints. The schemas are derived from the Go types of their
+// parameters, bodies and results the way encoding/json and gin's form binding read them.
+func OpenAPI(endpoints []Endpoint) map[string]interface{} {
+	g := &schemaGenerator{schemas: make(map[string]interface{})}
+	envelope := g.schema(reflect.TypeOf(v1.Response{}))
+	paths := make(map[string]map[string]interface{})
+	for _, e := range endpoints {
+		path, parameters := openAPIPath(e.Path)
+		parameters = append(parameters, g.queryParameters(e.Params)...)
+		operation := map[string]interface{}{
+			"operationId":     e.Name,
+			"summary":         e.Summary,
+			"x-required-role": e.RequiredRole(),
+			"responses": map[string]interface{}{
+				"200":     g.response(e),
+				"default": map[string]interface{}{"$ref": "#/components/responses/Error"},
+			},
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if len(e.Scopes) > 0 {
+			// the policy names a scope "METHOD /path#name"
+			operation["x-scopes"] = e.Scopes
+		}
+		if e.Body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(e.Body))}},
+			}
+		}
+		if e.RequiredRole() != auth.RoleRead {
+			// read routes are open to anonymous callers with the default access configuration
+			operation["security"] = []map[string][]string{{"apiKey": {}}, {"bearer": {}}, {"signature": {}}}
+		}
+		if paths[v1.BasePath+path] == nil {
+			paths[v1.BasePath+path] = make(map[string]interface{})
+		}
+		paths[v1.BasePath+path][strings.ToLower(e.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Masa Oracle node API",
+			"version":     OpenAPIVersion,
+			"description": "Every response is a " + schemaName(reflect.TypeOf(v1.Response{})) + " envelope. Failed requests set error.code to one of the v1 error codes.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": g.schemas,
+			"responses": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"description": "The request failed, see error.code",
+					"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": envelope}},
+				},
+			},
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": auth.APIKeyHeader},
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"signature": map[string]interface{}{
+					"type": "apiKey", "in": "header", "name": auth.SignatureHeader,
+					"description": "personal_sign signature of the request, with the " + auth.AddressHeader + " and " + auth.TimestampHeader + " headers",
+				},
+			},
+		},
+	}
+}
+
+// openAPIPath converts the gin parameters of path, like :id, to OpenAPI ones.
+func openAPIPath(path string) (string, []interface{}) {
+	var parameters []interface{}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+			parameters = append(parameters, map[string]interface{}{
+				"name": name, "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+func (g *schemaGenerator) response(e Endpoint) map[string]interface{} {
+	if e.Stream != "" {
+		return map[string]interface{}{
+			"description": "A stream of events",
+			"content":     map[string]interface{}{e.Stream: map[string]interface{}{}},
+		}
+	}
+	schema := g.schema(reflect.TypeOf(v1.Response{}))
+	if e.Result != nil {
+		schema = map[string]interface{}{"allOf": []interface{}{
+			schema,
+			map[string]interface{}{"properties": map[string]interface{}{"data": g.schema(reflect.TypeOf(e.Result))}},
+		}}
+	}
+	return map[string]interface{}{
+		"description": "Success",
+		"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}},
+	}
+}
+
+// schemaGenerator collects the schemas of named struct types as components.
+type schemaGenerator struct {
+	schemas map[string]interface{}
+}
+
+func (g *schemaGenerator) queryParameters(params interface{}) []interface{} {
+	var parameters []interface{}
+	if params == nil {
+		return parameters
+	}
+	t := reflect.TypeOf(params)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("form")
+		if name == "" || name == "-" {
+			continue
+		}
+		parameter := map[string]interface{}{"name": name, "in": "query", "schema": g.schema(field.Type)}
+		if field.Type == durationType {
+			// durations are bound from their text form
+			parameter["schema"] = map[string]interface{}{"type": "string", "example": "24h"}
+		}
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			parameter["required"] = true
+		}
+		if field.Type.Kind() == reflect.Slice {
+			parameter["explode"] = true
+		}
+		parameters = append(parameters, parameter)
+	}
+	return parameters
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == durationType:
+		return map[string]interface{}{"type": "integer", "format": "int64", "description": "nanoseconds"}
+	case t == rawMessageType:
+		return map[string]interface{}{}
+	case t == bigIntType:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() != reflect.String && (t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) ||
+		t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType)):
+		// the custom marshalers of the API's types, like hex bytes and multiaddresses, write strings
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, ok := g.schemas[name]; !ok {
+			// placeholder for recursive types
+			g.schemas[name] = map[string]interface{}{}
+			g.schemas[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	g.addFields(t, properties, &required)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds the fields encoding/json writes, including those of embedded structs.
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.addFields(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schema(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// schemaName names the component of a type after its package and name, like ad.Ad.
+func schemaName(t reflect.Type) string {
+	return t.String()
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/auth"
+	"github.com/masa-finance/masa-oracle/pkg/pubsub"
+)
+
+func TestOpenAPI(t *testing.T) {
+	endpoints := Endpoints()
+	data, err := json.Marshal(OpenAPI(endpoints))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range endpoints {
+		path, _ := openAPIPath(e.Path)
+		if _, ok := document.Paths[v1.BasePath+path][strings.ToLower(e.Method)]; !ok {
+			t.Errorf("%s %s is missing", e.Method, e.Path)
+		}
+	}
+	for _, ref := range strings.Split(string(data), `"$ref":"#/components/schemas/`)[1:] {
+		name := ref[:strings.Index(ref, `"`)]
+		if _, ok := document.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s is referenced but missing", name)
+		}
+	}
+	var signedAd struct {
+		Properties map[string]interface{} `json:"properties"`
+		Required   []string               `json:"required"`
+	}
+	if err := json.Unmarshal(document.Components.Schemas["ad.SignedAd"], &signedAd); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := signedAd.Properties["signature"]; !ok || len(signedAd.Required) != 5 {
+		t.Errorf("unexpected ad.SignedAd schema %+v", signedAd)
+	}
+}
+
+func TestV1Envelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	(&API{}).RegisterV1(router.Group(v1.BasePath))
+
+	tests := []struct {
+		path   string
+		status int
+		code   string
+	}{
+		{"/v1/peers", http.StatusServiceUnavailable, v1.CodeUnavailable},
+		{"/v1/peers/addresses", http.StatusServiceUnavailable, v1.CodeUnavailable},
+		{"/v1/peers/not-a-peer", http.StatusBadRequest, v1.CodeInvalidRequest},
+		{"/v1/nodeData?pageSize=1000", http.StatusBadRequest, v1.CodeInvalidRequest},
+		{"/v1/nodeData?page=x", http.StatusBadRequest, v1.CodeInvalidRequest},
+		{"/v1/admin/bans", http.StatusServiceUnavailable, v1.CodeUnavailable},
+		{"/v1/records/not-a-peer/profile", http.StatusBadRequest, v1.CodeInvalidRequest},
+		{"/v1/capabilities/teleport/providers", http.StatusBadRequest, v1.CodeInvalidRequest},
+		{"/v1/capabilities/relay/providers?limit=1000", http.StatusBadRequest, v1.CodeInvalidRequest},
+		{"/v1/capabilities/data:twitter/providers", http.StatusServiceUnavailable, v1.CodeUnavailable},
+		{"/v1/capabilities/relay/providers?probe=true", http.StatusForbidden, v1.CodeForbidden},
+		{"/v1/records/16Uiu2HAm2ddQmSZgZXi5Bz9s92FcKLbokU78VfqLFCP4xrL5wMjk/profile", http.StatusServiceUnavailable, v1.CodeUnavailable},
+		{"/v1/openapi.json", http.StatusOK, ""},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if recorder.Code != test.status {
+			t.Errorf("%s: status %d, want %d", test.path, recorder.Code, test.status)
+		}
+		if test.code == "" {
+			continue
+		}
+		var response v1.Response
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Success || response.Error == nil || response.Error.Code != test.code {
+			t.Errorf("%s: unexpected response %s", test.path, recorder.Body.String())
+		}
+	}
+}
+
+func TestJoinTopicBufferSize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	(&API{}).RegisterV1(router.Group(v1.BasePath))
+
+	body := fmt.Sprintf(`{"topic":"test","bufferSize":%d}`, pubsub.MaxBufferSize+1)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/topics/join", strings.NewReader(body)))
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), v1.CodeInvalidRequest) {
+		t.Errorf("expected an oversized buffer to be rejected, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestProbeScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	probe := "GET " + auth.ScopePath(v1.BasePath+"/capabilities/:name/providers", probeScope)
+	if Policy()[probe] != auth.RolePublisher {
+		t.Fatalf("%s requires %q, want %q", probe, Policy()[probe], auth.RolePublisher)
+	}
+
+	tests := []struct {
+		policy map[string]auth.Role
+		status int
+	}{
+		{nil, http.StatusForbidden},
+		// the access file lets readers probe, the request then fails for lack of a DHT
+		{map[string]auth.Role{probe: auth.RoleRead}, http.StatusServiceUnavailable},
+	}
+	for _, test := range tests {
+		authenticator, err := auth.NewAuthenticator(auth.Config{Anonymous: auth.RoleRead, Policy: test.policy}, Policy())
+		if err != nil {
+			t.Fatal(err)
+		}
+		router := gin.New()
+		group := router.Group(v1.BasePath)
+		group.Use(authenticator.Middleware(nil))
+		(&API{}).RegisterV1(group)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/capabilities/relay/providers?probe=true", nil))
+		if recorder.Code != test.status {
+			t.Errorf("policy %v: status %d, want %d", test.policy, recorder.Code, test.status)
+		}
+	}
+}
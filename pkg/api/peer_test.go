@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+
+	masa "github.com/masa-finance/masa-oracle/pkg"
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/network"
+)
+
+func newTestHost(t *testing.T) host.Host {
+	ps, err := network.NewTTLPeerstore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := libp2p.New(libp2p.Peerstore(ps), libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+func TestPeerInfo(t *testing.T) {
+	local, remote := newTestHost(t), newTestHost(t)
+	if err := local.Connect(context.Background(), peer.AddrInfo{ID: remote.ID(), Addrs: remote.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+	api := NewAPI(&masa.OracleNode{Host: local})
+
+	info, err := api.PeerInfo(remote.ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Connectedness != "Connected" || len(info.Connections) != 1 || info.Connections[0].Direction != "Outbound" {
+		t.Errorf("expected one outbound connection, got %+v", info)
+	}
+	if info.PublicKey == "" || info.EthAddress != "" {
+		// the test hosts have Ed25519 keys, which have no ETH address
+		t.Errorf("expected the public key without an ETH address, got %q and %q", info.PublicKey, info.EthAddress)
+	}
+	connected := false
+	for _, addr := range info.Addresses {
+		connected = connected || addr.TTL == peerstore.ConnectedAddrTTL
+	}
+	if !connected {
+		t.Errorf("expected an address with the connected TTL, got %+v", info.Addresses)
+	}
+
+	_, err = api.PeerInfo(newTestHost(t).ID().String())
+	if apiErr, ok := err.(*v1.Error); !ok || apiErr.Code != v1.CodeNotFound {
+		t.Errorf("expected an unknown peer not to be found, got %v", err)
+	}
+}
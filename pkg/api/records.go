@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/routing"
+
+	masa "github.com/masa-finance/masa-oracle/pkg"
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/records"
+)
+
+// recordTimeout bounds the DHT lookups of the record routes.
+const recordTimeout = 30 * time.Second
+
+func (api *API) putRecord(c *gin.Context) (interface{}, *v1.Meta, error) {
+	name := c.Param("name")
+	if !records.ValidName(name) {
+		return nil, nil, v1.InvalidRequest("%s", records.ErrInvalidName.Error())
+	}
+	var request v1.RecordRequest
+	if err := bindBody(c, &request); err != nil {
+		return nil, nil, err
+	}
+	if len(request.Value) > records.MaxValueSize {
+		return nil, nil, v1.InvalidRequest("the value is larger than %d bytes", records.MaxValueSize)
+	}
+	var ttl time.Duration
+	if request.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(request.TTL)
+		if err != nil || ttl <= 0 || ttl > records.MaxTTL {
+			return nil, nil, v1.InvalidRequest("ttl must be a positive duration of at most %s", records.MaxTTL)
+		}
+	}
+	if api.Node == nil || api.Node.DHT == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started its DHT")
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), recordTimeout)
+	defer cancel()
+	record, err := api.Node.PutRecord(ctx, name, request.Value, request.Version, ttl)
+	switch {
+	case errors.Is(err, masa.ErrStaleVersion):
+		return nil, nil, v1.Errorf(http.StatusConflict, v1.CodeConflict, "%s", err.Error())
+	case errors.Is(err, records.ErrNotStaked):
+		return nil, nil, v1.Errorf(http.StatusPreconditionRequired, v1.CodeNotStaked, "node must be staked to put records")
+	case err != nil:
+		return nil, nil, v1.Errorf(http.StatusBadGateway, v1.CodeUnavailable, "failed to put the record: %v", err)
+	}
+	return record, nil, nil
+}
+
+func (api *API) getRecord(c *gin.Context) (interface{}, *v1.Meta, error) {
+	id, err := peer.Decode(c.Param("id"))
+	if err != nil {
+		return nil, nil, v1.InvalidRequest("invalid peer ID: %s", err.Error())
+	}
+	name := c.Param("name")
+	if !records.ValidName(name) {
+		return nil, nil, v1.InvalidRequest("%s", records.ErrInvalidName.Error())
+	}
+	if api.Node == nil || api.Node.DHT == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started its DHT")
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), recordTimeout)
+	defer cancel()
+	record, err := api.Node.GetRecord(ctx, id, name)
+	switch {
+	case errors.Is(err, routing.ErrNotFound):
+		return nil, nil, v1.NotFound("no record %s of peer %s", name, id)
+	case err != nil:
+		return nil, nil, v1.Errorf(http.StatusBadGateway, v1.CodeUnavailable, "failed to get the record: %v", err)
+	}
+	return record, nil, nil
+}
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	masa "github.com/masa-finance/masa-oracle/pkg"
+	"github.com/masa-finance/masa-oracle/pkg/ad"
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	crypto2 "github.com/masa-finance/masa-oracle/pkg/crypto"
+	"github.com/masa-finance/masa-oracle/pkg/network"
+	"github.com/masa-finance/masa-oracle/pkg/pubsub"
+)
+
+// The operations below are shared by the HTTP routes and the gRPC server in pkg/rpc, so both transports behave the
+// same. Failures the caller can act on are returned as *v1.Error.
+
+// Peers returns the peers of the node's routing table.
+func (api *API) Peers() ([]v1.Peer, error) {
+	if api.Node == nil || api.Node.DHT == nil {
+		return nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started its DHT")
+	}
+	peers := make([]v1.Peer, 0)
+	for _, p := range api.Node.DHT.RoutingTable().ListPeers() {
+		peers = append(peers, v1.Peer{ID: p.String()})
+	}
+	return peers, nil
+}
+
+// ConnectedPeers returns the connected peers with the remote addresses of their connections.
+func (api *API) ConnectedPeers() ([]v1.PeerAddresses, error) {
+	if api.Node == nil || api.Node.Host == nil {
+		return nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started its host")
+	}
+	peers := make([]v1.PeerAddresses, 0)
+	for _, p := range api.Node.Host.Network().Peers() {
+		addresses := make([]string, 0)
+		for _, conn := range api.Node.Host.Network().ConnsToPeer(p) {
+			addresses = append(addresses, conn.RemoteMultiaddr().String())
+		}
+		peers = append(peers, v1.PeerAddresses{ID: p.String(), Addresses: addresses})
+	}
+	return peers, nil
+}
+
+// PeerInfo returns what the node knows of a peer. Peers the node has no address, protocol or node data of are not
+// found.
+func (api *API) PeerInfo(id string) (*v1.PeerInfo, error) {
+	peerID, err := peer.Decode(id)
+	if err != nil {
+		return nil, v1.InvalidRequest("invalid peer ID: %s", err.Error())
+	}
+	if api.Node == nil || api.Node.Host == nil {
+		return nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node has not started its host")
+	}
+	host := api.Node.Host
+	peerstore := host.Peerstore()
+	info := &v1.PeerInfo{
+		ID:            peerID.String(),
+		Connectedness: host.Network().Connectedness(peerID).String(),
+		Protocols:     make([]string, 0),
+		Addresses:     make([]v1.PeerAddress, 0),
+		Connections:   make([]v1.PeerConnection, 0),
+		Streams:       make(map[string]int),
+		Latency:       peerstore.LatencyEWMA(peerID),
+	}
+	if agentVersion, err := peerstore.Get(peerID, "AgentVersion"); err == nil {
+		info.AgentVersion, _ = agentVersion.(string)
+	}
+	if protocolVersion, err := peerstore.Get(peerID, "ProtocolVersion"); err == nil {
+		info.ProtocolVersion, _ = protocolVersion.(string)
+	}
+	if protocols, err := peerstore.GetProtocols(peerID); err == nil {
+		for _, protocol := range protocols {
+			info.Protocols = append(info.Protocols, string(protocol))
+		}
+		sort.Strings(info.Protocols)
+	}
+	if pubKey := peerstore.PubKey(peerID); pubKey != nil {
+		if data, err := crypto.MarshalPublicKey(pubKey); err == nil {
+			info.PublicKey = hex.EncodeToString(data)
+		}
+		if ethAddress, err := crypto2.Libp2pPubKeyToEthAddress(pubKey); err == nil {
+			info.EthAddress = ethAddress
+		}
+	}
+	if ttlPeerstore, ok := peerstore.(*network.TTLPeerstore); ok {
+		for _, addr := range ttlPeerstore.AddrTTLs(peerID) {
+			info.Addresses = append(info.Addresses, v1.PeerAddress{Address: addr.Addr.String(), TTL: addr.TTL})
+		}
+	} else {
+		for _, addr := range peerstore.Addrs(peerID) {
+			info.Addresses = append(info.Addresses, v1.PeerAddress{Address: addr.String()})
+		}
+	}
+	for _, conn := range host.Network().ConnsToPeer(peerID) {
+		stat, state := conn.Stat(), conn.ConnState()
+		streams := conn.GetStreams()
+		info.Connections = append(info.Connections, v1.PeerConnection{
+			ID:            conn.ID(),
+			Direction:     stat.Direction.String(),
+			Transport:     state.Transport,
+			Security:      string(state.Security),
+			Muxer:         string(state.StreamMultiplexer),
+			LocalAddress:  conn.LocalMultiaddr().String(),
+			RemoteAddress: conn.RemoteMultiaddr().String(),
+			Opened:        stat.Opened,
+			Transient:     stat.Transient,
+			Streams:       len(streams),
+		})
+		for _, stream := range streams {
+			info.Streams[string(stream.Protocol())]++
+		}
+	}
+	if api.Node.NodeTracker != nil {
+		if nodeData, ok := api.Node.NodeTracker.GetNodeData(peerID.String()); ok {
+			info.NodeData = &nodeData
+		}
+	}
+	if len(info.Addresses) == 0 && len(info.Protocols) == 0 && len(info.Connections) == 0 && info.NodeData == nil {
+		return nil, v1.NotFound("peer %s is not known to the node", peerID)
+	}
+	return info, nil
+}
+
+// NodeDataPage returns a page of the data of the nodes seen on the network matching params.
+func (api *API) NodeDataPage(params v1.NodeDataParams) ([]pubsub.NodeData, *v1.Meta, error) {
+	query, err := nodeQuery(params)
+	if err != nil {
+		return nil, nil, v1.InvalidRequest("%s", err.Error())
+	}
+	if api.Node == nil || api.Node.NodeTracker == nil {
+		return nil, nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "the node does not track node data")
+	}
+	page, err := api.Node.NodeTracker.Query(query)
+	if err != nil {
+		return nil, nil, v1.InvalidRequest("%s", err.Error())
+	}
+	meta := &v1.Meta{
+		TotalCount: page.Total,
+		NextCursor: page.NextCursor,
+		TotalPages: int(math.Ceil(float64(page.Total) / float64(params.PageSize))),
+	}
+	if params.Cursor == "" {
+		meta.Page = params.Page
+	}
+	return page.Nodes, meta, nil
+}
+
+func nodeQuery(params v1.NodeDataParams) (pubsub.NodeQuery, error) {
+	query := pubsub.NodeQuery{
+		Active:               params.Active,
+		Staked:               params.Staked,
+		EthAddress:           params.EthAddress,
+		MinAccumulatedUptime: params.MinUptime,
+		LastSeenSince:        params.LastSeenSince,
+		Transport:            params.Transport,
+		SortBy:               params.Sort,
+		Descending:           params.Order == "desc",
+		Cursor:               params.Cursor,
+		Offset:               params.Page * params.PageSize,
+		Limit:                params.PageSize,
+	}
+	if params.Page < 0 || params.PageSize < 1 || params.PageSize > maxNodeDataPageSize {
+		return query, fmt.Errorf("page must be positive and pageSize between 1 and %d", maxNodeDataPageSize)
+	}
+	if params.Cursor != "" && params.Page != 0 {
+		return query, errors.New("page cannot be combined with a cursor")
+	}
+	if params.Order != "" && params.Order != "asc" && params.Order != "desc" {
+		return query, errors.New("order must be asc or desc")
+	}
+	if params.MinUptime < 0 {
+		return query, errors.New("minUptime cannot be negative")
+	}
+	return query, nil
+}
+
+// PublishAdJSON validates an ad in its JSON form, signs it with the node's signer and publishes it on the ad topic.
+func (api *API) PublishAdJSON(body []byte) (*ad.SignedAd, error) {
+	newAd, err := ad.Decode(body)
+	var validationErr *ad.ValidationError
+	if errors.As(err, &validationErr) {
+		return nil, &v1.Error{Code: v1.CodeInvalidRequest, Message: "Invalid ad", Details: validationErr.Errors, Status: http.StatusBadRequest}
+	}
+	if err != nil {
+		return nil, v1.InvalidRequest("%s", err.Error())
+	}
+	// the signer's address publishes the ad, receiving nodes check its stake and limit its ads
+	publisher := api.Node.Signer.Address().Hex()
+	if newAd.Publisher != "" && !strings.EqualFold(newAd.Publisher, publisher) {
+		return nil, &v1.Error{Code: v1.CodeInvalidRequest, Message: "Invalid ad", Status: http.StatusBadRequest, Details: []ad.FieldError{
+			{Field: "/Publisher", Message: "does not match the address of this node"},
+		}}
+	}
+	staked, err := api.Node.StakeCache.IsStaked(publisher)
+	if err != nil {
+		return nil, v1.Errorf(http.StatusServiceUnavailable, v1.CodeUnavailable, "could not check the stake of %s: %v", publisher, err)
+	}
+	if !staked {
+		return nil, v1.Errorf(http.StatusPreconditionRequired, v1.CodeNotStaked, "node must be staked to be an ad publisher")
+	}
+	reservation, err := api.Node.AdRateLimiter.Reserve(publisher)
+	if err != nil {
+		return nil, v1.Errorf(http.StatusTooManyRequests, v1.CodeRateLimited, "%s", err.Error())
+	}
+	signedAd, err := ad.NewSignedAd(newAd, api.Node.Signer)
+	if err != nil {
+		reservation.Cancel()
+		return nil, v1.Internal(err)
+	}
+	bodyBytes, err := json.Marshal(signedAd)
+	if err != nil {
+		reservation.Cancel()
+		return nil, v1.Internal(err)
+	}
+	if err := api.Node.PubSubManager.Publish(masa.AdTopic, bodyBytes); err != nil {
+		// a failed publish does not use the quota
+		reservation.Cancel()
+		return nil, v1.Internal(err)
+	}
+	return signedAd, nil
+}
+
+// QueryAds returns a page of the received ads matching params.
+func (api *API) QueryAds(params v1.AdsParams) (ad.Page, error) {
+	query, err := adQuery(params)
+	if err != nil {
+		return ad.Page{}, v1.InvalidRequest("%s", err.Error())
+	}
+	page, err := api.Node.AdStore.Query(query)
+	if err != nil {
+		return ad.Page{}, v1.InvalidRequest("%s", err.Error())
+	}
+	return page, nil
+}
+
+// SubscribeAdTopic subscribes the node to the ad topic, keeping the received ads in its ad store. It succeeds when
+// the node is already subscribed, as a started node always is.
+func (api *API) SubscribeAdTopic() error {
+	err := api.Node.PubSubManager.AddSubscription(masa.AdTopic, ad.NewSubscriptionHandler(api.Node.AdStore))
+	if err != nil && !errors.Is(err, pubsub.ErrAlreadySubscribed) {
+		return v1.Internal(err)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package api
+
+import "github.com/masa-finance/masa-oracle/pkg/pubsub"
+
+// gatewayHandler returns the buffer of a topic joined through the gateway. Topics the node joined itself have
+// their own handlers and can not be changed through the gateway.
+func (api *API) gatewayHandler(topic string) (*pubsub.BufferHandler, bool) {
+	handler, err := api.Node.PubSubManager.GetHandler(topic)
+	if err != nil {
+		return nil, false
+	}
+	buffer, ok := handler.(*pubsub.BufferHandler)
+	return buffer, ok
+}
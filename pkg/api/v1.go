@@ -0,0 +1,420 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+
+	masa "github.com/masa-finance/masa-oracle/pkg"
+	"github.com/masa-finance/masa-oracle/pkg/ad"
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/auth"
+	"github.com/masa-finance/masa-oracle/pkg/events"
+	"github.com/masa-finance/masa-oracle/pkg/network"
+	"github.com/masa-finance/masa-oracle/pkg/pubsub"
+	"github.com/masa-finance/masa-oracle/pkg/records"
+	"github.com/masa-finance/masa-oracle/pkg/webhook"
+)
+
+const maxNodeDataPageSize = 100
+
+// v1Handler handles a version 1 request. It returns the data of the response, with meta data for lists, or an
+// error, a *v1.Error to choose the status and code.
+type v1Handler func(api *API, c *gin.Context) (interface{}, *v1.Meta, error)
+
+// Endpoint describes a version 1 route. The OpenAPI document and the Go client in pkg/client are generated from the
+// endpoints, so Params, Body and Result must be the types the handler actually binds and returns.
+type Endpoint struct {
+	// Name is the operation id and the method name of the client
+	Name   string
+	Method string
+	// Path is relative to v1.BasePath, in gin syntax
+	Path    string
+	Summary string
+	// Role is the role the route requires, the default of auth.Authenticator.Required when empty
+	Role auth.Role
+	// Params is a struct of the query parameters, with form tags
+	Params interface{}
+	// Body is the JSON request body
+	Body interface{}
+	// Result is the data of a successful response
+	Result interface{}
+	// Stream is the content type of endpoints streaming their response, they are left out of the client
+	Stream string
+	// Scopes are the parts of the endpoint requiring their own role
+	Scopes []Scope
+
+	handle v1Handler
+	stream func(api *API) gin.HandlerFunc
+}
+
+// Scope is a part of an endpoint, like a sensitive event topic, that requires another role than the endpoint. The
+// policy names it auth.ScopePath of the endpoint's path, so the access file can override its role too.
+type Scope struct {
+	Name    string    `json:"name"`
+	Summary string    `json:"summary"`
+	Role    auth.Role `json:"role"`
+}
+
+// eventScopes gate the event topics that are not for every reader of the event streams: the bridge events carry the
+// payloads of the webhooks forwarded to the node.
+var eventScopes = []Scope{
+	{Name: events.TopicBridge, Summary: "Streams the bridge events", Role: auth.RoleAdmin},
+}
+
+// providerScopes gate probing the providers, which makes the node dial the peers found on the DHT.
+var providerScopes = []Scope{
+	{Name: probeScope, Summary: "Probes the reachability of the providers", Role: auth.RolePublisher},
+}
+
+// Endpoints returns the version 1 routes.
+func Endpoints() []Endpoint {
+	return []Endpoint{
+		{Name: "ListPeers", Method: http.MethodGet, Path: "/peers", Summary: "Lists the peers of the routing table",
+			Result: []v1.Peer{}, handle: (*API).listPeers},
+		{Name: "ListPeerAddresses", Method: http.MethodGet, Path: "/peers/addresses", Summary: "Lists the connected peers with their addresses",
+			Result: []v1.PeerAddresses{}, handle: (*API).listPeerAddresses},
+		{Name: "GetPeer", Method: http.MethodGet, Path: "/peers/:id", Summary: "Inspects a peer from the peerstore, its connections and node data",
+			Result: v1.PeerInfo{}, handle: (*API).getPeer},
+		{Name: "ListNodeData", Method: http.MethodGet, Path: "/nodeData", Summary: "Lists the data of the nodes seen on the network, filtered and sorted",
+			Params: v1.NodeDataParams{}, Result: []pubsub.NodeData{}, handle: (*API).listNodeData},
+		{Name: "PublishAd", Method: http.MethodPost, Path: "/ads", Summary: "Signs an ad and publishes it on the ad topic",
+			Role: auth.RolePublisher, Body: ad.Ad{}, Result: ad.SignedAd{}, handle: (*API).publishAdV1},
+		{Name: "ListAds", Method: http.MethodGet, Path: "/ads", Summary: "Lists the received ads",
+			Params: v1.AdsParams{}, Result: []ad.Ad{}, handle: (*API).listAds},
+		{Name: "GetAdSchema", Method: http.MethodGet, Path: "/ads/schema", Summary: "Returns the JSON schema of an ad version",
+			Params: v1.AdSchemaParams{}, Result: map[string]interface{}{}, handle: (*API).getAdSchema},
+		{Name: "GetAdLimits", Method: http.MethodGet, Path: "/ads/limits", Summary: "Returns the ad limits and the ads accepted and rejected per publisher",
+			Result: v1.AdLimits{}, handle: (*API).getAdLimits},
+		{Name: "SubscribeToAds", Method: http.MethodPost, Path: "/ads/subscribe", Summary: "Subscribes the node to the ad topic",
+			Role: auth.RolePublisher, handle: (*API).subscribeToAds},
+		{Name: "ListTopics", Method: http.MethodGet, Path: "/topics", Summary: "Lists the joined topics",
+			Result: []v1.Topic{}, handle: (*API).listTopics},
+		{Name: "JoinTopic", Method: http.MethodPost, Path: "/topics/join", Summary: "Joins a topic and buffers its messages",
+			Body: v1.TopicRequest{}, handle: (*API).joinTopic},
+		{Name: "LeaveTopic", Method: http.MethodPost, Path: "/topics/leave", Summary: "Leaves a topic joined through the API",
+			Body: v1.TopicRequest{}, handle: (*API).leaveTopic},
+		{Name: "PublishToTopic", Method: http.MethodPost, Path: "/topics/publish", Summary: "Publishes a message on a topic joined through the API",
+			Role: auth.RolePublisher, Body: v1.TopicRequest{}, handle: (*API).publishToTopic},
+		{Name: "ListTopicMessages", Method: http.MethodGet, Path: "/topics/messages", Summary: "Lists the buffered messages of a topic joined through the API",
+			Role: auth.RoleAdmin, Params: v1.TopicMessagesParams{}, Result: []pubsub.ReceivedMessage{}, handle: (*API).listTopicMessages},
+		{Name: "ListWebhooks", Method: http.MethodGet, Path: "/webhooks", Summary: "Lists the webhook subscriptions",
+			Role: auth.RoleAdmin, Result: []webhook.Subscription{}, handle: (*API).listWebhooks},
+		{Name: "ListWebhookDeliveries", Method: http.MethodGet, Path: "/webhooks/deliveries", Summary: "Lists the most recent webhook delivery attempts",
+			Role: auth.RoleAdmin, Params: v1.WebhookDeliveriesParams{}, Result: []webhook.LogEntry{}, handle: (*API).listWebhookDeliveries},
+		{Name: "PutRecord", Method: http.MethodPut, Path: "/records/:name", Summary: "Signs a record of the node and stores it on the DHT",
+			Role: auth.RolePublisher, Body: v1.RecordRequest{}, Result: records.Record{}, handle: (*API).putRecord},
+		{Name: "GetRecord", Method: http.MethodGet, Path: "/records/:id/:name", Summary: "Looks up the record of a peer on the DHT",
+			Result: records.Record{}, handle: (*API).getRecord},
+		{Name: "ListCapabilities", Method: http.MethodGet, Path: "/capabilities", Summary: "Lists the capabilities the node advertises",
+			Result: []network.Capability{}, handle: (*API).listCapabilities},
+		{Name: "FindProviders", Method: http.MethodGet, Path: "/capabilities/:name/providers", Summary: "Finds the nodes advertising a capability and their reachability",
+			Params: v1.ProvidersParams{}, Result: []network.Provider{}, Scopes: providerScopes, handle: (*API).findProviders},
+		{Name: "ConnectPeer", Method: http.MethodPost, Path: "/admin/peers/connect", Summary: "Dials a peer at a multiaddress",
+			Role: auth.RoleAdmin, Body: v1.AddressRequest{}, Result: v1.Peer{}, handle: (*API).connectPeer},
+		{Name: "DisconnectPeer", Method: http.MethodPost, Path: "/admin/peers/:id/disconnect", Summary: "Closes the connections to a peer",
+			Role: auth.RoleAdmin, handle: (*API).disconnectPeer},
+		{Name: "ListBans", Method: http.MethodGet, Path: "/admin/bans", Summary: "Lists the banned peers",
+			Role: auth.RoleAdmin, Result: []network.Ban{}, handle: (*API).listBans},
+		{Name: "BanPeer", Method: http.MethodPost, Path: "/admin/bans", Summary: "Bans a peer for a while and disconnects it",
+			Role: auth.RoleAdmin, Body: v1.BanRequest{}, Result: network.Ban{}, handle: (*API).banPeer},
+		{Name: "UnbanPeer", Method: http.MethodDelete, Path: "/admin/bans/:id", Summary: "Lifts the ban of a peer",
+			Role: auth.RoleAdmin, handle: (*API).unbanPeer},
+		{Name: "ListBootnodes", Method: http.MethodGet, Path: "/admin/bootnodes", Summary: "Lists the bootnodes",
+			Role: auth.RoleAdmin, Result: []string{}, handle: (*API).listBootnodes},
+		{Name: "AddBootnode", Method: http.MethodPost, Path: "/admin/bootnodes", Summary: "Connects to a bootnode and adds it to the bootnodes",
+			Role: auth.RoleAdmin, Body: v1.AddressRequest{}, handle: (*API).addBootnode},
+		{Name: "RemoveBootnode", Method: http.MethodDelete, Path: "/admin/bootnodes", Summary: "Removes a bootnode",
+			Role: auth.RoleAdmin, Params: v1.BootnodeParams{}, handle: (*API).removeBootnode},
+		{Name: "RefreshDHT", Method: http.MethodPost, Path: "/admin/dht/refresh", Summary: "Reconnects to the bootnodes and refreshes the DHT routing table",
+			Role: auth.RoleAdmin, Result: v1.DHTRefresh{}, handle: (*API).refreshDHT},
+		{Name: "StreamEvents", Method: http.MethodGet, Path: "/events", Summary: "Streams the node's events as server-sent events",
+			Params: eventsParams{}, Stream: "text/event-stream", Scopes: eventScopes, stream: (*API).StreamEvents},
+		{Name: "StreamEventsWebSocket", Method: http.MethodGet, Path: "/events/ws", Summary: "Streams the node's events over a WebSocket",
+			Params: eventsParams{}, Stream: "application/json", Scopes: eventScopes, stream: (*API).StreamEventsWebSocket},
+	}
+}
+
+// eventsParams documents the parameters read by parseEventsQuery.
+type eventsParams struct {
+	// Topics are comma separated or repeated
+	Topics []string `form:"topics"`
+	Since  uint64   `form:"since"`
+}
+
+// RequiredRole returns the role the endpoint requires.
+func (e Endpoint) RequiredRole() auth.Role {
+	if e.Role != auth.RoleNone {
+		return e.Role
+	}
+	if e.Method == http.MethodGet {
+		return auth.RoleRead
+	}
+	return auth.RoleAdmin
+}
+
+// Policy returns the roles of the version 1 routes and their scopes for auth.NewAuthenticator.
+func Policy() map[string]auth.Role {
+	policy := make(map[string]auth.Role)
+	for _, e := range Endpoints() {
+		policy[e.Method+" "+v1.BasePath+e.Path] = e.RequiredRole()
+		for _, scope := range e.Scopes {
+			policy[e.Method+" "+auth.ScopePath(v1.BasePath+e.Path, scope.Name)] = scope.Role
+		}
+	}
+	return policy
+}
+
+// allowsScope reports whether the caller has the role the policy gives a scope of the route of c. Without the auth
+// middleware nobody has.
+func allowsScope(c *gin.Context, scope string) bool {
+	authenticator, ok := c.Value(auth.AuthenticatorKey).(*auth.Authenticator)
+	if !ok {
+		return false
+	}
+	return callerRole(c).Allows(authenticator.Required(c.Request.Method, auth.ScopePath(c.FullPath(), scope)))
+}
+
+// RegisterV1 adds the version 1 routes and the OpenAPI document to the group.
+func (api *API) RegisterV1(group *gin.RouterGroup) {
+	endpoints := Endpoints()
+	for _, e := range endpoints {
+		if e.stream != nil {
+			group.Handle(e.Method, e.Path, e.stream(api))
+		} else {
+			group.Handle(e.Method, e.Path, api.v1Handler(e.handle))
+		}
+	}
+	document := OpenAPI(endpoints)
+	group.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, document)
+	})
+}
+
+// Deprecated marks a route as a deprecated alias of its version 1 successor.
+func Deprecated(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+v1.BasePath+successor+`>; rel="successor-version"`)
+	}
+}
+
+func (api *API) v1Handler(handle v1Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, meta, err := handle(api, c)
+		if err != nil {
+			var apiErr *v1.Error
+			if !errors.As(err, &apiErr) {
+				apiErr = v1.Internal(err)
+			}
+			c.JSON(apiErr.Status, v1.Response{Error: apiErr})
+			return
+		}
+		c.JSON(http.StatusOK, v1.Response{Success: true, Data: data, Meta: meta})
+	}
+}
+
+// bindQuery reads the query parameters into params, a pointer to the Params of the endpoint.
+func bindQuery(c *gin.Context, params interface{}) error {
+	if err := c.ShouldBindQuery(params); err != nil {
+		return v1.InvalidRequest("invalid query parameters: %v", err)
+	}
+	return nil
+}
+
+// bindBody reads the JSON body into body, a pointer to the Body of the endpoint.
+func bindBody(c *gin.Context, body interface{}) error {
+	if err := c.ShouldBindJSON(body); err != nil {
+		return v1.InvalidRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+func listMeta(list interface{}) *v1.Meta {
+	return &v1.Meta{TotalCount: reflect.ValueOf(list).Len()}
+}
+
+func (api *API) listPeers(c *gin.Context) (interface{}, *v1.Meta, error) {
+	peers, err := api.Peers()
+	if err != nil {
+		return nil, nil, err
+	}
+	return peers, listMeta(peers), nil
+}
+
+func (api *API) listPeerAddresses(c *gin.Context) (interface{}, *v1.Meta, error) {
+	peers, err := api.ConnectedPeers()
+	if err != nil {
+		return nil, nil, err
+	}
+	return peers, listMeta(peers), nil
+}
+
+func (api *API) getPeer(c *gin.Context) (interface{}, *v1.Meta, error) {
+	info, err := api.PeerInfo(c.Param("id"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, nil, nil
+}
+
+func (api *API) listNodeData(c *gin.Context) (interface{}, *v1.Meta, error) {
+	params := v1.NodeDataParams{PageSize: masa.PageSize}
+	if err := bindQuery(c, &params); err != nil {
+		return nil, nil, err
+	}
+	data, meta, err := api.NodeDataPage(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, meta, nil
+}
+
+func (api *API) publishAdV1(c *gin.Context) (interface{}, *v1.Meta, error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, nil, v1.InvalidRequest("invalid request body")
+	}
+	signedAd, err := api.PublishAdJSON(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signedAd, nil, nil
+}
+
+func (api *API) listAds(c *gin.Context) (interface{}, *v1.Meta, error) {
+	var params v1.AdsParams
+	if err := bindQuery(c, &params); err != nil {
+		return nil, nil, err
+	}
+	page, err := api.QueryAds(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return page.Ads, &v1.Meta{TotalCount: page.Total, NextCursor: page.NextCursor}, nil
+}
+
+func (api *API) getAdSchema(c *gin.Context) (interface{}, *v1.Meta, error) {
+	params := v1.AdSchemaParams{Version: ad.SchemaVersion}
+	if err := bindQuery(c, &params); err != nil {
+		return nil, nil, err
+	}
+	schema, err := ad.Schema(params.Version)
+	if err != nil {
+		return nil, nil, v1.NotFound("unknown ad version %d", params.Version)
+	}
+	return json.RawMessage(schema), nil, nil
+}
+
+func (api *API) getAdLimits(c *gin.Context) (interface{}, *v1.Meta, error) {
+	limits := v1.AdLimits{Limits: api.Node.AdRateLimiter.Config(), Publishers: api.Node.AdRateLimiter.Stats()}
+	return limits, listMeta(limits.Publishers), nil
+}
+
+func (api *API) subscribeToAds(c *gin.Context) (interface{}, *v1.Meta, error) {
+	return nil, nil, api.SubscribeAdTopic()
+}
+
+func (api *API) listTopics(c *gin.Context) (interface{}, *v1.Meta, error) {
+	topics := make([]v1.Topic, 0)
+	for _, name := range api.Node.PubSubManager.ListTopics() {
+		ids, _ := api.Node.PubSubManager.ListPeers(name)
+		peers := make([]string, 0, len(ids))
+		for _, id := range ids {
+			peers = append(peers, id.String())
+		}
+		_, gateway := api.gatewayHandler(name)
+		topics = append(topics, v1.Topic{Topic: name, Peers: peers, Gateway: gateway})
+	}
+	return topics, listMeta(topics), nil
+}
+
+func (api *API) joinTopic(c *gin.Context) (interface{}, *v1.Meta, error) {
+	var request v1.TopicRequest
+	if err := bindBody(c, &request); err != nil {
+		return nil, nil, err
+	}
+	if request.BufferSize > pubsub.MaxBufferSize {
+		return nil, nil, v1.InvalidRequest("bufferSize must be at most %d", pubsub.MaxBufferSize)
+	}
+	if _, err := api.Node.PubSubManager.GetHandler(request.Topic); err == nil {
+		return nil, nil, v1.Errorf(http.StatusConflict, v1.CodeConflict, "topic %s is already subscribed", request.Topic)
+	}
+	if err := api.Node.PubSubManager.AddSubscription(request.Topic, pubsub.NewBufferHandler(request.BufferSize)); err != nil {
+		return nil, nil, v1.Internal(err)
+	}
+	return nil, nil, nil
+}
+
+func (api *API) leaveTopic(c *gin.Context) (interface{}, *v1.Meta, error) {
+	var request v1.TopicRequest
+	if err := bindBody(c, &request); err != nil {
+		return nil, nil, err
+	}
+	if _, ok := api.gatewayHandler(request.Topic); !ok {
+		return nil, nil, v1.NotFound("topic %s was not joined through the API", request.Topic)
+	}
+	if err := api.Node.PubSubManager.RemoveSubscription(request.Topic); err != nil {
+		return nil, nil, v1.Internal(err)
+	}
+	return nil, nil, nil
+}
+
+func (api *API) publishToTopic(c *gin.Context) (interface{}, *v1.Meta, error) {
+	var request v1.TopicRequest
+	if err := bindBody(c, &request); err != nil {
+		return nil, nil, err
+	}
+	if request.Message == "" {
+		return nil, nil, v1.InvalidRequest("message is required")
+	}
+	if _, ok := api.gatewayHandler(request.Topic); !ok {
+		return nil, nil, v1.NotFound("topic %s was not joined through the API", request.Topic)
+	}
+	if err := api.Node.PubSubManager.Publish(request.Topic, []byte(request.Message)); err != nil {
+		return nil, nil, v1.Internal(err)
+	}
+	return nil, nil, nil
+}
+
+func (api *API) listTopicMessages(c *gin.Context) (interface{}, *v1.Meta, error) {
+	var params v1.TopicMessagesParams
+	if err := bindQuery(c, &params); err != nil {
+		return nil, nil, err
+	}
+	if params.Limit < 0 {
+		return nil, nil, v1.InvalidRequest("limit must be positive")
+	}
+	buffer, ok := api.gatewayHandler(params.Topic)
+	if !ok {
+		return nil, nil, v1.NotFound("topic %s was not joined through the API", params.Topic)
+	}
+	messages := buffer.Messages(params.Limit)
+	return messages, listMeta(messages), nil
+}
+
+func (api *API) listWebhooks(c *gin.Context) (interface{}, *v1.Meta, error) {
+	if api.Node.Webhooks == nil {
+		return nil, nil, v1.NotFound("no webhooks configured")
+	}
+	subscriptions := api.Node.Webhooks.Subscriptions()
+	return subscriptions, listMeta(subscriptions), nil
+}
+
+func (api *API) listWebhookDeliveries(c *gin.Context) (interface{}, *v1.Meta, error) {
+	params := v1.WebhookDeliveriesParams{Limit: 100}
+	if err := bindQuery(c, &params); err != nil {
+		return nil, nil, err
+	}
+	if params.Limit < 0 {
+		return nil, nil, v1.InvalidRequest("limit must be positive")
+	}
+	if api.Node.Webhooks == nil {
+		return nil, nil, v1.NotFound("no webhooks configured")
+	}
+	entries := api.Node.Webhooks.Log(params.Subscription, params.Limit)
+	return entries, listMeta(entries), nil
+}
@@ -0,0 +1,247 @@
+// Package v1 holds the request and response types of version 1 of the node API, shared by the server in pkg/api
+// and the client in pkg/client.
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/masa-finance/masa-oracle/pkg/ad"
+	"github.com/masa-finance/masa-oracle/pkg/pubsub"
+)
+
+// BasePath is the prefix of the version 1 routes.
+const BasePath = "/v1"
+
+// Error codes, stable across releases so clients can branch on them instead of on messages.
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeUnauthorized   = "unauthorized"
+	CodeForbidden      = "forbidden"
+	CodeNotFound       = "not_found"
+	CodeConflict       = "conflict"
+	CodeRateLimited    = "rate_limited"
+	CodeNotStaked      = "not_staked"
+	CodeUnavailable    = "unavailable"
+	CodeInternal       = "internal"
+)
+
+// Response is the envelope of every version 1 response. Data is set on success, Error on failure.
+type Response struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data,omitempty"`
+	Meta    *Meta       `json:"meta,omitempty"`
+	Error   *Error      `json:"error,omitempty"`
+}
+
+// Meta describes a list in Data.
+type Meta struct {
+	TotalCount int `json:"totalCount"`
+	// NextCursor continues after the last item of a page, empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+	Page       int    `json:"page,omitempty"`
+	TotalPages int    `json:"totalPages,omitempty"`
+}
+
+// Error is a failed request. Details holds code specific data, such as the invalid fields of an ad.
+type Error struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+	// Status is the HTTP status of the response
+	Status int `json:"-"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Errorf returns an error with the given status and code.
+func Errorf(status int, code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...), Status: status}
+}
+
+// InvalidRequest returns a 400 error.
+func InvalidRequest(format string, args ...interface{}) *Error {
+	return Errorf(http.StatusBadRequest, CodeInvalidRequest, format, args...)
+}
+
+// NotFound returns a 404 error.
+func NotFound(format string, args ...interface{}) *Error {
+	return Errorf(http.StatusNotFound, CodeNotFound, format, args...)
+}
+
+// Internal returns a 500 error.
+func Internal(err error) *Error {
+	return Errorf(http.StatusInternalServerError, CodeInternal, "%s", err.Error())
+}
+
+// Peer is a peer of the node's routing table.
+type Peer struct {
+	ID string `json:"id"`
+}
+
+// PeerAddresses lists the remote addresses of the connections to a peer.
+type PeerAddresses struct {
+	ID        string   `json:"id"`
+	Addresses []string `json:"addresses"`
+}
+
+// PeerInfo is what the node knows of a peer from its peerstore, connections and node data.
+type PeerInfo struct {
+	ID string `json:"id"`
+	// Connectedness is NotConnected, Connected, CanConnect or CannotConnect
+	Connectedness   string `json:"connectedness"`
+	AgentVersion    string `json:"agentVersion"`
+	ProtocolVersion string `json:"protocolVersion"`
+	// Protocols are the protocols the peer announced through identify
+	Protocols []string `json:"protocols"`
+	// PublicKey is the hex of the protobuf encoded libp2p public key
+	PublicKey   string           `json:"publicKey"`
+	EthAddress  string           `json:"ethAddress"`
+	Addresses   []PeerAddress    `json:"addresses"`
+	Connections []PeerConnection `json:"connections"`
+	// Streams counts the open streams per protocol
+	Streams map[string]int `json:"streams"`
+	// Latency is the moving average of the measured latency, zero when not measured
+	Latency  time.Duration    `json:"latency"`
+	NodeData *pubsub.NodeData `json:"nodeData,omitempty"`
+}
+
+// PeerAddress is an address of a peer in the peerstore. TTL is how long it is kept after it was last seen, zero when
+// unknown.
+type PeerAddress struct {
+	Address string        `json:"address"`
+	TTL     time.Duration `json:"ttl"`
+}
+
+// PeerConnection is an open connection to a peer.
+type PeerConnection struct {
+	ID string `json:"id"`
+	// Direction is Inbound or Outbound
+	Direction     string    `json:"direction"`
+	Transport     string    `json:"transport"`
+	Security      string    `json:"security"`
+	Muxer         string    `json:"muxer"`
+	LocalAddress  string    `json:"localAddress"`
+	RemoteAddress string    `json:"remoteAddress"`
+	Opened        time.Time `json:"opened"`
+	// Transient connections, like relayed ones, may be closed soon
+	Transient bool `json:"transient"`
+	Streams   int  `json:"streams"`
+}
+
+// NodeDataParams filter, sort and paginate node data. Pages are numbered from 0, a cursor continues after a previous
+// page instead.
+type NodeDataParams struct {
+	Active     *bool  `form:"active"`
+	Staked     *bool  `form:"staked"`
+	EthAddress string `form:"ethAddress"`
+	// MinUptime is the minimum accumulated uptime, like 24h
+	MinUptime     time.Duration `form:"minUptime"`
+	LastSeenSince time.Time     `form:"lastSeenSince" time_format:"2006-01-02T15:04:05Z07:00"`
+	// Transport is a protocol of the node's multiaddresses, like tcp, udp, quic-v1 or ws
+	Transport string `form:"transport"`
+	// Sort is a field of the node data, lastUpdated by default
+	Sort string `form:"sort"`
+	// Order is asc or desc
+	Order    string `form:"order"`
+	Cursor   string `form:"cursor"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"pageSize"`
+}
+
+// AdsParams filter, sort and paginate ads.
+type AdsParams struct {
+	// Metadata holds key:value pairs the ads must all have
+	Metadata  []string  `form:"metadata"`
+	Publisher string    `form:"publisher"`
+	Q         string    `form:"q"`
+	Since     time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until     time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	// Sort is receivedAt, expiresAt or publisher
+	Sort string `form:"sort"`
+	// Order is asc or desc
+	Order  string `form:"order"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
+
+// AdSchemaParams select the ad schema version, the current one when zero.
+type AdSchemaParams struct {
+	Version int `form:"version"`
+}
+
+// AdLimits are the ad limits of the node and the ads accepted and rejected per publisher.
+type AdLimits struct {
+	Limits     ad.RateLimitConfig  `json:"limits"`
+	Publishers []ad.PublisherStats `json:"publishers"`
+}
+
+// Topic is a pubsub topic the node joined.
+type Topic struct {
+	Topic string   `json:"topic"`
+	Peers []string `json:"peers"`
+	// Gateway is set for topics joined through the API
+	Gateway bool `json:"gateway"`
+}
+
+// TopicRequest joins, leaves or publishes to a topic. BufferSize applies to joins and is at most
+// pubsub.MaxBufferSize, Message applies to publishing.
+type TopicRequest struct {
+	Topic      string `json:"topic" binding:"required"`
+	BufferSize int    `json:"bufferSize,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// TopicMessagesParams select the most recent messages of a topic, all buffered ones when Limit is zero.
+type TopicMessagesParams struct {
+	Topic string `form:"topic" binding:"required"`
+	Limit int    `form:"limit"`
+}
+
+// WebhookDeliveriesParams select the most recent delivery attempts of a subscription, of all when it is empty.
+type WebhookDeliveriesParams struct {
+	Subscription string `form:"subscription"`
+	Limit        int    `form:"limit"`
+}
+
+// AddressRequest names a peer or a bootnode by a multiaddress ending in /p2p/<peer ID>.
+type AddressRequest struct {
+	Address string `json:"address" binding:"required"`
+}
+
+// BanRequest bans a peer for Duration, like 1h, 24h by default.
+type BanRequest struct {
+	PeerID   string `json:"peerId" binding:"required"`
+	Duration string `json:"duration,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// BootnodeParams select a bootnode by its multiaddress.
+type BootnodeParams struct {
+	Address string `form:"address" binding:"required"`
+}
+
+// DHTRefresh reports the state of the DHT after a refresh.
+type DHTRefresh struct {
+	ConnectedBootnodes int `json:"connectedBootnodes"`
+	RoutingTableSize   int `json:"routingTableSize"`
+}
+
+// RecordRequest puts a record of the node on the DHT. TTL is a duration like 1h, 24h by default, and a zero
+// Version is the version after the stored record's.
+type RecordRequest struct {
+	Value   json.RawMessage `json:"value" binding:"required"`
+	TTL     string          `json:"ttl,omitempty"`
+	Version uint64          `json:"version,omitempty"`
+}
+
+// ProvidersParams select how many providers of a capability are looked up, 20 by default, and whether the ones the
+// node is not connected to are dialed to check their reachability, which requires the publisher role.
+type ProvidersParams struct {
+	Limit int  `form:"limit"`
+	Probe bool `form:"probe"`
+}
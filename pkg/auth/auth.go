@@ -0,0 +1,313 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+
+	"github.com/masa-finance/masa-oracle/pkg/signer"
+)
+
+// Headers of signed requests. The signature is an Ethereum personal_sign signature, hex encoded, over the text
+// returned by SigningText.
+const (
+	APIKeyHeader    = "X-API-Key"
+	AddressHeader   = "X-Masa-Address"
+	TimestampHeader = "X-Masa-Timestamp"
+	SignatureHeader = "X-Masa-Signature"
+)
+
+// MaxClockSkew is how far the timestamp of a signed request may be from the local clock.
+const MaxClockSkew = 5 * time.Minute
+
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Role grants access to the routes requiring it or a lower role.
+type Role string
+
+const (
+	RoleNone      Role = ""
+	RoleRead      Role = "read"
+	RolePublisher Role = "publisher"
+	RoleAdmin     Role = "admin"
+)
+
+var roleLevels = map[Role]int{RoleNone: 0, RoleRead: 1, RolePublisher: 2, RoleAdmin: 3}
+
+// Allows reports whether the role grants access to routes requiring required.
+func (r Role) Allows(required Role) bool {
+	return roleLevels[r] >= roleLevels[required]
+}
+
+func (r Role) valid() bool {
+	_, ok := roleLevels[r]
+	return ok
+}
+
+// Principal is the caller of a request.
+type Principal struct {
+	ID   string `json:"id"`
+	Role Role   `json:"role"`
+}
+
+// APIKey grants its role to requests sending the key in the X-API-Key header or as a bearer token. The file holds
+// either the key or its hex encoded SHA-256 hash.
+type APIKey struct {
+	ID        string `json:"id"`
+	Key       string `json:"key,omitempty"`
+	KeySHA256 string `json:"keySha256,omitempty"`
+	Role      Role   `json:"role"`
+
+	hash []byte
+}
+
+// AddressRole grants its role to requests signed by the Ethereum address.
+type AddressRole struct {
+	Address string `json:"address"`
+	Role    Role   `json:"role"`
+}
+
+// Config is the access configuration of the API.
+type Config struct {
+	Keys      []APIKey      `json:"keys,omitempty"`
+	Addresses []AddressRole `json:"addresses,omitempty"`
+	// Anonymous is the role of requests without credentials
+	Anonymous Role `json:"anonymous,omitempty"`
+	// Loopback is the role of requests without credentials from the local host
+	Loopback Role `json:"loopback,omitempty"`
+	// Policy maps routes, as "METHOD /path" with the path as registered, to the role they require. It overrides the
+	// default policy. Scopes of a route, the parts of it requiring their own role, are named "METHOD /path#scope".
+	Policy map[string]Role `json:"policy,omitempty"`
+}
+
+// DefaultConfig is the configuration without an access file: anyone, the local host included, may use the read
+// routes and nobody may change anything. Granting admin to the local host is an explicit choice of the access file,
+// behind a local reverse proxy it grants admin to every caller.
+func DefaultConfig() Config {
+	return Config{Anonymous: RoleRead, Loopback: RoleRead}
+}
+
+// LoadConfig reads the configuration from a JSON file.
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	info, err := os.Stat(path)
+	if err != nil {
+		return config, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		logrus.Warnf("API access file %s is readable by other users, restrict it with chmod 600", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("invalid API access file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Authenticator identifies the callers of the API.
+type Authenticator struct {
+	config    Config
+	keys      []APIKey
+	addresses map[common.Address]Role
+	policy    map[string]Role
+	mutex     sync.Mutex
+	// seen holds the signatures used within the clock skew window, so signed requests can not be replayed
+	seen map[string]time.Time
+}
+
+func NewAuthenticator(config Config, defaultPolicy map[string]Role) (*Authenticator, error) {
+	a := &Authenticator{
+		config:    config,
+		addresses: make(map[common.Address]Role),
+		policy:    make(map[string]Role),
+		seen:      make(map[string]time.Time),
+	}
+	if !config.Anonymous.valid() {
+		return nil, fmt.Errorf("unknown anonymous role %q", config.Anonymous)
+	}
+	if !config.Loopback.valid() {
+		return nil, fmt.Errorf("unknown loopback role %q", config.Loopback)
+	}
+	ids := make(map[string]bool)
+	for _, key := range config.Keys {
+		if key.ID == "" || ids[key.ID] {
+			return nil, fmt.Errorf("API key with missing or duplicate id %q", key.ID)
+		}
+		ids[key.ID] = true
+		if !key.Role.valid() || key.Role == RoleNone {
+			return nil, fmt.Errorf("API key %s has unknown role %q", key.ID, key.Role)
+		}
+		switch {
+		case key.KeySHA256 != "":
+			hash, err := hex.DecodeString(key.KeySHA256)
+			if err != nil || len(hash) != sha256.Size {
+				return nil, fmt.Errorf("API key %s has an invalid keySha256", key.ID)
+			}
+			key.hash = hash
+		case key.Key != "":
+			hash := sha256.Sum256([]byte(key.Key))
+			key.hash = hash[:]
+		default:
+			return nil, fmt.Errorf("API key %s has no key", key.ID)
+		}
+		a.keys = append(a.keys, key)
+	}
+	for _, entry := range config.Addresses {
+		if !common.IsHexAddress(entry.Address) {
+			return nil, fmt.Errorf("invalid address %q", entry.Address)
+		}
+		if !entry.Role.valid() || entry.Role == RoleNone {
+			return nil, fmt.Errorf("address %s has unknown role %q", entry.Address, entry.Role)
+		}
+		a.addresses[common.HexToAddress(entry.Address)] = entry.Role
+	}
+	for route, role := range defaultPolicy {
+		a.policy[route] = role
+	}
+	for route, role := range config.Policy {
+		if !role.valid() {
+			return nil, fmt.Errorf("route %s requires unknown role %q", route, role)
+		}
+		a.policy[route] = role
+	}
+	return a, nil
+}
+
+// Required returns the role a route, or a scope of a route named with ScopePath, requires. Routes without a policy
+// require RoleRead when they do not change anything and RoleAdmin otherwise, scopes without a policy RoleAdmin.
+func (a *Authenticator) Required(method, path string) Role {
+	if role, ok := a.policy[method+" "+path]; ok {
+		return role
+	}
+	if strings.Contains(path, "#") {
+		return RoleAdmin
+	}
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+		return RoleRead
+	}
+	return RoleAdmin
+}
+
+// ScopePath returns the path naming a scope of the route at path in the policy.
+func ScopePath(path, scope string) string {
+	return path + "#" + scope
+}
+
+// Authenticate returns the caller of the request. Requests with invalid credentials fail, requests without any get
+// the anonymous or loopback role.
+func (a *Authenticator) Authenticate(r *http.Request, body []byte) (Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return a.authenticateKey(key)
+	}
+	if key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return a.authenticateKey(key)
+	}
+	if r.Header.Get(SignatureHeader) != "" {
+		return a.authenticateSignature(r, body)
+	}
+	return a.AuthenticateKey("", r.RemoteAddr)
+}
+
+// AuthenticateKey returns the caller sending key from remoteAddr, for transports without signed requests. Without a
+// key the caller gets the anonymous or loopback role.
+func (a *Authenticator) AuthenticateKey(key, remoteAddr string) (Principal, error) {
+	if key != "" {
+		return a.authenticateKey(key)
+	}
+	if isLoopback(remoteAddr) {
+		return Principal{ID: "loopback", Role: a.config.Loopback}, nil
+	}
+	return Principal{ID: "anonymous", Role: a.config.Anonymous}, nil
+}
+
+func (a *Authenticator) authenticateKey(key string) (Principal, error) {
+	hash := sha256.Sum256([]byte(key))
+	var match *APIKey
+	// compare against every key so the time taken does not tell which key almost matched
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare(a.keys[i].hash, hash[:]) == 1 {
+			match = &a.keys[i]
+		}
+	}
+	if match == nil {
+		return Principal{}, fmt.Errorf("%w: invalid API key", ErrUnauthorized)
+	}
+	return Principal{ID: "key:" + match.ID, Role: match.Role}, nil
+}
+
+func (a *Authenticator) authenticateSignature(r *http.Request, body []byte) (Principal, error) {
+	if !common.IsHexAddress(r.Header.Get(AddressHeader)) {
+		return Principal{}, fmt.Errorf("%w: invalid address", ErrUnauthorized)
+	}
+	address := common.HexToAddress(r.Header.Get(AddressHeader))
+	role, ok := a.addresses[address]
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: address %s is not allowed", ErrUnauthorized, address.Hex())
+	}
+	timestamp := r.Header.Get(TimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: invalid timestamp", ErrUnauthorized)
+	}
+	now := time.Now()
+	if skew := now.Sub(time.Unix(unix, 0)); skew > MaxClockSkew || skew < -MaxClockSkew {
+		return Principal{}, fmt.Errorf("%w: timestamp outside of the allowed clock skew", ErrUnauthorized)
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(r.Header.Get(SignatureHeader), "0x"))
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: invalid signature", ErrUnauthorized)
+	}
+	text := SigningText(r.Method, r.URL.RequestURI(), timestamp, body)
+	if !signer.VerifyText(address, text, signature) {
+		return Principal{}, fmt.Errorf("%w: invalid signature", ErrUnauthorized)
+	}
+
+	// the signed request is seen once, whatever encoding of the signature it comes with: a signature verifies with
+	// V as 0/1 or 27/28 and with either S
+	hash := sha256.Sum256(text)
+	key := address.Hex() + ":" + hex.EncodeToString(hash[:])
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for k, seen := range a.seen {
+		if now.Sub(seen) > 2*MaxClockSkew {
+			delete(a.seen, k)
+		}
+	}
+	if _, replayed := a.seen[key]; replayed {
+		return Principal{}, fmt.Errorf("%w: replayed request", ErrUnauthorized)
+	}
+	a.seen[key] = now
+	return Principal{ID: "address:" + address.Hex(), Role: role}, nil
+}
+
+// SigningText returns the text a client signs with personal_sign: the method, the path with the query, the unix
+// timestamp and the hex encoded SHA-256 hash of the body, one per line.
+func SigningText(method, requestURI, timestamp string, body []byte) []byte {
+	hash := sha256.Sum256(body)
+	return []byte(strings.Join([]string{method, requestURI, timestamp, hex.EncodeToString(hash[:])}, "\n"))
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
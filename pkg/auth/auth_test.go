@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+
+	"github.com/masa-finance/masa-oracle/pkg/signer"
+)
+
+func TestMiddleware(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := signer.NewPrivateKeySigner(key)
+	config := Config{
+		Keys:      []APIKey{{ID: "ops", Key: "admin-key", Role: RoleAdmin}, {ID: "dash", Key: "read-key", Role: RoleRead}},
+		Addresses: []AddressRole{{Address: publisher.Address().Hex(), Role: RolePublisher}},
+		Loopback:  RoleAdmin,
+	}
+	a, err := NewAuthenticator(config, map[string]Role{"POST /ads": RolePublisher})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(a.Middleware(NewAuditLog(auditPath)))
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"success": true}) }
+	router.GET("/peers", ok)
+	router.POST("/ads", ok)
+	router.POST("/topics/join", ok)
+
+	sign := func(req *http.Request, body string) {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		sig, err := publisher.SignText(SigningText(req.Method, req.URL.RequestURI(), timestamp, []byte(body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(AddressHeader, publisher.Address().Hex())
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, hex.EncodeToString(sig))
+	}
+
+	for _, test := range []struct {
+		name    string
+		method  string
+		path    string
+		prepare func(req *http.Request)
+		status  int
+	}{
+		{"anonymous read", "GET", "/peers", nil, http.StatusUnauthorized},
+		{"loopback", "POST", "/topics/join", func(req *http.Request) { req.RemoteAddr = "127.0.0.1:5000" }, http.StatusOK},
+		{"read key", "GET", "/peers", func(req *http.Request) { req.Header.Set(APIKeyHeader, "read-key") }, http.StatusOK},
+		{"read key publishing", "POST", "/ads", func(req *http.Request) { req.Header.Set(APIKeyHeader, "read-key") }, http.StatusForbidden},
+		{"invalid key", "GET", "/peers", func(req *http.Request) { req.Header.Set("Authorization", "Bearer wrong") }, http.StatusUnauthorized},
+		{"admin bearer", "POST", "/topics/join", func(req *http.Request) { req.Header.Set("Authorization", "Bearer admin-key") }, http.StatusOK},
+		{"signed publisher", "POST", "/ads", func(req *http.Request) { sign(req, "{}") }, http.StatusOK},
+		{"signed publisher joining", "POST", "/topics/join", func(req *http.Request) { sign(req, "{}") }, http.StatusForbidden},
+		{"tampered body", "POST", "/ads", func(req *http.Request) { sign(req, `{"other":1}`) }, http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader("{}"))
+		if test.prepare != nil {
+			test.prepare(req)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("%s: status %d, want %d", test.name, w.Code, test.status)
+		}
+	}
+
+	file, err := os.Open(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []AuditRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	// the signed publisher is audited with its address, the rejected reads are not audited
+	if len(records) != 6 || records[0].Principal != "loopback" || records[3].Principal != "address:"+publisher.Address().Hex() ||
+		records[5].Principal != "unauthenticated" || records[5].Status != http.StatusUnauthorized {
+		t.Errorf("unexpected audit log %+v", records)
+	}
+}
+
+func TestSignedRequestReplay(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	s := signer.NewPrivateKeySigner(key)
+	a, err := NewAuthenticator(Config{Addresses: []AddressRole{{Address: s.Address().Hex(), Role: RoleAdmin}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	sig, _ := s.SignText(SigningText("DELETE", "/x?y=1", timestamp, nil))
+	req := httptest.NewRequest("DELETE", "/x?y=1", nil)
+	req.Header.Set(AddressHeader, s.Address().Hex())
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "0x"+hex.EncodeToString(sig))
+	if principal, err := a.Authenticate(req, nil); err != nil || principal.Role != RoleAdmin {
+		t.Fatalf("got %+v, %v", principal, err)
+	}
+	if _, err := a.Authenticate(req, nil); err == nil {
+		t.Error("replayed request accepted")
+	}
+
+	// the same signature with V in the other form, and with the malleated S
+	flipped := append([]byte(nil), sig...)
+	flipped[64] += 27
+	malleated := append([]byte(nil), sig...)
+	s2 := new(big.Int).Sub(crypto.S256().Params().N, new(big.Int).SetBytes(sig[32:64]))
+	s2.FillBytes(malleated[32:64])
+	malleated[64] ^= 1
+	for name, variant := range map[string][]byte{"v+27": flipped, "high s": malleated} {
+		req.Header.Set(SignatureHeader, "0x"+hex.EncodeToString(variant))
+		if _, err := a.Authenticate(req, nil); err == nil {
+			t.Errorf("request replayed with the %s signature accepted", name)
+		}
+	}
+}
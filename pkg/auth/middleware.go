@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+)
+
+const (
+	// PrincipalKey is the gin context key of the caller's Principal
+	PrincipalKey = "principal"
+	// AuthenticatorKey is the gin context key of the Authenticator, handlers ask it the roles of the scopes of their
+	// route
+	AuthenticatorKey = "authenticator"
+	// MaxBodySize is the largest request body read to check signatures
+	MaxBodySize = 10 << 20
+)
+
+// AuditRecord is an entry of the audit log.
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	Principal string    `json:"principal"`
+	Role      Role      `json:"role"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Remote    string    `json:"remote"`
+	Status    int       `json:"status"`
+}
+
+// AuditLog appends the mutating calls to a JSON lines file. It is safe for concurrent use.
+type AuditLog struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewAuditLog writes to path, only to the node log when path is empty.
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+func (l *AuditLog) Record(record AuditRecord) {
+	logrus.WithFields(logrus.Fields{
+		"principal": record.Principal,
+		"method":    record.Method,
+		"path":      record.Path,
+		"status":    record.Status,
+	}).Info("API audit")
+	if l == nil || l.path == "" {
+		return
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		logrus.Errorf("Error opening audit log: %v", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		logrus.Errorf("Error writing audit log: %v", err)
+	}
+}
+
+// Middleware authenticates the requests, rejects those whose caller lacks the role their route requires and
+// records the mutating calls, rejected or not, in the audit log. The caller is stored in the context under PrincipalKey
+// and the authenticator under AuthenticatorKey.
+func (a *Authenticator) Middleware(audit *AuditLog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body []byte
+		if c.Request.Body != nil && c.Request.Header.Get(SignatureHeader) != "" {
+			var err error
+			body, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxBodySize))
+			if err != nil {
+				abort(c, http.StatusRequestEntityTooLarge, v1.CodeInvalidRequest, "Request body too large")
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		mutating := c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead && c.Request.Method != http.MethodOptions
+		principal, err := a.Authenticate(c.Request, body)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"remote": c.ClientIP(), "path": c.Request.URL.Path}).Warnf("Rejected API call: %v", err)
+			abort(c, http.StatusUnauthorized, v1.CodeUnauthorized, "Unauthorized")
+			principal = Principal{ID: "unauthenticated"}
+		} else if required := a.Required(c.Request.Method, c.FullPath()); !principal.Role.Allows(required) {
+			status, code := http.StatusForbidden, v1.CodeForbidden
+			if principal.Role == RoleNone {
+				status, code = http.StatusUnauthorized, v1.CodeUnauthorized
+			}
+			abort(c, status, code, "Requires the "+string(required)+" role")
+		} else {
+			c.Set(PrincipalKey, principal)
+			c.Set(AuthenticatorKey, a)
+			c.Next()
+		}
+		if mutating {
+			audit.Record(AuditRecord{
+				Time:      time.Now().UTC(),
+				Principal: principal.ID,
+				Role:      principal.Role,
+				Method:    c.Request.Method,
+				Path:      c.Request.URL.Path,
+				Remote:    c.ClientIP(),
+				Status:    c.Writer.Status(),
+			})
+		}
+	}
+}
+
+// abort rejects a request with a body that is both a v1 error envelope and the message of the unversioned routes.
+func abort(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"success": false, "message": message, "error": v1.Error{Code: code, Message: message}})
+}
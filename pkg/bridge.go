@@ -1,49 +1,170 @@
 package masa
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+
+	"github.com/masa-finance/masa-oracle/pkg/bridge"
+	"github.com/masa-finance/masa-oracle/pkg/crypto"
 )
 
-func NewBridge() error {
+// NewBridge serves the webhook bridge until the node's context is done. Authenticated webhooks are forwarded
+// through the node to the target of the first route they match.
+func NewBridge(node *OracleNode) error {
 
 	logrus.Info("starting server")
 	router := gin.Default()
 	//router.Use(cors.Default())
 	// router.SetTrustedProxies([]string{add values here})
 
-	// Use the auth middleware for the /webhook route
-	router.POST("/webhook", authMiddleware(), webhookHandler)
+	clientsFile := os.Getenv(BridgeClientsFile)
+	if clientsFile == "" {
+		return errors.New("no bridge clients configured, set " + BridgeClientsFile)
+	}
+	clients, err := bridge.LoadClients(clientsFile)
+	if err != nil {
+		return err
+	}
+	auth, err := bridge.NewAuthenticator(clients)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Loaded %d bridge clients", len(clients))
+
+	routes, err := bridge.LoadRoutes(os.Getenv(BridgeRoutesFile))
+	if err != nil {
+		return err
+	}
+	for _, topic := range routes.Topics() {
+		if err := node.PubSubManager.JoinTopic(topic); err != nil {
+			return err
+		}
+	}
+	logrus.Infof("Loaded %d bridge routes", len(routes))
+
+	forwarder := bridge.NewForwarder(newForwarderConfig(), node.PubSubManager.Publish, bridge.StreamSender(node.Host))
+	defer forwarder.Close()
+
+	// Use the auth middleware for the /webhook routes
+	router.POST("/webhook", auth.Middleware(), webhookHandler(routes, forwarder))
+	router.POST("/webhook/*path", auth.Middleware(), webhookHandler(routes, forwarder))
+	router.GET("/deliveries/:id", auth.Middleware(), deliveryHandler(forwarder))
 
-	// Paths to the certificate and key files
-	certFile := os.Getenv(Cert)
-	keyFile := os.Getenv(CertPem)
+	// The certificate is reloaded when it is renewed or replaced
+	certs, err := NewServerCertManager(os.Getenv(Cert), os.Getenv(CertPem), "masa-bridge")
+	if err != nil {
+		return err
+	}
+	go certs.Run(node.Context, certCheckInterval)
 
-	if err := router.RunTLS(":8080", certFile, keyFile); err != nil {
+	server := &http.Server{Addr: ":8080", Handler: router, TLSConfig: certs.TLSConfig()}
+	caFile := os.Getenv(BridgeClientCA)
+	if caFile == "" && os.Getenv(CADir) != "" {
+		caFile = filepath.Join(os.Getenv(CADir), crypto.CACertFileName)
+	}
+	if caFile != "" {
+		if err := requestClientCerts(server.TLSConfig, caFile); err != nil {
+			return err
+		}
+	}
+	go func() {
+		<-node.Context.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logrus.Errorf("Error shutting down the bridge: %v", err)
+		}
+	}()
+	if err := server.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 	return nil
 }
 
-func authMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Get the token from the Authorization header
-		token := c.GetHeader("Authorization")
+// newForwarderConfig reads the retries from the bridgeMaxAttempts, bridgeRetryBackoff and bridgeDeadLetterPath
+// environment variables.
+func newForwarderConfig() bridge.ForwarderConfig {
+	config := bridge.ForwarderConfig{DeadLetterPath: os.Getenv(BridgeDeadLetterPath)}
+	config.MaxAttempts, _ = strconv.Atoi(os.Getenv(BridgeMaxAttempts))
+	config.RetryBackoff, _ = time.ParseDuration(os.Getenv(BridgeRetryBackoff))
+	return config
+}
+
+// requestClientCerts makes config request TLS client certificates and verify the ones given against the CA
+// certificates in caFile. Clients without a certificate can still authenticate with a token or signature.
+func requestClientCerts(config *tls.Config, caFile string) error {
+	caCerts, err := os.ReadFile(caFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCerts) {
+		return fmt.Errorf("no certificates found in %s", caFile)
+	}
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	config.ClientCAs = pool
+	return nil
+}
 
-		// Check the token
-		if token != "your_expected_token" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+// webhookHandler queues a JSON webhook for forwarding along the first matching route. It answers 202 right away,
+// with the delivery id to follow it on /deliveries/:id.
+func webhookHandler(routes bridge.Routes, forwarder *bridge.Forwarder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := c.MustGet(bridge.ClientKey).(*bridge.Client)
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Could not read request body"})
+			return
+		}
+		var document interface{}
+		if err := json.Unmarshal(body, &document); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Request body is not valid JSON"})
 			return
 		}
-		c.Next()
+		route := routes.Match(c.Request, document)
+		if route == nil {
+			logrus.WithFields(logrus.Fields{"client": client.ID, "path": c.Request.URL.Path}).Warn("No route for webhook")
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "No route for webhook"})
+			return
+		}
+
+		envelope := bridge.Envelope{
+			ID:         uuid.New().String(),
+			Route:      route.Name,
+			Client:     client.ID,
+			Path:       c.Request.URL.Path,
+			ReceivedAt: time.Now().UTC(),
+			Payload:    body,
+		}
+		logrus.WithFields(logrus.Fields{"client": client.ID, "route": route.Name, "id": envelope.ID}).Info("Webhook called")
+		delivery := forwarder.Forward(envelope, route)
+		c.JSON(http.StatusAccepted, gin.H{"success": true, "data": delivery})
 	}
 }
 
-func webhookHandler(c *gin.Context) {
-	// Handle the webhook request here
-
-	c.JSON(http.StatusOK, gin.H{"message": "Webhook called"})
+// deliveryHandler reports the state of a delivery to the client that sent the webhook.
+func deliveryHandler(forwarder *bridge.Forwarder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := c.MustGet(bridge.ClientKey).(*bridge.Client)
+		delivery, ok := forwarder.Status(c.Param("id"))
+		if !ok || delivery.Envelope.Client != client.ID {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Delivery not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": delivery})
+	}
 }
@@ -0,0 +1,202 @@
+package bridge
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Headers of HMAC signed requests. The signature is the hex encoded HMAC-SHA256 of the timestamp, a dot and the
+// request body, keyed with the client's secret.
+const (
+	ClientHeader    = "X-Masa-Client"
+	TimestampHeader = "X-Masa-Timestamp"
+	SignatureHeader = "X-Masa-Signature"
+	signaturePrefix = "sha256="
+)
+
+const (
+	// MaxClockSkew is how far the timestamp of a signed request may be from the local clock
+	MaxClockSkew = 5 * time.Minute
+	// MaxBodySize is the largest webhook body accepted
+	MaxBodySize = 1 << 20
+	// ClientKey is the gin context key of the authenticated *Client
+	ClientKey = "bridgeClient"
+)
+
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Client is a partner system allowed to call the bridge. It authenticates with any of the credentials it has: a
+// bearer token, HMAC signatures or a TLS client certificate with the given common name.
+type Client struct {
+	ID             string `json:"id"`
+	Token          string `json:"token,omitempty"`
+	HMACSecret     string `json:"hmacSecret,omitempty"`
+	CertCommonName string `json:"certCommonName,omitempty"`
+}
+
+// LoadClients reads the clients from a JSON secrets file holding an array of clients.
+func LoadClients(path string) ([]Client, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		logrus.Warnf("Bridge secrets file %s is readable by other users, restrict it with chmod 600", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var clients []Client
+	if err := json.Unmarshal(data, &clients); err != nil {
+		return nil, fmt.Errorf("invalid bridge secrets file %s: %w", path, err)
+	}
+	return clients, nil
+}
+
+// Authenticator authenticates webhook requests against the configured clients.
+type Authenticator struct {
+	clients map[string]*Client
+	mutex   sync.Mutex
+	// seen holds the signatures used within the clock skew window, so signed requests can not be replayed
+	seen map[string]time.Time
+}
+
+func NewAuthenticator(clients []Client) (*Authenticator, error) {
+	auth := &Authenticator{
+		clients: make(map[string]*Client),
+		seen:    make(map[string]time.Time),
+	}
+	for i := range clients {
+		client := &clients[i]
+		if client.ID == "" {
+			return nil, errors.New("bridge client without id")
+		}
+		if client.Token == "" && client.HMACSecret == "" && client.CertCommonName == "" {
+			return nil, fmt.Errorf("bridge client %s has no credentials", client.ID)
+		}
+		if _, exists := auth.clients[client.ID]; exists {
+			return nil, fmt.Errorf("duplicate bridge client %s", client.ID)
+		}
+		auth.clients[client.ID] = client
+	}
+	return auth, nil
+}
+
+// Authenticate returns the client that sent the request. The body is needed to check HMAC signatures.
+func (a *Authenticator) Authenticate(r *http.Request, body []byte) (*Client, error) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		commonName := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		for _, client := range a.clients {
+			if client.CertCommonName != "" && client.CertCommonName == commonName {
+				return client, nil
+			}
+		}
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return a.authenticateToken(token)
+	}
+	if r.Header.Get(SignatureHeader) != "" {
+		return a.authenticateSignature(r, body)
+	}
+	return nil, ErrUnauthorized
+}
+
+func (a *Authenticator) authenticateToken(token string) (*Client, error) {
+	var match *Client
+	// compare against every token so the time taken does not tell which client almost matched
+	for _, client := range a.clients {
+		if client.Token != "" && subtle.ConstantTimeCompare([]byte(client.Token), []byte(token)) == 1 {
+			match = client
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: invalid token", ErrUnauthorized)
+	}
+	return match, nil
+}
+
+func (a *Authenticator) authenticateSignature(r *http.Request, body []byte) (*Client, error) {
+	client, ok := a.clients[r.Header.Get(ClientHeader)]
+	if !ok || client.HMACSecret == "" {
+		return nil, fmt.Errorf("%w: unknown client %q", ErrUnauthorized, r.Header.Get(ClientHeader))
+	}
+	timestamp := r.Header.Get(TimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid timestamp", ErrUnauthorized)
+	}
+	now := time.Now()
+	if skew := now.Sub(time.Unix(unix, 0)); skew > MaxClockSkew || skew < -MaxClockSkew {
+		return nil, fmt.Errorf("%w: timestamp outside of the allowed clock skew", ErrUnauthorized)
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(r.Header.Get(SignatureHeader), signaturePrefix))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature", ErrUnauthorized)
+	}
+	if !hmac.Equal(signature, Sign(client.HMACSecret, timestamp, body)) {
+		return nil, fmt.Errorf("%w: invalid signature", ErrUnauthorized)
+	}
+
+	key := client.ID + "/" + hex.EncodeToString(signature)
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for k, seen := range a.seen {
+		if now.Sub(seen) > 2*MaxClockSkew {
+			delete(a.seen, k)
+		}
+	}
+	if _, replayed := a.seen[key]; replayed {
+		return nil, fmt.Errorf("%w: replayed request", ErrUnauthorized)
+	}
+	a.seen[key] = now
+	return client, nil
+}
+
+// Sign returns the HMAC-SHA256 signature of a request body sent at timestamp, in unix seconds.
+func Sign(secret, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Middleware authenticates the requests and stores the client in the context under ClientKey. The body stays
+// readable by the handlers.
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxBodySize))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		client, err := a.Authenticate(c.Request, body)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"remote": c.ClientIP(), "path": c.FullPath()}).Warnf("Rejected webhook call: %v", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		logrus.WithFields(logrus.Fields{"client": client.ID, "remote": c.ClientIP()}).Debug("Authenticated webhook call")
+		c.Set(ClientKey, client)
+		c.Next()
+	}
+}
@@ -0,0 +1,82 @@
+package bridge
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthenticate(t *testing.T) {
+	auth, err := NewAuthenticator([]Client{
+		{ID: "tokens", Token: "secret-token"},
+		{ID: "signer", HMACSecret: "hmac-secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`{"hello":"world"}`)
+
+	sign := func(client, secret string, at time.Time) error {
+		r := httptest.NewRequest("POST", "/webhook", strings.NewReader(string(body)))
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		r.Header.Set(ClientHeader, client)
+		r.Header.Set(TimestampHeader, timestamp)
+		r.Header.Set(SignatureHeader, signaturePrefix+hex.EncodeToString(Sign(secret, timestamp, body)))
+		_, err := auth.Authenticate(r, body)
+		return err
+	}
+
+	t.Run("token", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/webhook", nil)
+		r.Header.Set("Authorization", "Bearer secret-token")
+		client, err := auth.Authenticate(r, nil)
+		if err != nil || client.ID != "tokens" {
+			t.Fatalf("got %v, %v", client, err)
+		}
+		r.Header.Set("Authorization", "Bearer wrong")
+		if _, err := auth.Authenticate(r, nil); !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("wrong token accepted: %v", err)
+		}
+	})
+
+	t.Run("missing credentials", func(t *testing.T) {
+		if _, err := auth.Authenticate(httptest.NewRequest("POST", "/webhook", nil), nil); !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("request without credentials accepted: %v", err)
+		}
+	})
+
+	t.Run("signature", func(t *testing.T) {
+		now := time.Now()
+		if err := sign("signer", "hmac-secret", now); err != nil {
+			t.Fatal(err)
+		}
+		if err := sign("signer", "hmac-secret", now); err == nil {
+			t.Fatal("replayed request accepted")
+		}
+		if err := sign("signer", "other-secret", now.Add(time.Second)); err == nil {
+			t.Fatal("wrong secret accepted")
+		}
+		if err := sign("signer", "hmac-secret", now.Add(-2*MaxClockSkew)); err == nil {
+			t.Fatal("stale timestamp accepted")
+		}
+		if err := sign("tokens", "secret-token", now.Add(2*time.Second)); err == nil {
+			t.Fatal("client without an HMAC secret accepted")
+		}
+	})
+}
+
+func TestNewAuthenticatorRejectsInvalidClients(t *testing.T) {
+	for _, clients := range [][]Client{
+		{{Token: "token"}},
+		{{ID: "a"}},
+		{{ID: "a", Token: "1"}, {ID: "a", Token: "2"}},
+	} {
+		if _, err := NewAuthenticator(clients); err == nil {
+			t.Errorf("clients %+v accepted", clients)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultMaxAttempts  = 5
+	DefaultRetryBackoff = 2 * time.Second
+	// AckTimeout is how long a peer has to acknowledge a forwarded webhook
+	AckTimeout = 10 * time.Second
+	// maxDeliveries is how many deliveries are kept for status queries
+	maxDeliveries = 4096
+)
+
+// Delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Envelope is the message forwarded into the network for a webhook.
+type Envelope struct {
+	ID         string          `json:"id"`
+	Route      string          `json:"route"`
+	Client     string          `json:"client"`
+	Path       string          `json:"path"`
+	ReceivedAt time.Time       `json:"receivedAt"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Ack is the answer of a peer to a forwarded envelope, Error is set when the peer did not accept it.
+type Ack struct {
+	ID    string `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+// Delivery is the forwarding state of an envelope.
+type Delivery struct {
+	Envelope  Envelope  `json:"envelope"`
+	Status    string    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	route *Route
+	timer *time.Timer
+}
+
+// ForwarderConfig configures the retries of a Forwarder. Zero values select the defaults, without a DeadLetterPath
+// failed deliveries are only logged.
+type ForwarderConfig struct {
+	MaxAttempts    int
+	RetryBackoff   time.Duration
+	DeadLetterPath string
+}
+
+// PublishFunc publishes data on a pubsub topic.
+type PublishFunc func(topic string, data []byte) error
+
+// SendFunc sends data to a node protocol of a peer and returns once the peer acknowledged it.
+type SendFunc func(ctx context.Context, peerId peer.ID, protocolId protocol.ID, data []byte) error
+
+// Forwarder delivers envelopes to the targets of their routes. Failed deliveries are retried with exponential
+// backoff and written to the dead-letter file once all attempts failed. It is safe for concurrent use.
+type Forwarder struct {
+	config     ForwarderConfig
+	publish    PublishFunc
+	send       SendFunc
+	ctx        context.Context
+	cancel     context.CancelFunc
+	mutex      sync.Mutex
+	deliveries map[string]*Delivery
+	order      []string
+	closed     bool
+	deadLetter sync.Mutex
+}
+
+func NewForwarder(config ForwarderConfig, publish PublishFunc, send SendFunc) *Forwarder {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = DefaultRetryBackoff
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Forwarder{
+		config:     config,
+		publish:    publish,
+		send:       send,
+		ctx:        ctx,
+		cancel:     cancel,
+		deliveries: make(map[string]*Delivery),
+	}
+}
+
+// Forward queues the envelope for delivery and returns its pending state right away. The attempts are made in the
+// background, Status reports their outcome.
+func (f *Forwarder) Forward(envelope Envelope, route *Route) Delivery {
+	d := &Delivery{Envelope: envelope, Status: DeliveryPending, UpdatedAt: time.Now(), route: route}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.deliveries[envelope.ID] = d
+	f.order = append(f.order, envelope.ID)
+	if len(f.order) > maxDeliveries {
+		delete(f.deliveries, f.order[0])
+		f.order = f.order[1:]
+	}
+	d.timer = time.AfterFunc(0, func() { f.attempt(d) })
+	return *d
+}
+
+// Status returns the state of a delivery.
+func (f *Forwarder) Status(id string) (Delivery, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	d, ok := f.deliveries[id]
+	if !ok {
+		return Delivery{}, false
+	}
+	return *d, true
+}
+
+// Close stops the retries and writes the pending deliveries to the dead-letter file.
+func (f *Forwarder) Close() {
+	f.mutex.Lock()
+	f.closed = true
+	var pending []*Delivery
+	for _, d := range f.deliveries {
+		// deliveries with an attempt in flight are dead-lettered when the attempt fails
+		if d.Status == DeliveryPending && d.timer != nil && d.timer.Stop() {
+			d.Status, d.LastError, d.UpdatedAt = DeliveryFailed, "bridge stopped", time.Now()
+			pending = append(pending, d)
+		}
+	}
+	f.mutex.Unlock()
+	f.cancel()
+	for _, d := range pending {
+		f.writeDeadLetter(*d)
+	}
+}
+
+func (f *Forwarder) attempt(d *Delivery) Delivery {
+	err := f.deliver(d)
+
+	f.mutex.Lock()
+	d.Attempts++
+	d.UpdatedAt = time.Now()
+	switch {
+	case err == nil:
+		d.Status, d.LastError = DeliveryDelivered, ""
+	case d.Attempts >= f.config.MaxAttempts || f.closed:
+		d.Status, d.LastError = DeliveryFailed, err.Error()
+	default:
+		d.LastError = err.Error()
+		backoff := f.config.RetryBackoff << (d.Attempts - 1)
+		d.timer = time.AfterFunc(backoff, func() { f.attempt(d) })
+	}
+	state := *d
+	f.mutex.Unlock()
+
+	log := logrus.WithFields(logrus.Fields{"id": state.Envelope.ID, "route": state.Envelope.Route, "client": state.Envelope.Client})
+	switch state.Status {
+	case DeliveryDelivered:
+		log.Debugf("Forwarded webhook after %d attempts", state.Attempts)
+	case DeliveryFailed:
+		log.Errorf("Giving up forwarding webhook after %d attempts: %v", state.Attempts, err)
+		f.writeDeadLetter(state)
+	default:
+		log.Warnf("Forwarding webhook failed, retrying: %v", err)
+	}
+	return state
+}
+
+func (f *Forwarder) deliver(d *Delivery) error {
+	data, err := json.Marshal(d.Envelope)
+	if err != nil {
+		return err
+	}
+	if d.route.Topic != "" {
+		return f.publish(d.route.Topic, data)
+	}
+	ctx, cancel := context.WithTimeout(f.ctx, AckTimeout)
+	defer cancel()
+	return f.send(ctx, d.route.PeerID(), d.route.ProtocolID(), data)
+}
+
+// writeDeadLetter appends a failed delivery to the dead-letter file as a JSON line.
+func (f *Forwarder) writeDeadLetter(d Delivery) {
+	if f.config.DeadLetterPath == "" {
+		return
+	}
+	line, err := json.Marshal(d)
+	if err != nil {
+		logrus.Errorf("Error marshaling dead letter: %v", err)
+		return
+	}
+	f.deadLetter.Lock()
+	defer f.deadLetter.Unlock()
+	file, err := os.OpenFile(f.config.DeadLetterPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		logrus.Errorf("Error opening dead-letter file: %v", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		logrus.Errorf("Error writing dead letter: %v", err)
+	}
+}
+
+// StreamSender returns a SendFunc opening a stream to the peer from h, writing the data and waiting for the Ack.
+func StreamSender(h host.Host) SendFunc {
+	return func(ctx context.Context, peerId peer.ID, protocolId protocol.ID, data []byte) error {
+		stream, err := h.NewStream(ctx, peerId, protocolId)
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = stream.SetDeadline(deadline)
+		}
+		if _, err := stream.Write(data); err != nil {
+			_ = stream.Reset()
+			return err
+		}
+		if err := stream.CloseWrite(); err != nil {
+			_ = stream.Reset()
+			return err
+		}
+		var ack Ack
+		if err := json.NewDecoder(io.LimitReader(stream, MaxBodySize)).Decode(&ack); err != nil {
+			return fmt.Errorf("no acknowledgement from %s: %w", peerId, err)
+		}
+		if ack.Error != "" {
+			return fmt.Errorf("rejected by %s: %s", peerId, ack.Error)
+		}
+		return nil
+	}
+}
+
+// ReadEnvelope reads an envelope sent with StreamSender, the receiving node answers with WriteAck.
+func ReadEnvelope(stream network.Stream) (Envelope, error) {
+	var envelope Envelope
+	_ = stream.SetReadDeadline(time.Now().Add(AckTimeout))
+	data, err := io.ReadAll(io.LimitReader(stream, 2*MaxBodySize))
+	if err != nil {
+		return envelope, err
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return envelope, err
+	}
+	if envelope.ID == "" {
+		return envelope, errors.New("envelope without id")
+	}
+	return envelope, nil
+}
+
+// WriteAck acknowledges an envelope, or reports why it was not accepted when err is set.
+func WriteAck(stream network.Stream, id string, err error) error {
+	ack := Ack{ID: id}
+	if err != nil {
+		ack.Error = err.Error()
+	}
+	return json.NewEncoder(stream).Encode(ack)
+}
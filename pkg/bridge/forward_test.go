@@ -0,0 +1,124 @@
+package bridge
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func waitForStatus(t *testing.T, f *Forwarder, id, status string) Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if d, _ := f.Status(id); d.Status == status {
+			return d
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	d, _ := f.Status(id)
+	t.Fatalf("delivery %s is %s, want %s", id, d.Status, status)
+	return d
+}
+
+func TestForwarderRetries(t *testing.T) {
+	var calls atomic.Int32
+	publish := func(topic string, data []byte) error {
+		if calls.Add(1) < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}
+	f := NewForwarder(ForwarderConfig{MaxAttempts: 5, RetryBackoff: time.Millisecond}, publish, nil)
+	defer f.Close()
+
+	d := f.Forward(Envelope{ID: "1", Payload: json.RawMessage(`{}`)}, &Route{Name: "r", Topic: "t"})
+	if d.Status != DeliveryPending || d.Attempts != 0 {
+		t.Fatalf("queued delivery: %+v", d)
+	}
+	d = waitForStatus(t, f, "1", DeliveryDelivered)
+	if d.Attempts != 3 {
+		t.Errorf("delivered after %d attempts, want 3", d.Attempts)
+	}
+}
+
+func TestForwarderDeadLetter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	publish := func(topic string, data []byte) error { return errors.New("unreachable") }
+	f := NewForwarder(ForwarderConfig{MaxAttempts: 2, RetryBackoff: time.Millisecond, DeadLetterPath: path}, publish, nil)
+	defer f.Close()
+
+	f.Forward(Envelope{ID: "1", Payload: json.RawMessage(`{"a":1}`)}, &Route{Name: "r", Topic: "t"})
+	waitForStatus(t, f, "1", DeliveryFailed)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		t.Fatal("dead-letter file is empty")
+	}
+	var d Delivery
+	if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Envelope.ID != "1" || d.Attempts != 2 || d.LastError != "unreachable" || string(d.Envelope.Payload) != `{"a":1}` {
+		t.Errorf("dead letter %+v", d)
+	}
+}
+
+func TestStreamSender(t *testing.T) {
+	ctx := context.Background()
+	sender, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+	receiver, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer receiver.Close()
+	received := make(chan Envelope, 1)
+	receiver.SetStreamHandler(Protocol, func(stream network.Stream) {
+		defer stream.Close()
+		envelope, err := ReadEnvelope(stream)
+		if envelope.Route == "reject" {
+			err = errors.New("not wanted")
+		}
+		_ = WriteAck(stream, envelope.ID, err)
+		if err == nil {
+			received <- envelope
+		}
+	})
+	if err := sender.Connect(ctx, peer.AddrInfo{ID: receiver.ID(), Addrs: receiver.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewForwarder(ForwarderConfig{MaxAttempts: 1}, nil, StreamSender(sender))
+	defer f.Close()
+	route := &Route{Name: "peer", Peer: receiver.ID().String()}
+	if err := route.validate(); err != nil {
+		t.Fatal(err)
+	}
+	f.Forward(Envelope{ID: "1", Route: "peer", Payload: json.RawMessage(`{}`)}, route)
+	waitForStatus(t, f, "1", DeliveryDelivered)
+	if envelope := <-received; envelope.ID != "1" {
+		t.Errorf("received %+v", envelope)
+	}
+	f.Forward(Envelope{ID: "2", Route: "reject", Payload: json.RawMessage(`{}`)}, route)
+	if d := waitForStatus(t, f, "2", DeliveryFailed); d.LastError == "" {
+		t.Errorf("rejected delivery %+v", d)
+	}
+}
@@ -0,0 +1,178 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// Protocol is the node protocol receiving forwarded webhooks when a route names a peer without a protocol.
+const Protocol = "/masa/bridge/v.0.0.3-alpha"
+
+// Route forwards the webhooks it matches to a pubsub topic, or to a node protocol of a peer. A webhook matches when
+// every condition given matches:
+//   - Path is the request path, a trailing * matches any path with that prefix
+//   - Headers maps header names to their values, an empty value only requires the header to be present
+//   - Fields maps JSON fields of the body, nested fields separated by dots, to their values
+type Route struct {
+	Name     string            `json:"name"`
+	Path     string            `json:"path,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Topic    string            `json:"topic,omitempty"`
+	Peer     string            `json:"peer,omitempty"`
+	Protocol string            `json:"protocol,omitempty"`
+
+	peerId peer.ID
+}
+
+// PeerID returns the peer of a route forwarding to a node protocol.
+func (r *Route) PeerID() peer.ID {
+	return r.peerId
+}
+
+// ProtocolID returns the node protocol of a route forwarding to a peer.
+func (r *Route) ProtocolID() protocol.ID {
+	if r.Protocol == "" {
+		return Protocol
+	}
+	return protocol.ID(r.Protocol)
+}
+
+func (r *Route) validate() error {
+	if r.Name == "" {
+		return errors.New("bridge route without name")
+	}
+	if (r.Topic == "") == (r.Peer == "") {
+		return fmt.Errorf("bridge route %s needs either a topic or a peer", r.Name)
+	}
+	if r.Peer != "" {
+		id, err := peer.Decode(r.Peer)
+		if err != nil {
+			return fmt.Errorf("bridge route %s: invalid peer: %w", r.Name, err)
+		}
+		r.peerId = id
+	}
+	return nil
+}
+
+// Matches reports whether the request with the given body, decoded from JSON, matches the route.
+func (r *Route) Matches(req *http.Request, body interface{}) bool {
+	if r.Path != "" {
+		if prefix, ok := strings.CutSuffix(r.Path, "*"); ok {
+			if !strings.HasPrefix(req.URL.Path, prefix) {
+				return false
+			}
+		} else if req.URL.Path != r.Path {
+			return false
+		}
+	}
+	for name, value := range r.Headers {
+		values, ok := req.Header[http.CanonicalHeaderKey(name)]
+		if !ok || (value != "" && !contains(values, value)) {
+			return false
+		}
+	}
+	for field, value := range r.Fields {
+		actual, ok := lookupField(body, field)
+		if !ok || fmt.Sprint(actual) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupField returns the value of a dotted field path in a decoded JSON document.
+func lookupField(document interface{}, field string) (interface{}, bool) {
+	value := document
+	for _, name := range strings.Split(field, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// ParsePeers parses a comma separated list of the peer ids of the bridges a node accepts forwarded webhooks from.
+func ParsePeers(list string) (map[peer.ID]bool, error) {
+	peers := make(map[peer.ID]bool)
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		id, err := peer.Decode(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bridge peer %s: %w", value, err)
+		}
+		peers[id] = true
+	}
+	return peers, nil
+}
+
+// Routes are tried in order, the first matching route forwards the webhook.
+type Routes []*Route
+
+// LoadRoutes reads the routes from a JSON file holding an array of routes.
+func LoadRoutes(path string) (Routes, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var routes Routes
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return nil, fmt.Errorf("invalid bridge routes file %s: %w", path, err)
+	}
+	names := make(map[string]bool)
+	for _, route := range routes {
+		if err := route.validate(); err != nil {
+			return nil, err
+		}
+		if names[route.Name] {
+			return nil, fmt.Errorf("duplicate bridge route %s", route.Name)
+		}
+		names[route.Name] = true
+	}
+	return routes, nil
+}
+
+// Match returns the first route matching the request, nil if none does.
+func (routes Routes) Match(req *http.Request, body interface{}) *Route {
+	for _, route := range routes {
+		if route.Matches(req, body) {
+			return route
+		}
+	}
+	return nil
+}
+
+// Topics returns the pubsub topics the routes forward to.
+func (routes Routes) Topics() []string {
+	var topics []string
+	seen := make(map[string]bool)
+	for _, route := range routes {
+		if route.Topic != "" && !seen[route.Topic] {
+			seen[route.Topic] = true
+			topics = append(topics, route.Topic)
+		}
+	}
+	return topics
+}
@@ -0,0 +1,77 @@
+package bridge
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestRoutesMatch(t *testing.T) {
+	routes := Routes{
+		{Name: "orders", Path: "/webhook/orders/*", Fields: map[string]string{"order.status": "paid"}, Topic: "orders"},
+		{Name: "typed", Headers: map[string]string{"X-Event-Type": "signup"}, Topic: "signups"},
+		{Name: "traced", Headers: map[string]string{"X-Trace": ""}, Topic: "traces"},
+		{Name: "exact", Path: "/webhook", Topic: "default"},
+	}
+	for _, test := range []struct {
+		path    string
+		headers map[string]string
+		body    interface{}
+		route   string
+	}{
+		{"/webhook/orders/1", nil, map[string]interface{}{"order": map[string]interface{}{"status": "paid"}}, "orders"},
+		{"/webhook/orders/1", nil, map[string]interface{}{"order": map[string]interface{}{"status": "open"}}, ""},
+		{"/webhook/x", map[string]string{"x-event-type": "signup"}, nil, "typed"},
+		{"/webhook/x", map[string]string{"X-Event-Type": "login"}, nil, ""},
+		{"/webhook/x", map[string]string{"X-Trace": "abc"}, nil, "traced"},
+		{"/webhook", nil, nil, "exact"},
+		{"/webhook/other", nil, []interface{}{1}, ""},
+	} {
+		req := httptest.NewRequest("POST", test.path, nil)
+		for name, value := range test.headers {
+			req.Header.Set(name, value)
+		}
+		name := ""
+		if route := routes.Match(req, test.body); route != nil {
+			name = route.Name
+		}
+		if name != test.route {
+			t.Errorf("%s %v %v: matched %q, want %q", test.path, test.headers, test.body, name, test.route)
+		}
+	}
+}
+
+func TestRouteValidate(t *testing.T) {
+	for _, route := range []Route{
+		{Topic: "t"},
+		{Name: "none"},
+		{Name: "both", Topic: "t", Peer: "12D3KooWRBhwfeP2Y4TCx1SM6s9rUoHhR5STiGwxBhgFRcw3UERE"},
+		{Name: "badPeer", Peer: "not a peer"},
+	} {
+		if err := route.validate(); err == nil {
+			t.Errorf("route %+v accepted", route)
+		}
+	}
+	route := Route{Name: "peer", Peer: "12D3KooWRBhwfeP2Y4TCx1SM6s9rUoHhR5STiGwxBhgFRcw3UERE"}
+	if err := route.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if route.ProtocolID() != Protocol {
+		t.Errorf("default protocol is %s", route.ProtocolID())
+	}
+}
+
+func TestParsePeers(t *testing.T) {
+	peers, err := ParsePeers(" 12D3KooWRBhwfeP2Y4TCx1SM6s9rUoHhR5STiGwxBhgFRcw3UERE, ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := peer.Decode("12D3KooWRBhwfeP2Y4TCx1SM6s9rUoHhR5STiGwxBhgFRcw3UERE")
+	if len(peers) != 1 || !peers[id] {
+		t.Errorf("parsed %v", peers)
+	}
+	if _, err := ParsePeers("12D3KooWRBhwfeP2Y4TCx1SM6s9rUoHhR5STiGwxBhgFRcw3UERE,not a peer"); err == nil {
+		t.Error("invalid peer accepted")
+	}
+}
@@ -0,0 +1,41 @@
+package masa
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/masa-finance/masa-oracle/pkg/crypto"
+)
+
+// certCheckInterval is how often served certificates are checked for renewal and changes on disk
+const certCheckInterval = time.Hour
+
+// NewServerCertManager serves the server certificate at certPath and keyPath, reloading it when the files change.
+// When the caDir environment variable names a local CA directory the certificate is issued and renewed by that CA,
+// for the local host names and addresses plus the comma separated certSANs, valid for certValidity.
+func NewServerCertManager(certPath, keyPath, commonName string) (*crypto.CertManager, error) {
+	ca, err := LocalCA()
+	if err != nil {
+		return nil, err
+	}
+	names, ips := crypto.DefaultServerNames()
+	extraNames, extraIps := crypto.ParseSANs(strings.Split(os.Getenv(CertSANs), ","))
+	validity, _ := time.ParseDuration(os.Getenv(CertValidity))
+	opts := crypto.CertOptions{
+		CommonName:  commonName,
+		DNSNames:    append(names, extraNames...),
+		IPAddresses: append(ips, extraIps...),
+		Validity:    validity,
+	}
+	return crypto.NewCertManager(certPath, keyPath, ca, opts)
+}
+
+// LocalCA loads or creates the CA in the caDir directory, nil without a caDir.
+func LocalCA() (*crypto.CA, error) {
+	dir := os.Getenv(CADir)
+	if dir == "" {
+		return nil, nil
+	}
+	return crypto.LoadOrCreateCA(dir)
+}
@@ -0,0 +1,113 @@
+// Package client is a Go client of version 1 of the node API. The methods of Client are generated from the
+// endpoints of pkg/api by cmd/masa-apigen, run go generate after changing them.
+package client
+
+//go:generate go run ../../cmd/masa-apigen -out client_gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/auth"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Client calls the API of a node.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with httpClient, for instance one trusting the node's CA.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey authenticates the requests with an API key.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// New returns a client of the node API at baseURL, like http://localhost:8080.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: &http.Client{Timeout: defaultTimeout}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// response is v1.Response with the data left encoded until its type is known.
+type response struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Meta    *v1.Meta        `json:"meta"`
+	Error   *v1.Error       `json:"error"`
+}
+
+// do sends a request to a v1 path and decodes the data of the response into result. Failed requests return a
+// *v1.Error.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, result interface{}) (*v1.Meta, error) {
+	target := c.baseURL + v1.BasePath + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set(auth.APIKeyHeader, c.apiKey)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var envelope response
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("invalid response to %s %s (%s): %w", method, path, resp.Status, err)
+	}
+	if envelope.Error != nil {
+		envelope.Error.Status = resp.StatusCode
+		return nil, envelope.Error
+	}
+	if !envelope.Success || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &v1.Error{Code: v1.CodeInternal, Message: "request failed with " + resp.Status, Status: resp.StatusCode}
+	}
+	if result != nil && len(envelope.Data) > 0 {
+		if err := json.Unmarshal(envelope.Data, result); err != nil {
+			return nil, fmt.Errorf("invalid data in response to %s %s: %w", method, path, err)
+		}
+	}
+	return envelope.Meta, nil
+}
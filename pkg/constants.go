@@ -10,9 +10,44 @@ const (
 	masaPrefix           = "/masa"
 	NodeGossipTopic      = "/masa/gossip/v.0.0.3-alpha"
 	AdTopic              = "/masa/ad/v.0.0.3-alpha"
+	KeyRotationTopic     = "/masa/keyRotation/v.0.0.3-alpha"
 	rendezvous           = "masa-mdns"
 	PortNbr              = "portNbr"
 	PageSize             = 25
 	NodeBackupFileName   = "nodeBackup.json"
 	NodeBackupPath       = "nodeBackupPath"
+	AdStoreFileName      = "ads.json"
+	AdStorePath          = "adStorePath"
+	AdStoreMaxSize       = "adStoreMaxSize"
+	AdStoreTTL           = "adStoreTTL"
+	AdRateLimit          = "adRateLimit"
+	AdRateBurst          = "adRateBurst"
+	AdDailyQuota         = "adDailyQuota"
+	AdStakeUnit          = "adStakeUnit"
+	AdMaxStakeMultiplier = "adMaxStakeMultiplier"
+	PubSubTracePath      = "pubsubTracePath"
+	PubSubTraceMaxSize   = "pubsubTraceMaxSize"
+	PubSubTraceFiles     = "pubsubTraceFiles"
+	BridgeClientsFile    = "bridgeClientsFile"
+	BridgeClientCA       = "bridgeClientCA"
+	BridgeRoutesFile     = "bridgeRoutesFile"
+	BridgeDeadLetterPath = "bridgeDeadLetterPath"
+	BridgeMaxAttempts    = "bridgeMaxAttempts"
+	BridgeRetryBackoff   = "bridgeRetryBackoff"
+	BridgePeers          = "bridgePeers"
+	WebhooksFile         = "webhooksFile"
+	WebhookQueueFileName = "webhook_queue.json"
+	WebhookQueuePath     = "webhookQueuePath"
+	EpochDuration        = "epochDuration"
+	CADir                = "caDir"
+	CertSANs             = "certSANs"
+	CertValidity         = "certValidity"
+	APITLS               = "apiTLS"
+	APIAuthFile          = "apiAuthFile"
+	APIAuditLog          = "apiAuditLog"
+	APIKey               = "apiKey"
+	GRPCPort             = "grpcPort"
+	BanListFileName      = "bans.json"
+	BanListPath          = "banListPath"
+	Capabilities         = "capabilities"
 )
@@ -0,0 +1,305 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/masa-finance/masa-oracle/pkg/atomicfile"
+)
+
+const (
+	CACertFileName = "ca.pem"
+	CAKeyFileName  = "ca-key.pem"
+	// DefaultCAValidity is the lifetime of a new root certificate
+	DefaultCAValidity = 10 * 365 * 24 * time.Hour
+	// DefaultCertValidity is the lifetime of issued certificates
+	DefaultCertValidity = 90 * 24 * time.Hour
+	defaultOrganization = "Masa Oracle"
+)
+
+// CA is a local certificate authority issuing the server and client certificates of a deployment.
+type CA struct {
+	Cert    *x509.Certificate
+	CertPEM []byte
+	key     *ecdsa.PrivateKey
+}
+
+// CertOptions describe an issued certificate. Server certificates need at least one DNS name or IP address, the
+// common name of client certificates identifies the client to the servers.
+type CertOptions struct {
+	CommonName   string
+	Organization []string
+	DNSNames     []string
+	IPAddresses  []net.IP
+	// Validity defaults to DefaultCertValidity
+	Validity time.Duration
+	// Client issues a client certificate instead of a server certificate
+	Client bool
+}
+
+// LoadOrCreateCA loads the root certificate and key from dir, creating a new root when there is none.
+func LoadOrCreateCA(dir string) (*CA, error) {
+	certPath, keyPath := filepath.Join(dir, CACertFileName), filepath.Join(dir, CAKeyFileName)
+	ca, err := LoadCA(certPath, keyPath)
+	if err == nil || !os.IsNotExist(err) {
+		return ca, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	ca, err = NewCA(defaultOrganization+" Root CA", DefaultCAValidity)
+	if err != nil {
+		return nil, err
+	}
+	if err := ca.Save(certPath, keyPath); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// NewCA creates a root certificate and key.
+func NewCA(commonName string, validity time.Duration) (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName, Organization: []string{defaultOrganization}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{Cert: cert, CertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), key: key}, nil
+}
+
+// LoadCA reads a root certificate and its key from PEM files.
+func LoadCA(certPath, keyPath string) (*CA, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := parseCertificatePEM(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA certificate %s: %w", certPath, err)
+	}
+	if !cert.IsCA {
+		return nil, fmt.Errorf("%s is not a CA certificate", certPath)
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no key found in %s", keyPath)
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA key %s: %w", keyPath, err)
+	}
+	return &CA{Cert: cert, CertPEM: certPEM, key: key}, nil
+}
+
+// Save writes the root certificate and, readable by the owner only, its key. Both files are replaced atomically, the
+// key first.
+func (ca *CA) Save(certPath, keyPath string) error {
+	keyPEM, err := encodeKeyPEM(ca.key)
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(certPath, ca.CertPEM, 0644)
+}
+
+// Issue creates a certificate and key signed by the CA, PEM encoded.
+func (ca *CA) Issue(opts CertOptions) (certPEM, keyPEM []byte, err error) {
+	if opts.CommonName == "" && len(opts.DNSNames) > 0 {
+		opts.CommonName = opts.DNSNames[0]
+	}
+	if opts.CommonName == "" {
+		return nil, nil, errors.New("certificate needs a common name or DNS name")
+	}
+	if !opts.Client && len(opts.DNSNames) == 0 && len(opts.IPAddresses) == 0 {
+		return nil, nil, errors.New("server certificate needs a DNS name or IP address")
+	}
+	if opts.Validity <= 0 {
+		opts.Validity = DefaultCertValidity
+	}
+	if len(opts.Organization) == 0 {
+		opts.Organization = []string{defaultOrganization}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	notAfter := now.Add(opts.Validity)
+	if notAfter.After(ca.Cert.NotAfter) {
+		notAfter = ca.Cert.NotAfter
+	}
+	extKeyUsage := x509.ExtKeyUsageServerAuth
+	if opts.Client {
+		extKeyUsage = x509.ExtKeyUsageClientAuth
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: opts.CommonName, Organization: opts.Organization},
+		DNSNames:              opts.DNSNames,
+		IPAddresses:           opts.IPAddresses,
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{extKeyUsage},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err = encodeKeyPEM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the chain lets clients that only trust the root verify the certificate
+	certPEM = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), ca.CertPEM...)
+	return certPEM, keyPEM, nil
+}
+
+// IssueFiles issues a certificate and writes it and its key, readable by the owner only, to the given paths. Both
+// files are replaced atomically, the key first, so a reader never pairs the new certificate with the old key.
+func (ca *CA) IssueFiles(opts CertOptions, certPath, keyPath string) error {
+	certPEM, keyPEM, err := ca.Issue(opts)
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(certPath, certPEM, 0644)
+}
+
+// Issued reports whether the first certificate of the PEM file at certPath was issued by the CA.
+func (ca *CA) Issued(certPath string) bool {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return false
+	}
+	cert, err := parseCertificatePEM(data)
+	if err != nil {
+		return false
+	}
+	return cert.CheckSignatureFrom(ca.Cert) == nil
+}
+
+// WriteBundle writes the CA certificate followed by the certificates in extra, PEM files, for clients to trust.
+func (ca *CA) WriteBundle(path string, extra ...string) error {
+	bundle := append([]byte{}, ca.CertPEM...)
+	for _, file := range extra {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := parseCertificatePEM(data); err != nil {
+			return fmt.Errorf("invalid certificate %s: %w", file, err)
+		}
+		bundle = append(bundle, data...)
+	}
+	return os.WriteFile(path, bundle, 0644)
+}
+
+// CertExpiry returns when the first certificate of a PEM file expires.
+func CertExpiry(certPath string) (time.Time, error) {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	cert, err := parseCertificatePEM(data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// NeedsRenewal reports whether the certificate at certPath is missing, invalid or expires within renewBefore.
+func NeedsRenewal(certPath string, renewBefore time.Duration) bool {
+	expiry, err := CertExpiry(certPath)
+	return err != nil || time.Until(expiry) < renewBefore
+}
+
+// DefaultServerNames returns the DNS names and IP addresses of a local server certificate: localhost, the host
+// name and the loopback addresses.
+func DefaultServerNames() ([]string, []net.IP) {
+	names := []string{"localhost"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "localhost" {
+		names = append(names, hostname)
+	}
+	return names, []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+}
+
+// ParseSANs splits subject alternative names into DNS names and IP addresses.
+func ParseSANs(sans []string) ([]string, []net.IP) {
+	var names []string
+	var ips []net.IP
+	for _, san := range sans {
+		if ip := net.ParseIP(san); ip != nil {
+			ips = append(ips, ip)
+		} else if san != "" {
+			names = append(names, san)
+		}
+	}
+	return names, ips
+}
+
+func parseCertificatePEM(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func encodeKeyPEM(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+func newSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
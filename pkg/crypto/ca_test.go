@@ -0,0 +1,118 @@
+package crypto
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCAIssue(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Cert.Equal(ca.Cert) {
+		t.Fatal("the CA was not reloaded from its directory")
+	}
+
+	names, ips := ParseSANs([]string{"node.internal", "10.0.0.1"})
+	certPath, keyPath := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem")
+	// an existing key file readable by others is replaced by one readable by the owner only
+	if err := os.WriteFile(keyPath, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ca.IssueFiles(CertOptions{DNSNames: names, IPAddresses: ips, Validity: time.Hour}, certPath, keyPath); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode %v, want 0600", info.Mode().Perm())
+	}
+	m, err := NewCertManager(certPath, keyPath, nil, CertOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := m.cert.Leaf
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	for _, name := range []string{"node.internal", "10.0.0.1"} {
+		if _, err := leaf.Verify(x509.VerifyOptions{DNSName: name, Roots: roots}); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if leaf.Subject.CommonName != "node.internal" || time.Until(leaf.NotAfter) > time.Hour {
+		t.Errorf("unexpected certificate %s valid until %s", leaf.Subject.CommonName, leaf.NotAfter)
+	}
+	if _, _, err := ca.Issue(CertOptions{CommonName: "server"}); err == nil {
+		t.Error("server certificate without names issued")
+	}
+	if _, _, err := ca.Issue(CertOptions{CommonName: "client", Client: true}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCertManagerRenewsAndReloads(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := NewCA("test", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewCA("other", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath, keyPath := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem")
+	opts := CertOptions{DNSNames: []string{"localhost"}}
+	if err := other.IssueFiles(opts, certPath, keyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// a certificate of another CA is kept, even when it is due for renewal
+	m, err := NewCertManager(certPath, keyPath, ca, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.renewBefore = 2 * time.Hour
+	if err := m.renew(); err != nil {
+		t.Fatal(err)
+	}
+	if ca.Issued(certPath) {
+		t.Fatal("the certificate of another CA was replaced")
+	}
+
+	// a missing certificate is issued by the CA
+	if err := os.Remove(certPath); err != nil {
+		t.Fatal(err)
+	}
+	m, err = NewCertManager(certPath, keyPath, ca, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := m.GetCertificate(nil)
+	if first.Leaf.CheckSignatureFrom(ca.Cert) != nil {
+		t.Fatal("certificate was not issued by the CA")
+	}
+	// the CA caps the validity, so the certificate is due for renewal right away
+	m.renewBefore = 2 * time.Hour
+	time.Sleep(10 * time.Millisecond)
+	if err := m.renew(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.reload(); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := m.GetCertificate(nil)
+	if second.Leaf.SerialNumber.Cmp(first.Leaf.SerialNumber) == 0 {
+		t.Error("certificate was not renewed")
+	}
+}
@@ -0,0 +1,137 @@
+package crypto
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CertManager serves a certificate from PEM files to TLS servers and reloads it when the files change, without
+// restarting the server. With a CA it also issues a missing certificate, and renews the ones it issued before they
+// expire.
+type CertManager struct {
+	certPath string
+	keyPath  string
+	ca       *CA
+	opts     CertOptions
+	// renewBefore is how long before expiry the certificate is renewed
+	renewBefore time.Duration
+	mutex       sync.RWMutex
+	cert        *tls.Certificate
+	modTime     time.Time
+}
+
+// NewCertManager loads the certificate at certPath and keyPath. When ca is not nil, a missing certificate is issued
+// by it with opts, and a certificate it issued is renewed once a third of its validity is left. A certificate of
+// another CA is left alone, with a warning once it is due for renewal.
+func NewCertManager(certPath, keyPath string, ca *CA, opts CertOptions) (*CertManager, error) {
+	if opts.Validity <= 0 {
+		opts.Validity = DefaultCertValidity
+	}
+	m := &CertManager{certPath: certPath, keyPath: keyPath, ca: ca, opts: opts, renewBefore: opts.Validity / 3}
+	if err := m.renew(); err != nil {
+		return nil, err
+	}
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config.GetCertificate.
+func (m *CertManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.cert, nil
+}
+
+// GetClientCertificate returns the current certificate, for tls.Config.GetClientCertificate.
+func (m *CertManager) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return m.GetCertificate(nil)
+}
+
+// TLSConfig returns a server configuration serving the current certificate.
+func (m *CertManager) TLSConfig() *tls.Config {
+	return &tls.Config{GetCertificate: m.GetCertificate, MinVersion: tls.VersionTLS12}
+}
+
+// Expiry returns when the current certificate expires.
+func (m *CertManager) Expiry() time.Time {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.cert.Leaf.NotAfter
+}
+
+// Run checks every interval whether the certificate has to be renewed or was replaced on disk, until ctx is done.
+func (m *CertManager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.renew(); err != nil {
+				logrus.Errorf("Error renewing certificate %s: %v", m.certPath, err)
+			}
+			if err := m.reload(); err != nil {
+				logrus.Errorf("Error reloading certificate %s: %v", m.certPath, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *CertManager) renew() error {
+	if m.ca == nil {
+		return nil
+	}
+	if _, err := os.Stat(m.certPath); os.IsNotExist(err) {
+		logrus.Infof("Issuing certificate %s", m.certPath)
+		return m.ca.IssueFiles(m.opts, m.certPath, m.keyPath)
+	}
+	if !NeedsRenewal(m.certPath, m.renewBefore) {
+		return nil
+	}
+	if !m.ca.Issued(m.certPath) {
+		// the operator's own certificate, replacing it is up to them
+		if expiry, err := CertExpiry(m.certPath); err == nil {
+			logrus.Warnf("Certificate %s was not issued by the local CA and expires on %s, replace it", m.certPath, expiry.Format(time.RFC3339))
+		}
+		return nil
+	}
+	logrus.Infof("Renewing certificate %s", m.certPath)
+	return m.ca.IssueFiles(m.opts, m.certPath, m.keyPath)
+}
+
+// reload loads the certificate files when they changed since they were last loaded.
+func (m *CertManager) reload() error {
+	info, err := os.Stat(m.certPath)
+	if err != nil {
+		return err
+	}
+	m.mutex.RLock()
+	unchanged := m.cert != nil && info.ModTime().Equal(m.modTime)
+	m.mutex.RUnlock()
+	if unchanged {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(m.certPath, m.keyPath)
+	if err != nil {
+		return err
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.cert != nil {
+		logrus.Infof("Reloaded certificate %s, valid until %s", m.certPath, cert.Leaf.NotAfter.Format(time.RFC3339))
+	}
+	m.cert, m.modTime = &cert, info.ModTime()
+	return nil
+}
@@ -0,0 +1,81 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+const (
+	// FormatProtobuf is the hex encoded libp2p protobuf key, the format of legacy key files and PRIVATE_KEY.
+	FormatProtobuf = "protobuf"
+	// FormatHex is the raw hex encoded secp256k1 key as used by Ethereum wallets.
+	FormatHex = "hex"
+	// FormatKeystore is an Ethereum V3 keystore JSON document.
+	FormatKeystore = "keystore"
+)
+
+// ExportPrivateKey encodes privKey in the given format. The passphrase is only used for FormatKeystore.
+func ExportPrivateKey(privKey crypto.PrivKey, format string, opts KeyOptions) ([]byte, error) {
+	switch format {
+	case FormatProtobuf:
+		data, err := crypto.MarshalPrivateKey(privKey)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(hex.EncodeToString(data)), nil
+	case FormatHex:
+		ecdsaPrivKey, err := Libp2pPrivateKeyToEcdsa(privKey)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(hex.EncodeToString(ethCrypto.FromECDSA(ecdsaPrivKey))), nil
+	case FormatKeystore:
+		passphrase, err := GetPassphrase(opts, true)
+		if err != nil {
+			return nil, err
+		}
+		scryptN, scryptP := opts.ScryptN, opts.ScryptP
+		if scryptN == 0 || scryptP == 0 {
+			scryptN, scryptP = defaultScryptParams()
+		}
+		return EncryptKeystore(privKey, passphrase, scryptN, scryptP)
+	default:
+		return nil, fmt.Errorf("unknown key format %q, expected %s, %s or %s", format, FormatProtobuf, FormatHex, FormatKeystore)
+	}
+}
+
+// ImportPrivateKey decodes a key in any of the export formats. Keystores are decrypted with the passphrase from opts
+// and hex input is tried as a raw Ethereum key before the libp2p protobuf encoding.
+func ImportPrivateKey(data []byte, opts KeyOptions) (crypto.PrivKey, error) {
+	if IsKeystoreFile(data) {
+		passphrase, err := GetPassphrase(opts, false)
+		if err != nil {
+			return nil, err
+		}
+		return DecryptKeystore(data, passphrase)
+	}
+	encoded := strings.TrimPrefix(strings.TrimSpace(string(data)), "0x")
+	raw, err := hex.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding private key: %w", err)
+	}
+	if len(raw) == 32 {
+		ecdsaPrivKey, err := ethCrypto.ToECDSA(raw)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing ethereum private key: %w", err)
+		}
+		return EcdsaToLibp2pPrivateKey(ecdsaPrivKey)
+	}
+	privKey, err := crypto.UnmarshalPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling private key: %w", err)
+	}
+	if privKey.Type() != crypto.Secp256k1 {
+		return nil, fmt.Errorf("unsupported key type %s, the node key must be secp256k1", privKey.Type())
+	}
+	return privKey, nil
+}
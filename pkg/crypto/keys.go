@@ -5,11 +5,14 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"strings"
 
 	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
 	ethCrypto "github.com/ethereum/go-ethereum/crypto"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/sirupsen/logrus"
+
+	"github.com/masa-finance/masa-oracle/pkg/atomicfile"
 )
 
 func getPrivateKeyFromEnv(envKey string) (privKey crypto.PrivKey, err error) {
@@ -25,15 +28,9 @@ func getPrivateKeyFromEnv(envKey string) (privKey crypto.PrivKey, err error) {
 	return privKey, nil
 }
 
-func getPrivateKeyFromFile(keyFile string) (crypto.PrivKey, error) {
-	// Check if the private key file exists
-	data, err := os.ReadFile(keyFile)
-	if err != nil {
-		return nil, logAndReturnError("Error reading private key file: %s", err)
-	}
-
+func getPrivateKeyFromHex(keyFile string, data []byte) (crypto.PrivKey, error) {
 	// Decode the private key from the file
-	rawKey, err := hex.DecodeString(string(data))
+	rawKey, err := hex.DecodeString(strings.TrimSpace(string(data)))
 	if err != nil {
 		return nil, logAndReturnError("Error decoding private key: %s", err)
 	}
@@ -47,43 +44,97 @@ func getPrivateKeyFromFile(keyFile string) (crypto.PrivKey, error) {
 	return privKey, nil
 }
 
-func generateNewPrivateKey(keyFile string) (crypto.PrivKey, error) {
-	// Generate a new private key
-	privKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 2048)
+func getPrivateKeyFromFile(keyFile string, opts KeyOptions) (crypto.PrivKey, error) {
+	// Check if the private key file exists, only a missing file results in a new key
+	if _, err := os.Stat(keyFile); os.IsNotExist(err) {
+		return generateNewPrivateKey(keyFile, opts)
+	}
+	return LoadPrivateKey(keyFile, opts)
+}
+
+// LoadPrivateKey reads the node key from keyFile, which may be a keystore or a legacy hex key. Legacy keys are
+// migrated to an encrypted keystore unless opts.Plaintext is set.
+func LoadPrivateKey(keyFile string, opts KeyOptions) (crypto.PrivKey, error) {
+	privKey, legacy, err := readPrivateKey(keyFile, opts)
 	if err != nil {
-		return nil, logAndReturnError("Error generating new private key: %s", err)
+		return nil, err
+	}
+	if legacy && !opts.Plaintext {
+		if err := migrateToKeystore(keyFile, privKey, opts); err != nil {
+			return nil, logAndReturnError("Error migrating private key to keystore: %s", err)
+		}
+	}
+	return privKey, nil
+}
+
+// ReadPrivateKey reads the node key from keyFile like LoadPrivateKey but never changes the file, for commands that
+// only look at the key.
+func ReadPrivateKey(keyFile string, opts KeyOptions) (crypto.PrivKey, error) {
+	privKey, _, err := readPrivateKey(keyFile, opts)
+	return privKey, err
+}
+
+// readPrivateKey reads a keystore or a legacy hex key file, legacy tells which it was.
+func readPrivateKey(keyFile string, opts KeyOptions) (privKey crypto.PrivKey, legacy bool, err error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, false, logAndReturnError("Error reading private key file: %s", err)
+	}
+	if IsKeystoreFile(data) {
+		privKey, err = getPrivateKeyFromKeystore(keyFile, data, opts)
+		return privKey, false, err
+	}
+	privKey, err = getPrivateKeyFromHex(keyFile, data)
+	return privKey, true, err
+}
+
+// SavePrivateKey writes privKey to keyFile as a keystore, or as a hex encoded libp2p key when opts.Plaintext is set.
+func SavePrivateKey(keyFile string, privKey crypto.PrivKey, opts KeyOptions) error {
+	if !opts.Plaintext {
+		return saveKeystore(keyFile, privKey, opts, true)
 	}
 	data, err := crypto.MarshalPrivateKey(privKey)
 	if err != nil {
-		return nil, logAndReturnError("Error marshalling private key: %s", err)
+		return err
 	}
-	encodedKey := hex.EncodeToString(data)
+	return atomicfile.WriteFile(keyFile, []byte(hex.EncodeToString(data)), 0600)
+}
 
+func generateNewPrivateKey(keyFile string, opts KeyOptions) (crypto.PrivKey, error) {
+	// Generate a new private key
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 2048)
+	if err != nil {
+		return nil, logAndReturnError("Error generating new private key: %s", err)
+	}
 	// Save the private key to the file
-	if err := os.WriteFile(keyFile, []byte(encodedKey), 0600); err != nil {
+	if err := SavePrivateKey(keyFile, privKey, opts); err != nil {
 		return nil, logAndReturnError("Error saving private key to file: %s", err)
 	}
-	logrus.Infof("Generated and saved a new private key to %s: %s", keyFile, privKey)
+	logrus.Infof("Generated and saved a new private key to %s", keyFile)
 	return privKey, nil
 }
 
+// GetOrCreatePrivateKey loads the node key using the options from the environment, see KeyOptionsFromEnv.
 func GetOrCreatePrivateKey(keyFile string) (crypto.PrivKey, *ecdsa.PrivateKey, string, error) {
+	return GetOrCreatePrivateKeyWithOptions(keyFile, KeyOptionsFromEnv())
+}
+
+// GetOrCreatePrivateKeyWithOptions loads the node key from PRIVATE_KEY or keyFile, creating a new key when the file
+// does not exist. Plaintext key files are migrated to an encrypted keystore unless opts.Plaintext is set.
+func GetOrCreatePrivateKeyWithOptions(keyFile string, opts KeyOptions) (crypto.PrivKey, *ecdsa.PrivateKey, string, error) {
 	var privKey crypto.PrivKey
 	var err error
-	envKey := os.Getenv("PRIVATE_KEY")
+	envKey := os.Getenv(PrivateKeyEnv)
 	if envKey != "" {
+		logrus.Warnf("Using the plaintext %s environment variable, consider using an encrypted keystore instead", PrivateKeyEnv)
 		privKey, err = getPrivateKeyFromEnv(envKey)
 		if err != nil {
 			return nil, nil, "", err
 		}
 	} else {
-		// Check if the private key file exists
-		privKey, err = getPrivateKeyFromFile(keyFile)
+		privKey, err = getPrivateKeyFromFile(keyFile, opts)
 		if err != nil {
-			privKey, err = generateNewPrivateKey(keyFile)
-			if err != nil {
-				return nil, nil, "", err
-			}
+			return nil, nil, "", err
 		}
 	}
 	// After obtaining the libp2p privKey, convert it to an ECDSA private key
@@ -91,6 +142,10 @@ func GetOrCreatePrivateKey(keyFile string) (crypto.PrivKey, *ecdsa.PrivateKey, s
 	if err != nil {
 		return nil, nil, "", err
 	}
+	ethAddress := ethCrypto.PubkeyToAddress(ecdsaPrivKey.PublicKey).Hex()
+	if !opts.WriteEcdsaKey {
+		return privKey, ecdsaPrivKey, ethAddress, nil
+	}
 	// Save the ECDSA private key in the same directory as the libp2p key
 	ecdsaKeyFilePath := keyFile + ".ecdsa"
 	ecdsaKeyBytes := ethCrypto.FromECDSA(ecdsaPrivKey)
@@ -100,7 +155,6 @@ func GetOrCreatePrivateKey(keyFile string) (crypto.PrivKey, *ecdsa.PrivateKey, s
 		return privKey, ecdsaPrivKey, "", err
 	}
 	logrus.Infof("Saved ECDSA private key to %s", ecdsaKeyFilePath)
-	ethAddress := ethCrypto.PubkeyToAddress(ecdsaPrivKey.PublicKey).Hex()
 	return privKey, ecdsaPrivKey, ethAddress, nil
 }
 
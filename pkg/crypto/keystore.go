@@ -0,0 +1,224 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/term"
+
+	"github.com/masa-finance/masa-oracle/pkg/atomicfile"
+)
+
+const (
+	// KeystorePassphraseEnv holds the keystore passphrase directly, mainly for container deployments.
+	KeystorePassphraseEnv = "KEYSTORE_PASSPHRASE"
+	// KeystorePassphraseFileEnv points to a file whose first line is the keystore passphrase.
+	KeystorePassphraseFileEnv = "KEYSTORE_PASSPHRASE_FILE"
+	// PlaintextKeyEnv disables the encrypted keystore and keeps the legacy hex key file.
+	PlaintextKeyEnv = "PLAINTEXT_KEY"
+	// WriteEcdsaKeyEnv controls whether the raw ECDSA key is written to <keyFile>.ecdsa.
+	WriteEcdsaKeyEnv = "WRITE_ECDSA_KEY"
+	// PrivateKeyEnv holds a hex encoded libp2p private key and bypasses the key file entirely.
+	PrivateKeyEnv = "PRIVATE_KEY"
+)
+
+// KeyOptions controls where the node key comes from and how it is stored on disk.
+type KeyOptions struct {
+	// Plaintext keeps the key as a hex encoded libp2p key instead of a V3 keystore.
+	Plaintext bool
+	// WriteEcdsaKey writes an unencrypted copy of the ECDSA key to <keyFile>.ecdsa.
+	WriteEcdsaKey bool
+	// Passphrase is used as is when set, otherwise PassphraseFile or a prompt is used.
+	Passphrase string
+	// PassphraseFile is read when Passphrase is empty.
+	PassphraseFile string
+	// ScryptN and ScryptP are the scrypt parameters used for new keystores.
+	ScryptN int
+	ScryptP int
+}
+
+// KeyOptionsFromEnv builds the KeyOptions from the environment. The encrypted keystore is the default and the
+// .ecdsa copy is only written for plaintext keys unless WRITE_ECDSA_KEY says otherwise.
+func KeyOptionsFromEnv() KeyOptions {
+	plaintext := getEnvAsBool(PlaintextKeyEnv, false)
+	return KeyOptions{
+		Plaintext:      plaintext,
+		WriteEcdsaKey:  getEnvAsBool(WriteEcdsaKeyEnv, plaintext),
+		Passphrase:     os.Getenv(KeystorePassphraseEnv),
+		PassphraseFile: os.Getenv(KeystorePassphraseFileEnv),
+		ScryptN:        keystore.StandardScryptN,
+		ScryptP:        keystore.StandardScryptP,
+	}
+}
+
+// IsKeystoreFile reports whether the contents of a key file look like a V3 keystore rather than a hex key.
+func IsKeystoreFile(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// EncryptKeystore encrypts a libp2p secp256k1 private key as an Ethereum V3 keystore JSON document.
+func EncryptKeystore(privKey crypto.PrivKey, passphrase string, scryptN, scryptP int) ([]byte, error) {
+	ecdsaPrivKey, err := Libp2pPrivateKeyToEcdsa(privKey)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	key := &keystore.Key{
+		Id:         id,
+		Address:    ethCrypto.PubkeyToAddress(ecdsaPrivKey.PublicKey),
+		PrivateKey: ecdsaPrivKey,
+	}
+	return keystore.EncryptKey(key, passphrase, scryptN, scryptP)
+}
+
+// DecryptKeystore decrypts an Ethereum V3 keystore JSON document into a libp2p private key.
+func DecryptKeystore(data []byte, passphrase string) (crypto.PrivKey, error) {
+	key, err := keystore.DecryptKey(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return EcdsaToLibp2pPrivateKey(key.PrivateKey)
+}
+
+// EcdsaToLibp2pPrivateKey converts a secp256k1 ECDSA private key into its libp2p representation.
+func EcdsaToLibp2pPrivateKey(ecdsaPrivKey *ecdsa.PrivateKey) (crypto.PrivKey, error) {
+	return crypto.UnmarshalSecp256k1PrivateKey(ethCrypto.FromECDSA(ecdsaPrivKey))
+}
+
+// GetPassphrase resolves the keystore passphrase from the options, a passphrase file or an interactive prompt.
+// When confirm is set the prompt asks twice, which is used when a new keystore is created.
+func GetPassphrase(opts KeyOptions, confirm bool) (string, error) {
+	if opts.Passphrase != "" {
+		return opts.Passphrase, nil
+	}
+	if opts.PassphraseFile != "" {
+		data, err := os.ReadFile(opts.PassphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("error reading passphrase file: %w", err)
+		}
+		passphrase := strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r")
+		if passphrase == "" {
+			return "", fmt.Errorf("passphrase file %s is empty", opts.PassphraseFile)
+		}
+		return passphrase, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no keystore passphrase available, set %s or %s", KeystorePassphraseEnv, KeystorePassphraseFileEnv)
+	}
+	passphrase, err := promptPassphrase("Keystore passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("keystore passphrase must not be empty")
+	}
+	if confirm {
+		repeat, err := promptPassphrase("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if repeat != passphrase {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+func promptPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error reading passphrase: %w", err)
+	}
+	return string(data), nil
+}
+
+func getPrivateKeyFromKeystore(keyFile string, data []byte, opts KeyOptions) (crypto.PrivKey, error) {
+	passphrase, err := GetPassphrase(opts, false)
+	if err != nil {
+		return nil, logAndReturnError("Error getting keystore passphrase: %s", err)
+	}
+	privKey, err := DecryptKeystore(data, passphrase)
+	if err != nil {
+		return nil, logAndReturnError("Error decrypting keystore %s: %s", keyFile, err)
+	}
+	logrus.Infof("Loaded private key from keystore %s", keyFile)
+	return privKey, nil
+}
+
+// saveKeystore encrypts privKey and writes it to keyFile, replacing any existing file atomically.
+func saveKeystore(keyFile string, privKey crypto.PrivKey, opts KeyOptions, confirm bool) error {
+	passphrase, err := GetPassphrase(opts, confirm)
+	if err != nil {
+		return err
+	}
+	scryptN, scryptP := opts.ScryptN, opts.ScryptP
+	if scryptN == 0 || scryptP == 0 {
+		scryptN, scryptP = defaultScryptParams()
+	}
+	data, err := EncryptKeystore(privKey, passphrase, scryptN, scryptP)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(keyFile, data, 0600)
+}
+
+// BackupPrivateKey copies the key in keyFile to keyFile.<unix time>.old and returns the backup path. A legacy hex key
+// is encrypted into a keystore for the backup, it is only copied as plaintext when opts.Plaintext is set.
+func BackupPrivateKey(keyFile string, at time.Time, opts KeyOptions) (string, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return "", err
+	}
+	backupFile := fmt.Sprintf("%s.%d.old", keyFile, at.Unix())
+	if IsKeystoreFile(data) || opts.Plaintext {
+		return backupFile, atomicfile.WriteFile(backupFile, data, 0600)
+	}
+	privKey, err := getPrivateKeyFromHex(keyFile, data)
+	if err != nil {
+		return "", err
+	}
+	return backupFile, saveKeystore(backupFile, privKey, opts, true)
+}
+
+// migrateToKeystore replaces a legacy hex key file with an encrypted keystore holding the same key.
+func migrateToKeystore(keyFile string, privKey crypto.PrivKey, opts KeyOptions) error {
+	logrus.Warnf("Migrating plaintext private key %s to an encrypted keystore", keyFile)
+	if err := saveKeystore(keyFile, privKey, opts, true); err != nil {
+		return err
+	}
+	if !opts.WriteEcdsaKey {
+		ecdsaKeyFilePath := keyFile + ".ecdsa"
+		if err := os.Remove(ecdsaKeyFilePath); err != nil && !os.IsNotExist(err) {
+			logrus.Warnf("Could not remove plaintext ECDSA key %s: %v", ecdsaKeyFilePath, err)
+		}
+	}
+	logrus.Infof("Migrated private key to keystore %s", keyFile)
+	return nil
+}
+
+func defaultScryptParams() (int, int) {
+	return keystore.StandardScryptN, keystore.StandardScryptP
+}
+
+func getEnvAsBool(name string, defaultVal bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(name)); err == nil {
+		return value
+	}
+	return defaultVal
+}
@@ -0,0 +1,161 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+func testKeyOptions() KeyOptions {
+	return KeyOptions{
+		Passphrase: "correct horse battery staple",
+		ScryptN:    keystore.LightScryptN,
+		ScryptP:    keystore.LightScryptP,
+	}
+}
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := EncryptKeystore(privKey, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsKeystoreFile(data) {
+		t.Fatal("expected encrypted key to be detected as a keystore")
+	}
+	decrypted, err := DecryptKeystore(data, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !privKey.Equals(decrypted) {
+		t.Error("decrypted key does not match the original key")
+	}
+	if _, err := DecryptKeystore(data, "wrong"); err == nil {
+		t.Error("expected decrypting with the wrong passphrase to fail")
+	}
+}
+
+func TestMigratePlaintextKey(t *testing.T) {
+	t.Setenv(PrivateKeyEnv, "")
+	keyFile := filepath.Join(t.TempDir(), "masa_oracle_key")
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := crypto.MarshalPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, []byte(hex.EncodeToString(raw)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile+".ecdsa", []byte("plaintext"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, _, ethAddress, err := GetOrCreatePrivateKeyWithOptions(keyFile, testKeyOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !privKey.Equals(loaded) {
+		t.Error("migrated key does not match the original key")
+	}
+	if ethAddress == "" {
+		t.Error("expected an eth address")
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsKeystoreFile(data) {
+		t.Error("expected key file to be replaced by a keystore")
+	}
+	if _, err := os.Stat(keyFile + ".ecdsa"); !os.IsNotExist(err) {
+		t.Error("expected plaintext ecdsa key to be removed")
+	}
+
+	reloaded, _, _, err := GetOrCreatePrivateKeyWithOptions(keyFile, testKeyOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !privKey.Equals(reloaded) {
+		t.Error("reloaded key does not match the original key")
+	}
+}
+
+func TestReadPrivateKeyLeavesLegacyKey(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "masa_oracle_key")
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := crypto.MarshalPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := []byte(hex.EncodeToString(raw))
+	if err := os.WriteFile(keyFile, legacy, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadPrivateKey(keyFile, testKeyOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !privKey.Equals(read) {
+		t.Error("read key does not match the original key")
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(legacy) {
+		t.Error("expected ReadPrivateKey to leave the key file unchanged")
+	}
+}
+
+func TestBackupEncryptsLegacyKey(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "masa_oracle_key")
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := crypto.MarshalPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, []byte(hex.EncodeToString(raw)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := testKeyOptions()
+	backupFile, err := BackupPrivateKey(keyFile, time.Unix(1700000000, 0), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backupFile != keyFile+".1700000000.old" {
+		t.Errorf("unexpected backup file %s", backupFile)
+	}
+	data, err := os.ReadFile(backupFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsKeystoreFile(data) {
+		t.Fatal("expected the backup of a legacy key to be an encrypted keystore")
+	}
+	decrypted, err := DecryptKeystore(data, opts.Passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !privKey.Equals(decrypted) {
+		t.Error("backed up key does not match the original key")
+	}
+}
@@ -0,0 +1,94 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultDerivationPath is the BIP-44 path of the first Ethereum account, so wallets restoring the same mnemonic
+// show the node's staking address.
+const DefaultDerivationPath = "m/44'/60'/0'/0/0"
+
+// NewMnemonic generates a new 24 word BIP-39 mnemonic.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(256)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// PrivateKeyFromMnemonic derives the node key from a BIP-39 mnemonic and an optional BIP-39 passphrase along the
+// BIP-32 derivation path. The same key is used for the libp2p identity and the Ethereum address.
+func PrivateKeyFromMnemonic(mnemonic, passphrase, path string) (crypto.PrivKey, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	ecdsaPrivKey, err := deriveKey(seed, derivationPath)
+	if err != nil {
+		return nil, err
+	}
+	return EcdsaToLibp2pPrivateKey(ecdsaPrivKey)
+}
+
+// deriveKey implements BIP-32 private key derivation on secp256k1.
+func deriveKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	curveOrder := ethCrypto.S256().Params().N
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+	if k := new(big.Int).SetBytes(key); k.Sign() == 0 || k.Cmp(curveOrder) >= 0 {
+		return nil, errors.New("invalid master key, use a different seed")
+	}
+
+	for _, index := range path {
+		var data []byte
+		if index >= 0x80000000 {
+			// hardened child, derived from the private key
+			data = append([]byte{0}, key...)
+		} else {
+			parent, err := ethCrypto.ToECDSA(key)
+			if err != nil {
+				return nil, err
+			}
+			data = ethCrypto.CompressPubkey(&parent.PublicKey)
+		}
+		data = binary.BigEndian.AppendUint32(data, index)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		il := new(big.Int).SetBytes(sum[:32])
+		if il.Cmp(curveOrder) >= 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		child := il.Add(il, new(big.Int).SetBytes(key))
+		child.Mod(child, curveOrder)
+		if child.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		key = child.FillBytes(make([]byte, 32))
+		chainCode = sum[32:]
+	}
+	return ethCrypto.ToECDSA(key)
+}
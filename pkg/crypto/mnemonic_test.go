@@ -0,0 +1,25 @@
+package crypto
+
+import (
+	"testing"
+)
+
+func TestPrivateKeyFromMnemonic(t *testing.T) {
+	// well known test vector, the first Ethereum account of the all "abandon" mnemonic
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	privKey, err := PrivateKeyFromMnemonic(mnemonic, "", DefaultDerivationPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ethAddress, err := Libp2pPubKeyToEthAddress(privKey.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ethAddress != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Errorf("unexpected address %s", ethAddress)
+	}
+
+	if _, err := PrivateKeyFromMnemonic("abandon abandon abandon", "", DefaultDerivationPath); err == nil {
+		t.Error("expected an invalid mnemonic to be rejected")
+	}
+}
@@ -0,0 +1,160 @@
+package crypto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/sirupsen/logrus"
+)
+
+// KeyRotationFile returns the path of the pending key rotation announcement for keyFile.
+func KeyRotationFile(keyFile string) string {
+	return keyFile + ".rotation.json"
+}
+
+// KeyRotation announces that a node replaced its identity key. It is signed by the old key so that peers can
+// verify the new identity belongs to the same operator.
+type KeyRotation struct {
+	OldPeerId     string    `json:"oldPeerId"`
+	NewPeerId     string    `json:"newPeerId"`
+	OldEthAddress string    `json:"oldEthAddress"`
+	NewEthAddress string    `json:"newEthAddress"`
+	OldPublicKey  []byte    `json:"oldPublicKey"`
+	Timestamp     time.Time `json:"timestamp"`
+	Signature     []byte    `json:"signature"`
+}
+
+// NewKeyRotation creates a KeyRotation from oldKey to newKey and signs it with oldKey.
+func NewKeyRotation(oldKey, newKey crypto.PrivKey) (*KeyRotation, error) {
+	oldPeerId, err := peer.IDFromPrivateKey(oldKey)
+	if err != nil {
+		return nil, err
+	}
+	newPeerId, err := peer.IDFromPrivateKey(newKey)
+	if err != nil {
+		return nil, err
+	}
+	oldEthAddress, err := Libp2pPubKeyToEthAddress(oldKey.GetPublic())
+	if err != nil {
+		return nil, err
+	}
+	newEthAddress, err := Libp2pPubKeyToEthAddress(newKey.GetPublic())
+	if err != nil {
+		return nil, err
+	}
+	oldPublicKey, err := crypto.MarshalPublicKey(oldKey.GetPublic())
+	if err != nil {
+		return nil, err
+	}
+	rotation := &KeyRotation{
+		OldPeerId:     oldPeerId.String(),
+		NewPeerId:     newPeerId.String(),
+		OldEthAddress: oldEthAddress,
+		NewEthAddress: newEthAddress,
+		OldPublicKey:  oldPublicKey,
+		Timestamp:     time.Now().UTC(),
+	}
+	payload, err := rotation.signingPayload()
+	if err != nil {
+		return nil, err
+	}
+	rotation.Signature, err = oldKey.Sign(payload)
+	if err != nil {
+		return nil, err
+	}
+	return rotation, nil
+}
+
+// Verify checks that the rotation was signed by the key belonging to OldPeerId.
+func (r *KeyRotation) Verify() error {
+	pubKey, err := crypto.UnmarshalPublicKey(r.OldPublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid old public key: %w", err)
+	}
+	oldPeerId, err := peer.IDFromPublicKey(pubKey)
+	if err != nil {
+		return err
+	}
+	if oldPeerId.String() != r.OldPeerId {
+		return fmt.Errorf("old public key does not match peer %s", r.OldPeerId)
+	}
+	payload, err := r.signingPayload()
+	if err != nil {
+		return err
+	}
+	ok, err := pubKey.Verify(payload, r.Signature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid key rotation signature")
+	}
+	return nil
+}
+
+func (r *KeyRotation) signingPayload() ([]byte, error) {
+	unsigned := *r
+	unsigned.Signature = nil
+	return json.Marshal(unsigned)
+}
+
+// RotatePrivateKey replaces the key in keyFile with a newly generated key. The old key file is kept as a backup next
+// to keyFile and a KeyRotation signed by the old key is written to KeyRotationFile for the node to announce.
+func RotatePrivateKey(keyFile string, opts KeyOptions) (*KeyRotation, error) {
+	oldKey, err := LoadPrivateKey(keyFile, opts)
+	if err != nil {
+		return nil, err
+	}
+	newKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 2048)
+	if err != nil {
+		return nil, logAndReturnError("Error generating new private key: %s", err)
+	}
+	rotation, err := NewKeyRotation(oldKey, newKey)
+	if err != nil {
+		return nil, logAndReturnError("Error signing key rotation: %s", err)
+	}
+
+	backupFile, err := BackupPrivateKey(keyFile, rotation.Timestamp, opts)
+	if err != nil {
+		return nil, logAndReturnError("Error backing up private key: %s", err)
+	}
+	logrus.Infof("Backed up old private key to %s", backupFile)
+	if err := SavePrivateKey(keyFile, newKey, opts); err != nil {
+		return nil, logAndReturnError("Error saving private key to file: %s", err)
+	}
+	// the plaintext copy belongs to the old key, it is written again on start when enabled
+	if err := os.Remove(keyFile + ".ecdsa"); err != nil && !os.IsNotExist(err) {
+		logrus.Warnf("Could not remove ECDSA key of the old identity: %v", err)
+	}
+
+	data, err := json.MarshalIndent(rotation, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(KeyRotationFile(keyFile), data, 0644); err != nil {
+		return nil, logAndReturnError("Error saving key rotation: %s", err)
+	}
+	logrus.Infof("Rotated private key from %s to %s", rotation.OldPeerId, rotation.NewPeerId)
+	return rotation, nil
+}
+
+// ReadKeyRotation reads and verifies the pending key rotation announcement for keyFile, if any.
+func ReadKeyRotation(keyFile string) (*KeyRotation, error) {
+	data, err := os.ReadFile(KeyRotationFile(keyFile))
+	if err != nil {
+		return nil, err
+	}
+	var rotation KeyRotation
+	if err := json.Unmarshal(data, &rotation); err != nil {
+		return nil, err
+	}
+	if err := rotation.Verify(); err != nil {
+		return nil, err
+	}
+	return &rotation, nil
+}
@@ -0,0 +1,30 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+func TestKeyRotationVerify(t *testing.T) {
+	oldKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotation, err := NewKeyRotation(oldKey, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rotation.Verify(); err != nil {
+		t.Fatalf("expected rotation to verify: %v", err)
+	}
+
+	rotation.NewEthAddress = rotation.OldEthAddress
+	if err := rotation.Verify(); err == nil {
+		t.Error("expected a tampered rotation to fail verification")
+	}
+}
@@ -1,7 +1,6 @@
 package ethereum
 
 import (
-	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"log"
@@ -10,11 +9,11 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 
 	"github.com/masa-finance/masa-oracle/pkg/ethereum/contracts"
+	"github.com/masa-finance/masa-oracle/pkg/signer"
 )
 
 const (
@@ -24,7 +23,7 @@ const (
 	PaymentMethod   = "0x0000000000000000000000000000000000000000"
 )
 
-func Mint(ecdsaPrivKey *ecdsa.PrivateKey, toAddress string) error {
+func Mint(s signer.Signer, toAddress string) error {
 	// Connect to the Ethereum client
 
 	rpcEndpoint := os.Getenv("rpc.endpoint")
@@ -62,7 +61,10 @@ func Mint(ecdsaPrivKey *ecdsa.PrivateKey, toAddress string) error {
 	}
 
 	// Create a new transactor
-	auth, err := bind.NewKeyedTransactorWithChainID(ecdsaPrivKey, chainId)
+	auth, err := signer.NewTransactor(s, chainId)
+	if err != nil {
+		return err
+	}
 	auth.GasLimit = uint64(limit)
 
 	// Call the mint function
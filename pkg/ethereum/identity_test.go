@@ -13,6 +13,7 @@ import (
 
 	masa "github.com/masa-finance/masa-oracle/pkg"
 	"github.com/masa-finance/masa-oracle/pkg/crypto"
+	"github.com/masa-finance/masa-oracle/pkg/signer"
 )
 
 func init() {
@@ -37,13 +38,29 @@ func init() {
 	}
 }
 
+// setTestPassphrase supplies the keystore passphrase of the node key when the environment does not.
+func setTestPassphrase(t *testing.T) {
+	if os.Getenv(crypto.KeystorePassphraseEnv) == "" && os.Getenv(crypto.KeystorePassphraseFileEnv) == "" {
+		t.Setenv(crypto.KeystorePassphraseEnv, "test passphrase")
+	}
+}
+
+// skipWithoutChain skips an integration test when the chain endpoint in the environment variable env is not set.
+func skipWithoutChain(t *testing.T, env string) {
+	if os.Getenv(env) == "" {
+		t.Skipf("%s is not set, skipping the chain integration test", env)
+	}
+}
+
 func TestMint(t *testing.T) {
+	skipWithoutChain(t, "rpc.endpoint")
 	toAddress := "0x52f823a4dbe2Dc2934d5F5a854dCb8B407FEa24A"
+	setTestPassphrase(t)
 	_, ecdsaPrivKey, _, err := crypto.GetOrCreatePrivateKey(os.Getenv(masa.KeyFileKey))
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = Mint(ecdsaPrivKey, toAddress)
+	err = Mint(signer.NewPrivateKeySigner(ecdsaPrivKey), toAddress)
 	if err != nil {
 		t.Error(err)
 	}
@@ -1,20 +1,19 @@
 package ethereum
 
 import (
-	"crypto/ecdsa"
 	"errors"
 	"math/big"
 	"os"
 	"strconv"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 
 	"github.com/masa-finance/masa-oracle/pkg/ethereum/contracts"
+	"github.com/masa-finance/masa-oracle/pkg/signer"
 )
 
-func AddUser(ecdsaKey *ecdsa.PrivateKey, chainId int64, userId, reputationScore string) (string, error) {
+func AddUser(s signer.Signer, chainId int64, userId, reputationScore string) (string, error) {
 	// Connect to an ethereum node  running locally
 
 	ethNodeUrl := os.Getenv("eth.node.url")
@@ -27,7 +26,10 @@ func AddUser(ecdsaKey *ecdsa.PrivateKey, chainId int64, userId, reputationScore
 	}
 
 	// Initialize transactor
-	transactor, err := bind.NewKeyedTransactorWithChainID(ecdsaKey, big.NewInt(chainId))
+	transactor, err := signer.NewTransactor(s, big.NewInt(chainId))
+	if err != nil {
+		return "", err
+	}
 
 	// Set up gas price and gas limit
 	price, err := strconv.ParseInt(os.Getenv("gas.price.wei"), 10, 64)
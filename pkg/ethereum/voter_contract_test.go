@@ -14,6 +14,7 @@ import (
 
 	masa "github.com/masa-finance/masa-oracle/pkg"
 	"github.com/masa-finance/masa-oracle/pkg/crypto"
+	"github.com/masa-finance/masa-oracle/pkg/signer"
 )
 
 func init() {
@@ -38,12 +39,14 @@ func init() {
 }
 
 func TestAddUser(t *testing.T) {
+	skipWithoutChain(t, "eth.node.url")
+	setTestPassphrase(t)
 	_, ecdsaPrivKey, _, err := crypto.GetOrCreatePrivateKey(os.Getenv(masa.KeyFileKey))
 	if err != nil {
 		logrus.Fatal(err)
 	}
 
-	result, err := AddUser(ecdsaPrivKey, 31337, "testUser", "100")
+	result, err := AddUser(signer.NewPrivateKeySigner(ecdsaPrivKey), 31337, "testUser", "100")
 	if err != nil {
 		t.Fatal(err)
 	}
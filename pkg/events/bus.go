@@ -0,0 +1,144 @@
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Topics of the events published by the node.
+const (
+	TopicPeerConnected    = "peer.connected"
+	TopicPeerDisconnected = "peer.disconnected"
+	TopicNodeData         = "nodeData"
+	TopicAd               = "ad"
+	TopicStake            = "stake"
+	TopicBridge           = "bridge"
+	TopicEpochClosed      = "epoch.closed"
+)
+
+const (
+	DefaultHistorySize = 1024
+	subscriberBuffer   = 256
+)
+
+// Event is a change on the node or the network. Sequence numbers increase by one per event, clients resume a
+// stream after the last sequence they received.
+type Event struct {
+	Sequence uint64      `json:"sequence"`
+	Topic    string      `json:"topic"`
+	Time     time.Time   `json:"time"`
+	Data     interface{} `json:"data"`
+}
+
+// Bus fans events out to subscribers and keeps the most recent events so subscribers can resume. It is safe for
+// concurrent use and Publish never blocks: a subscriber that can not keep up is closed and has to resume.
+type Bus struct {
+	mutex       sync.Mutex
+	sequence    uint64
+	history     []Event
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives the events of its topics on C until it is closed. C is closed when the subscription ends.
+type Subscription struct {
+	C      <-chan Event
+	c      chan Event
+	topics []string
+	bus    *Bus
+	closed bool
+}
+
+func NewBus(historySize int) *Bus {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Bus{
+		historySize: historySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event to the subscribers of topic.
+func (b *Bus) Publish(topic string, data interface{}) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.sequence++
+	event := Event{Sequence: b.sequence, Topic: topic, Time: time.Now().UTC(), Data: data}
+	b.history = append(b.history, event)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+	for subscription := range b.subscribers {
+		if !subscription.matches(topic) {
+			continue
+		}
+		select {
+		case subscription.c <- event:
+		default:
+			b.closeLocked(subscription)
+		}
+	}
+}
+
+// Subscribe returns a subscription to the given topics, all topics when none are given. A topic also selects its
+// sub topics, "peer" selects "peer.connected" and "peer.disconnected". When after is not zero the events following
+// that sequence are replayed first; complete is false when some of them are no longer kept.
+func (b *Bus) Subscribe(topics []string, after uint64) (subscription *Subscription, complete bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c := make(chan Event, subscriberBuffer+b.historySize)
+	subscription = &Subscription{C: c, c: c, topics: topics, bus: b}
+	complete = true
+	if after > 0 {
+		complete = after >= b.sequence || (len(b.history) > 0 && b.history[0].Sequence <= after+1)
+		for _, event := range b.history {
+			if event.Sequence > after && subscription.matches(event.Topic) {
+				c <- event
+			}
+		}
+	}
+	b.subscribers[subscription] = struct{}{}
+	return subscription, complete
+}
+
+// Sequence returns the sequence of the last published event.
+func (b *Bus) Sequence() uint64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.sequence
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.bus.mutex.Lock()
+	defer s.bus.mutex.Unlock()
+	s.bus.closeLocked(s)
+}
+
+func (b *Bus) closeLocked(subscription *Subscription) {
+	if subscription.closed {
+		return
+	}
+	subscription.closed = true
+	delete(b.subscribers, subscription)
+	close(subscription.c)
+}
+
+func (s *Subscription) matches(topic string) bool {
+	if len(s.topics) == 0 {
+		return true
+	}
+	for _, t := range s.topics {
+		if InTopic(topic, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// InTopic reports whether topic is parent or one of its sub topics.
+func InTopic(topic, parent string) bool {
+	return topic == parent || strings.HasPrefix(topic, parent+".")
+}
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"testing"
+)
+
+func receive(t *testing.T, subscription *Subscription) Event {
+	select {
+	case event, ok := <-subscription.C:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return event
+	default:
+		t.Fatal("expected an event")
+	}
+	return Event{}
+}
+
+func TestBusTopics(t *testing.T) {
+	bus := NewBus(10)
+	peers, _ := bus.Subscribe([]string{"peer"}, 0)
+	defer peers.Close()
+	all, _ := bus.Subscribe(nil, 0)
+	defer all.Close()
+
+	bus.Publish(TopicAd, "ad")
+	bus.Publish(TopicPeerConnected, "peer")
+
+	if event := receive(t, peers); event.Topic != TopicPeerConnected || event.Sequence != 2 {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event := receive(t, all); event.Topic != TopicAd || event.Sequence != 1 {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event := receive(t, all); event.Topic != TopicPeerConnected {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
+
+func TestBusResume(t *testing.T) {
+	bus := NewBus(3)
+	for i := 0; i < 5; i++ {
+		bus.Publish(TopicAd, i)
+	}
+
+	subscription, complete := bus.Subscribe(nil, 3)
+	if !complete {
+		t.Error("expected the events after sequence 3 to be kept")
+	}
+	for _, sequence := range []uint64{4, 5} {
+		if event := receive(t, subscription); event.Sequence != sequence {
+			t.Errorf("expected event %d, got %d", sequence, event.Sequence)
+		}
+	}
+	subscription.Close()
+
+	subscription, complete = bus.Subscribe(nil, 1)
+	defer subscription.Close()
+	if complete {
+		t.Error("expected event 2 to be reported as missed")
+	}
+	if event := receive(t, subscription); event.Sequence != 3 {
+		t.Errorf("expected the oldest kept event, got %d", event.Sequence)
+	}
+}
+
+func TestBusSlowSubscriber(t *testing.T) {
+	bus := NewBus(1)
+	subscription, _ := bus.Subscribe(nil, 0)
+	for i := 0; i < subscriberBuffer+2; i++ {
+		bus.Publish(TopicAd, i)
+	}
+	count := 0
+	for range subscription.C {
+		count++
+	}
+	if count != subscriberBuffer+1 {
+		t.Errorf("expected the subscriber to be closed once its buffer was full, got %d events", count)
+	}
+	subscription.Close()
+}
@@ -0,0 +1,177 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/core/discovery"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/discovery/routing"
+	"github.com/sirupsen/logrus"
+)
+
+// Capability is a service a node offers, advertised on the DHT so clients find the nodes that can serve them.
+type Capability string
+
+const (
+	// CapabilityRelay nodes relay connections for peers behind NATs
+	CapabilityRelay Capability = "relay"
+	// CapabilityArchive nodes keep the node data history of the network
+	CapabilityArchive Capability = "archive"
+	// CapabilityBridge nodes accept bridge clients
+	CapabilityBridge Capability = "bridge"
+	// CapabilityDataPrefix prefixes the data sources a node serves, like data:twitter
+	CapabilityDataPrefix = "data:"
+
+	capabilityNamespace = "/masa/capability/"
+	// capabilityRetryDelay is how long a failed advertisement waits before it is retried
+	capabilityRetryDelay = 2 * time.Minute
+)
+
+const (
+	ReachabilitySelf        = "self"
+	ReachabilityConnected   = "connected"
+	ReachabilityReachable   = "reachable"
+	ReachabilityUnreachable = "unreachable"
+	ReachabilityUnknown     = "unknown"
+)
+
+var dataSourcePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// ParseCapability parses the name of a capability, one of relay, archive, bridge or data:<source>.
+func ParseCapability(name string) (Capability, error) {
+	capability := Capability(strings.ToLower(strings.TrimSpace(name)))
+	switch capability {
+	case CapabilityRelay, CapabilityArchive, CapabilityBridge:
+		return capability, nil
+	}
+	if source, ok := strings.CutPrefix(string(capability), CapabilityDataPrefix); ok && dataSourcePattern.MatchString(source) {
+		return capability, nil
+	}
+	return "", fmt.Errorf("unknown capability %q, expected relay, archive, bridge or data:<source>", name)
+}
+
+// ParseCapabilities parses a comma separated list of capabilities, ignoring duplicates.
+func ParseCapabilities(list string) ([]Capability, error) {
+	capabilities := make([]Capability, 0)
+	seen := make(map[Capability]bool)
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		capability, err := ParseCapability(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[capability] {
+			seen[capability] = true
+			capabilities = append(capabilities, capability)
+		}
+	}
+	return capabilities, nil
+}
+
+// Namespace returns the DHT discovery namespace the providers of the capability are advertised under.
+func (c Capability) Namespace() string {
+	return capabilityNamespace + string(c)
+}
+
+// AdvertiseCapabilities advertises the node as a provider of each capability until ctx is done, advertising again
+// before the provider records expire.
+func AdvertiseCapabilities(ctx context.Context, dht *dht.IpfsDHT, capabilities []Capability) {
+	routingDiscovery := routing.NewRoutingDiscovery(dht)
+	for _, capability := range capabilities {
+		go advertiseCapability(ctx, routingDiscovery, capability)
+	}
+}
+
+func advertiseCapability(ctx context.Context, advertiser discovery.Advertiser, capability Capability) {
+	for {
+		wait := capabilityRetryDelay
+		ttl, err := advertiser.Advertise(ctx, capability.Namespace())
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logrus.Warnf("Failed to advertise capability %s, retrying in %s: %v", capability, wait, err)
+		} else {
+			logrus.Infof("Advertised capability %s", capability)
+			wait = 7 * ttl / 8
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Provider is a node advertising a capability and how well this node can reach it.
+type Provider struct {
+	PeerID    string   `json:"peerId"`
+	Addresses []string `json:"addresses"`
+	// Reachability is self, connected, reachable or unreachable when probed, or unknown
+	Reachability string `json:"reachability"`
+	// Latency is the measured round trip time, zero when not measured yet
+	Latency time.Duration `json:"latency"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// FindProviders looks up at most limit providers of the capability on the DHT, those found before ctx is done. With
+// probe, the providers this node is not connected to are dialed, each within timeout, to tell the reachable ones from
+// the unreachable ones.
+func FindProviders(ctx context.Context, host host.Host, dht *dht.IpfsDHT, capability Capability, limit int, probe bool, timeout time.Duration) ([]Provider, error) {
+	routingDiscovery := routing.NewRoutingDiscovery(dht)
+	peerChan, err := routingDiscovery.FindPeers(ctx, capability.Namespace(), discovery.Limit(limit))
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]peer.AddrInfo, 0)
+	for info := range peerChan {
+		infos = append(infos, info)
+	}
+	providers := make([]Provider, len(infos))
+	var wg sync.WaitGroup
+	for i, info := range infos {
+		i, info := i, info
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			providers[i] = providerOf(ctx, host, info, probe, timeout)
+		}()
+	}
+	wg.Wait()
+	return providers, nil
+}
+
+func providerOf(ctx context.Context, host host.Host, info peer.AddrInfo, probe bool, timeout time.Duration) Provider {
+	provider := Provider{PeerID: info.ID.String(), Addresses: make([]string, 0), Reachability: ReachabilityUnknown}
+	for _, addr := range info.Addrs {
+		provider.Addresses = append(provider.Addresses, addr.String())
+	}
+	switch {
+	case info.ID == host.ID():
+		provider.Reachability = ReachabilitySelf
+		return provider
+	case host.Network().Connectedness(info.ID) == network.Connected:
+		provider.Reachability = ReachabilityConnected
+	case probe:
+		dialCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		if err := host.Connect(dialCtx, info); err != nil {
+			provider.Reachability = ReachabilityUnreachable
+			provider.Error = err.Error()
+		} else {
+			provider.Reachability = ReachabilityReachable
+		}
+	}
+	provider.Latency = host.Peerstore().LatencyEWMA(info.ID)
+	return provider
+}
@@ -0,0 +1,28 @@
+package network
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCapabilities(t *testing.T) {
+	capabilities, err := ParseCapabilities(" relay, Data:twitter,,archive,relay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Capability{CapabilityRelay, "data:twitter", CapabilityArchive}
+	if !reflect.DeepEqual(capabilities, expected) {
+		t.Errorf("got %v, want %v", capabilities, expected)
+	}
+	if capabilities[1].Namespace() != "/masa/capability/data:twitter" {
+		t.Errorf("unexpected namespace %s", capabilities[1].Namespace())
+	}
+	for _, list := range []string{"teleport", "data:", "data:a/b"} {
+		if _, err := ParseCapabilities(list); err == nil {
+			t.Errorf("expected %q to be refused", list)
+		}
+	}
+	if capabilities, err := ParseCapabilities(""); err != nil || len(capabilities) != 0 {
+		t.Errorf("expected no capabilities, got %v, %v", capabilities, err)
+	}
+}
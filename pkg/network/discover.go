@@ -104,5 +104,4 @@ func Discover(ctx context.Context, host host.Host, dht *dht.IpfsDHT, protocol pr
 			}
 		}
 	}
-	logrus.Infof("found %d peers", len(host.Network().Peers()))
 }
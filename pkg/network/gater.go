@@ -0,0 +1,172 @@
+package network
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/sirupsen/logrus"
+
+	"github.com/masa-finance/masa-oracle/pkg/atomicfile"
+)
+
+// DefaultBanDuration is how long a peer is banned when no duration is given.
+const DefaultBanDuration = 24 * time.Hour
+
+// Ban keeps a peer from connecting to the node until it expires.
+type Ban struct {
+	PeerId  peer.ID   `json:"peerId"`
+	Reason  string    `json:"reason,omitempty"`
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"`
+}
+
+// BanList is a connection gater refusing the connections of banned peers, in both directions. The bans are saved to
+// its file, so they survive restarts until they expire.
+type BanList struct {
+	mutex    sync.RWMutex
+	bans     map[peer.ID]Ban
+	filePath string
+}
+
+// NewBanList creates the ban list and loads the bans of filePath, if it is set.
+func NewBanList(filePath string) (*BanList, error) {
+	b := &BanList{bans: make(map[peer.ID]Ban), filePath: filePath}
+	if filePath == "" {
+		return b, nil
+	}
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var bans []Ban
+	if err := json.Unmarshal(data, &bans); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, ban := range bans {
+		if ban.Expires.After(now) {
+			b.bans[ban.PeerId] = ban
+		}
+	}
+	logrus.Infof("Loaded %d peer bans from file", len(b.bans))
+	return b, nil
+}
+
+// Ban bans a peer for duration, DefaultBanDuration when zero, replacing an earlier ban of the peer. When the bans
+// can not be saved the earlier state is restored and the ban is not in effect.
+func (b *BanList) Ban(p peer.ID, duration time.Duration, reason string) (Ban, error) {
+	if duration <= 0 {
+		duration = DefaultBanDuration
+	}
+	now := time.Now()
+	ban := Ban{PeerId: p, Reason: reason, Created: now, Expires: now.Add(duration)}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	previous, banned := b.bans[p]
+	b.bans[p] = ban
+	if err := b.save(); err != nil {
+		if banned {
+			b.bans[p] = previous
+		} else {
+			delete(b.bans, p)
+		}
+		return Ban{}, err
+	}
+	return ban, nil
+}
+
+// Unban lifts the ban of a peer, reporting whether it was banned. When the bans can not be saved the ban stays in
+// effect.
+func (b *BanList) Unban(p peer.ID) (bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	ban, ok := b.bans[p]
+	if !ok {
+		return false, nil
+	}
+	delete(b.bans, p)
+	if err := b.save(); err != nil {
+		b.bans[p] = ban
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns the bans in effect, the ones expiring first first.
+func (b *BanList) List() []Ban {
+	now := time.Now()
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	bans := make([]Ban, 0, len(b.bans))
+	for _, ban := range b.bans {
+		if ban.Expires.After(now) {
+			bans = append(bans, ban)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Expires.Before(bans[j].Expires) })
+	return bans
+}
+
+// IsBanned reports whether a ban of the peer is in effect.
+func (b *BanList) IsBanned(p peer.ID) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	ban, ok := b.bans[p]
+	return ok && ban.Expires.After(time.Now())
+}
+
+// save writes the bans in effect to the file, the caller holds the lock.
+func (b *BanList) save() error {
+	if b.filePath == "" {
+		return nil
+	}
+	now := time.Now()
+	bans := make([]Ban, 0, len(b.bans))
+	for p, ban := range b.bans {
+		if ban.Expires.After(now) {
+			bans = append(bans, ban)
+		} else {
+			delete(b.bans, p)
+		}
+	}
+	data, err := json.Marshal(bans)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(b.filePath, data, 0644)
+}
+
+func (b *BanList) InterceptPeerDial(p peer.ID) bool {
+	return !b.IsBanned(p)
+}
+
+func (b *BanList) InterceptAddrDial(p peer.ID, _ ma.Multiaddr) bool {
+	return !b.IsBanned(p)
+}
+
+// InterceptAccept allows all inbound connections, the peer is only known once they are secured.
+func (b *BanList) InterceptAccept(network.ConnMultiaddrs) bool {
+	return true
+}
+
+func (b *BanList) InterceptSecured(_ network.Direction, p peer.ID, _ network.ConnMultiaddrs) bool {
+	if b.IsBanned(p) {
+		logrus.Debugf("Refusing connection of banned peer %s", p)
+		return false
+	}
+	return true
+}
+
+func (b *BanList) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
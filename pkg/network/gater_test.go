@@ -0,0 +1,61 @@
+package network
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/test"
+)
+
+func TestBanList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bans.json")
+	bans, err := NewBanList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	banned, other := test.RandPeerIDFatal(t), test.RandPeerIDFatal(t)
+	if _, err := bans.Ban(banned, time.Hour, "spam"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bans.Ban(other, time.Nanosecond, ""); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+
+	if bans.InterceptPeerDial(banned) || bans.InterceptSecured(network.DirInbound, banned, nil) {
+		t.Error("expected the banned peer to be refused")
+	}
+	if !bans.InterceptPeerDial(other) {
+		t.Error("expected the expired ban to be lifted")
+	}
+
+	reloaded, err := NewBanList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := reloaded.List(); len(list) != 1 || list[0].PeerId != banned || list[0].Reason != "spam" {
+		t.Errorf("expected the ban to be saved, got %+v", list)
+	}
+	if ok, err := reloaded.Unban(banned); !ok || err != nil {
+		t.Errorf("expected the peer to be unbanned, got %v, %v", ok, err)
+	}
+	if reloaded.IsBanned(banned) {
+		t.Error("expected the unbanned peer to be allowed")
+	}
+}
+
+func TestBanListSaveFailure(t *testing.T) {
+	bans, err := NewBanList(filepath.Join(t.TempDir(), "missing", "bans.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := test.RandPeerIDFatal(t)
+	if _, err := bans.Ban(p, time.Hour, ""); err == nil {
+		t.Fatal("expected saving to a missing directory to fail")
+	}
+	if bans.IsBanned(p) {
+		t.Error("expected the ban that was not saved to be rolled back")
+	}
+}
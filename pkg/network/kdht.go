@@ -2,6 +2,7 @@ package network
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -21,11 +22,14 @@ const (
 	PeerRemoved = "PeerRemoved"
 )
 
+// WithDht creates the Kademlia DHT under the protocol prefix, connects it to the bootstrap peers and reports the
+// changes of its routing table on peerChan. The dhtOptions, like record validators, are added to the defaults.
 func WithDht(ctx context.Context, host host.Host, bootstrapPeers []multiaddr.Multiaddr,
-	pId, prefix protocol.ID, peerChan chan PeerEvent) (*dht.IpfsDHT, error) {
+	pId, prefix protocol.ID, peerChan chan PeerEvent, dhtOptions ...dht.Option) (*dht.IpfsDHT, error) {
 	options := make([]dht.Option, 0)
 	options = append(options, dht.Mode(dht.ModeAutoServer))
 	options = append(options, dht.ProtocolPrefix(prefix))
+	options = append(options, dhtOptions...)
 
 	kademliaDHT, err := dht.New(ctx, host, options...)
 	if err != nil {
@@ -59,44 +63,16 @@ func WithDht(ctx context.Context, host host.Host, bootstrapPeers []multiaddr.Mul
 
 	var wg sync.WaitGroup
 	for _, peerAddr := range bootstrapPeers {
-		peerinfo, err := peer.AddrInfoFromP2pAddr(peerAddr)
-		if err != nil {
-			logrus.Errorf("kdht: %s", err.Error())
-		}
-		if peerinfo.ID == host.ID() {
-			logrus.Info("DHT Skipping connect to self")
-			continue
-		}
-		// Add the bootstrap node to the DHT
-		added, err := kademliaDHT.RoutingTable().TryAddPeer(peerinfo.ID, true, false)
-		if err != nil {
-			logrus.Warningf("Failed to add bootstrap peer %s to DHT: %v", peerinfo.ID, err)
-		} else if !added {
-			logrus.Warningf("Bootstrap peer %s was not added to DHT", peerinfo.ID)
-		} else {
-			logrus.Infof("Successfully added bootstrap peer %s to DHT", peerinfo.ID)
-		}
-
+		peerAddr := peerAddr
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if err := host.Connect(ctx, *peerinfo); err != nil {
-				logrus.Errorf("Failed to connect to bootstrap peer %s: %v", peerinfo.ID, err)
+			err := ConnectBootnode(ctx, host, kademliaDHT, peerAddr, pId)
+			if errors.Is(err, ErrSelf) {
+				logrus.Info("DHT Skipping connect to self")
+			} else if err != nil {
+				logrus.Errorf("Failed to connect to bootstrap peer %s: %v", peerAddr, err)
 				time.Sleep(retryDelay)
-			} else {
-				logrus.Info("Connection established with node:", *peerinfo)
-				stream, err := host.NewStream(ctx, peerinfo.ID, pId)
-				if err != nil {
-					logrus.Error("Error opening stream:", err)
-					return
-				}
-				defer stream.Close() // Close the stream when done
-
-				_, err = stream.Write([]byte(fmt.Sprintf("Initial Hello from %s\n", peerAddr.String())))
-				if err != nil {
-					logrus.Error("Error writing to stream:", err)
-					return
-				}
 			}
 		}()
 	}
@@ -104,6 +80,47 @@ func WithDht(ctx context.Context, host host.Host, bootstrapPeers []multiaddr.Mul
 	return kademliaDHT, nil
 }
 
+// ErrSelf is returned when the node is asked to connect to itself.
+var ErrSelf = errors.New("cannot connect to self")
+
+// ConnectBootnode adds the peer of a bootnode address to the routing table of the DHT, connects to it and says hello
+// over the protocol pId.
+func ConnectBootnode(ctx context.Context, host host.Host, kademliaDHT *dht.IpfsDHT, peerAddr multiaddr.Multiaddr, pId protocol.ID) error {
+	peerinfo, err := peer.AddrInfoFromP2pAddr(peerAddr)
+	if err != nil {
+		return fmt.Errorf("kdht: %w", err)
+	}
+	if peerinfo.ID == host.ID() {
+		return ErrSelf
+	}
+	// Add the bootstrap node to the DHT
+	added, err := kademliaDHT.RoutingTable().TryAddPeer(peerinfo.ID, true, false)
+	if err != nil {
+		logrus.Warningf("Failed to add bootstrap peer %s to DHT: %v", peerinfo.ID, err)
+	} else if !added {
+		logrus.Warningf("Bootstrap peer %s was not added to DHT", peerinfo.ID)
+	} else {
+		logrus.Infof("Successfully added bootstrap peer %s to DHT", peerinfo.ID)
+	}
+
+	if err := host.Connect(ctx, *peerinfo); err != nil {
+		return err
+	}
+	logrus.Info("Connection established with node:", *peerinfo)
+	stream, err := host.NewStream(ctx, peerinfo.ID, pId)
+	if err != nil {
+		logrus.Error("Error opening stream:", err)
+		return nil
+	}
+	defer stream.Close() // Close the stream when done
+
+	_, err = stream.Write([]byte(fmt.Sprintf("Initial Hello from %s\n", peerAddr.String())))
+	if err != nil {
+		logrus.Error("Error writing to stream:", err)
+	}
+	return nil
+}
+
 func monitorRoutingTable(ctx context.Context, dht *dht.IpfsDHT, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -0,0 +1,196 @@
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/record"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// pruneInterval is how often the TTLs of the addresses the address book let expire are dropped
+const pruneInterval = time.Minute
+
+// AddrTTL is a known address of a peer with the TTL it was last added with. TTL is zero when it is not known.
+type AddrTTL struct {
+	Addr ma.Multiaddr
+	TTL  time.Duration
+}
+
+// TTLPeerstore is an in-memory peerstore that remembers the TTL of the addresses of each peer, which the libp2p
+// peerstores do not expose. It follows the rules of the address book: adding an address never lowers its TTL,
+// setting it replaces the TTL and a TTL of zero removes it. The TTLs of expired addresses are dropped every
+// pruneInterval until the peerstore is closed.
+type TTLPeerstore struct {
+	peerstore.Peerstore
+	mutex     sync.Mutex
+	ttls      map[peer.ID]map[string]time.Duration
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewTTLPeerstore creates the peerstore, use it with the libp2p.Peerstore option.
+func NewTTLPeerstore() (*TTLPeerstore, error) {
+	store, err := pstoremem.NewPeerstore()
+	if err != nil {
+		return nil, err
+	}
+	ps := &TTLPeerstore{Peerstore: store, ttls: make(map[peer.ID]map[string]time.Duration), done: make(chan struct{})}
+	go ps.prune(pruneInterval)
+	return ps, nil
+}
+
+// Close stops pruning the TTLs and closes the address book.
+func (ps *TTLPeerstore) Close() error {
+	ps.closeOnce.Do(func() { close(ps.done) })
+	return ps.Peerstore.Close()
+}
+
+// AddrTTLs returns the known addresses of p with their TTLs.
+func (ps *TTLPeerstore) AddrTTLs(p peer.ID) []AddrTTL {
+	addrs := ps.Addrs(p)
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	result := make([]AddrTTL, 0, len(addrs))
+	for _, addr := range addrs {
+		result = append(result, AddrTTL{Addr: addr, TTL: ps.ttls[p][string(addr.Bytes())]})
+	}
+	return result
+}
+
+func (ps *TTLPeerstore) AddAddr(p peer.ID, addr ma.Multiaddr, ttl time.Duration) {
+	ps.AddAddrs(p, []ma.Multiaddr{addr}, ttl)
+}
+
+func (ps *TTLPeerstore) AddAddrs(p peer.ID, addrs []ma.Multiaddr, ttl time.Duration) {
+	ps.forgetExpired(p)
+	ps.Peerstore.AddAddrs(p, addrs, ttl)
+	ps.record(p, addrs, ttl, false)
+}
+
+func (ps *TTLPeerstore) SetAddr(p peer.ID, addr ma.Multiaddr, ttl time.Duration) {
+	ps.SetAddrs(p, []ma.Multiaddr{addr}, ttl)
+}
+
+func (ps *TTLPeerstore) SetAddrs(p peer.ID, addrs []ma.Multiaddr, ttl time.Duration) {
+	ps.Peerstore.SetAddrs(p, addrs, ttl)
+	ps.record(p, addrs, ttl, true)
+}
+
+func (ps *TTLPeerstore) UpdateAddrs(p peer.ID, oldTTL time.Duration, newTTL time.Duration) {
+	ps.Peerstore.UpdateAddrs(p, oldTTL, newTTL)
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	for key, ttl := range ps.ttls[p] {
+		if ttl != oldTTL {
+			continue
+		}
+		if newTTL == 0 {
+			delete(ps.ttls[p], key)
+		} else {
+			ps.ttls[p][key] = newTTL
+		}
+	}
+}
+
+func (ps *TTLPeerstore) ClearAddrs(p peer.ID) {
+	ps.Peerstore.ClearAddrs(p)
+	ps.mutex.Lock()
+	delete(ps.ttls, p)
+	ps.mutex.Unlock()
+}
+
+func (ps *TTLPeerstore) RemovePeer(p peer.ID) {
+	ps.Peerstore.RemovePeer(p)
+	ps.mutex.Lock()
+	delete(ps.ttls, p)
+	ps.mutex.Unlock()
+}
+
+// ConsumePeerRecord and GetPeerRecord forward the certified address book, identify looks it up by type assertion.
+func (ps *TTLPeerstore) ConsumePeerRecord(recordEnvelope *record.Envelope, ttl time.Duration) (bool, error) {
+	accepted, err := ps.Peerstore.(peerstore.CertifiedAddrBook).ConsumePeerRecord(recordEnvelope, ttl)
+	if err != nil || !accepted {
+		return accepted, err
+	}
+	if rec, err := recordEnvelope.Record(); err == nil {
+		if peerRecord, ok := rec.(*peer.PeerRecord); ok {
+			ps.record(peerRecord.PeerID, peerRecord.Addrs, ttl, true)
+		}
+	}
+	return accepted, nil
+}
+
+func (ps *TTLPeerstore) GetPeerRecord(p peer.ID) *record.Envelope {
+	return ps.Peerstore.(peerstore.CertifiedAddrBook).GetPeerRecord(p)
+}
+
+func (ps *TTLPeerstore) prune(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ps.pruneExpired()
+		case <-ps.done:
+			return
+		}
+	}
+}
+
+// pruneExpired forgets the expired addresses of every peer, including the peers that are never added again.
+func (ps *TTLPeerstore) pruneExpired() {
+	ps.mutex.Lock()
+	peers := make([]peer.ID, 0, len(ps.ttls))
+	for p := range ps.ttls {
+		peers = append(peers, p)
+	}
+	ps.mutex.Unlock()
+	for _, p := range peers {
+		ps.forgetExpired(p)
+	}
+}
+
+// forgetExpired drops the TTLs of the addresses the address book let expire, so adding them again starts afresh.
+// The addresses are read under the lock so an address added meanwhile is not forgotten.
+func (ps *TTLPeerstore) forgetExpired(p peer.ID) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	current := make(map[string]bool)
+	for _, addr := range ps.Addrs(p) {
+		current[string(addr.Bytes())] = true
+	}
+	for key := range ps.ttls[p] {
+		if !current[key] {
+			delete(ps.ttls[p], key)
+		}
+	}
+	if len(ps.ttls[p]) == 0 {
+		delete(ps.ttls, p)
+	}
+}
+
+func (ps *TTLPeerstore) record(p peer.ID, addrs []ma.Multiaddr, ttl time.Duration, replace bool) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	if ps.ttls[p] == nil {
+		ps.ttls[p] = make(map[string]time.Duration)
+	}
+	for _, addr := range addrs {
+		if addr == nil {
+			continue
+		}
+		key := string(addr.Bytes())
+		switch {
+		case ttl <= 0:
+			if replace {
+				delete(ps.ttls[p], key)
+			}
+		case replace || ttl > ps.ttls[p][key]:
+			ps.ttls[p][key] = ttl
+		}
+	}
+}
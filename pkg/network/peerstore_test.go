@@ -0,0 +1,34 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/test"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestTTLPeerstorePrunesExpiredAddresses(t *testing.T) {
+	ps, err := NewTTLPeerstore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ps.Close()
+	short, long := test.RandPeerIDFatal(t), test.RandPeerIDFatal(t)
+	addr := ma.StringCast("/ip4/1.2.3.4/tcp/4001")
+	ps.AddAddr(short, addr, 10*time.Millisecond)
+	ps.AddAddr(long, addr, time.Hour)
+
+	time.Sleep(20 * time.Millisecond)
+	ps.pruneExpired()
+	ps.mutex.Lock()
+	_, shortKept := ps.ttls[short]
+	_, longKept := ps.ttls[long]
+	ps.mutex.Unlock()
+	if shortKept || !longKept {
+		t.Errorf("expected only the expired peer to be pruned, kept short %v and long %v", shortKept, longKept)
+	}
+	if ttls := ps.AddrTTLs(long); len(ttls) != 1 || ttls[0].TTL != time.Hour {
+		t.Errorf("unexpected addresses %v", ttls)
+	}
+}
@@ -3,9 +3,12 @@ package masa
 import (
 	"bufio"
 	"context"
-	"crypto/ecdsa"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/libp2p/go-libp2p"
@@ -13,6 +16,8 @@ import (
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
 	"github.com/libp2p/go-libp2p/core/protocol"
 	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 	"github.com/libp2p/go-libp2p/p2p/muxer/yamux"
@@ -24,14 +29,26 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/masa-finance/masa-oracle/pkg/ad"
+	"github.com/masa-finance/masa-oracle/pkg/bridge"
 	crypto2 "github.com/masa-finance/masa-oracle/pkg/crypto"
+	"github.com/masa-finance/masa-oracle/pkg/events"
 	myNetwork "github.com/masa-finance/masa-oracle/pkg/network"
 	pubsub2 "github.com/masa-finance/masa-oracle/pkg/pubsub"
+	"github.com/masa-finance/masa-oracle/pkg/records"
+	"github.com/masa-finance/masa-oracle/pkg/signer"
+	"github.com/masa-finance/masa-oracle/pkg/staking"
+	"github.com/masa-finance/masa-oracle/pkg/webhook"
+)
+
+const (
+	keyRotationInterval = 5 * time.Minute
+	keyRotationWindow   = 24 * time.Hour
+	stakeCacheTTL       = 10 * time.Minute
 )
 
 type OracleNode struct {
 	Host          host.Host
-	PrivKey       *ecdsa.PrivateKey
+	Signer        signer.Signer
 	Protocol      protocol.ID
 	priorityAddrs multiaddr.Multiaddr
 	multiAddrs    []multiaddr.Multiaddr
@@ -39,9 +56,23 @@ type OracleNode struct {
 	Context       context.Context
 	PeerChan      chan myNetwork.PeerEvent
 	NodeTracker   *pubsub2.NodeEventTracker
+	KeyRotations  *pubsub2.KeyRotationHandler
 	PubSubManager *pubsub2.Manager
+	AdStore       *ad.Store
+	StakeCache    *staking.StakeCache
+	AdRateLimiter *ad.RateLimiter
+	Events        *events.Bus
+	Webhooks      *webhook.Notifier
+	BanList       *myNetwork.BanList
+	Capabilities  []myNetwork.Capability
 	Signature     string
-	IsStaked      bool
+	// IsStaked is whether the signer was staked when the node started, publishing checks the current stake
+	IsStaked bool
+
+	bootnodes      []multiaddr.Multiaddr
+	bootnodesMutex sync.Mutex
+	// bridgePeers are the bridges the node accepts forwarded webhooks from
+	bridgePeers map[peer.ID]bool
 }
 
 func (node *OracleNode) GetMultiAddrs() multiaddr.Multiaddr {
@@ -52,7 +83,18 @@ func (node *OracleNode) GetMultiAddrs() multiaddr.Multiaddr {
 	return node.priorityAddrs
 }
 
-func NewOracleNode(ctx context.Context, privKey crypto.PrivKey, portNbr int, useUdp, useTcp bool, isStaked bool) (*OracleNode, error) {
+// NodeOption configures the node created by NewOracleNode.
+type NodeOption func(*OracleNode)
+
+// WithSigner makes the node sign with s, like a keystore or external signer, instead of holding the node key in a
+// private key signer.
+func WithSigner(s signer.Signer) NodeOption {
+	return func(node *OracleNode) {
+		node.Signer = s
+	}
+}
+
+func NewOracleNode(ctx context.Context, privKey crypto.PrivKey, portNbr int, useUdp, useTcp bool, isStaked bool, opts ...NodeOption) (*OracleNode, error) {
 	// Start with the default scaling limits.
 	scalingLimits := rcmgr.DefaultLimits
 	concreteLimits := scalingLimits.AutoScale()
@@ -63,15 +105,44 @@ func NewOracleNode(ctx context.Context, privKey crypto.PrivKey, portNbr int, use
 		return nil, err
 	}
 
+	// the API shows the TTLs of the addresses of peers
+	peerstore, err := myNetwork.NewTTLPeerstore()
+	if err != nil {
+		return nil, err
+	}
+
+	banList, err := myNetwork.NewBanList(os.Getenv(BanListPath))
+	if err != nil {
+		return nil, err
+	}
+
+	capabilities, err := myNetwork.ParseCapabilities(os.Getenv(Capabilities))
+	if err != nil {
+		return nil, err
+	}
+
+	bridgePeers, err := bridge.ParsePeers(os.Getenv(BridgePeers))
+	if err != nil {
+		return nil, err
+	}
+
 	var addrStr []string
 	libp2pOptions := []libp2p.Option{
 		libp2p.Identity(privKey),
+		libp2p.Peerstore(peerstore),
+		libp2p.ConnectionGater(banList),
 		libp2p.ResourceManager(resourceManager),
 		libp2p.Ping(false), // disable built-in ping
 		libp2p.EnableNATService(),
 		libp2p.NATPortMap(),
 		libp2p.EnableRelay(), // Enable Circuit Relay v2 with hop
 	}
+	for _, capability := range capabilities {
+		if capability == myNetwork.CapabilityRelay {
+			// relay nodes serve the relay protocol to the peers that find them
+			libp2pOptions = append(libp2pOptions, libp2p.EnableRelayService())
+		}
+	}
 
 	securityOptions := []libp2p.Option{
 		libp2p.Security(noise.ID, noise.New),
@@ -94,26 +165,109 @@ func NewOracleNode(ctx context.Context, privKey crypto.PrivKey, portNbr int, use
 		return nil, err
 	}
 
-	subscriptionManager, err := pubsub2.NewPubSubManager(ctx, host)
-	if err != nil {
-		return nil, err
+	var managerOpts []pubsub2.ManagerOption
+	if tracePath := os.Getenv(PubSubTracePath); tracePath != "" {
+		tracer, err := newPubSubTracer(tracePath)
+		if err != nil {
+			return nil, err
+		}
+		logrus.Infof("Tracing pubsub events to %s", tracePath)
+		managerOpts = append(managerOpts, pubsub2.WithTracer(tracer))
 	}
-
-	ecdsaPrivKey, err := crypto2.Libp2pPrivateKeyToEcdsa(privKey)
+	subscriptionManager, err := pubsub2.NewPubSubManager(ctx, host, managerOpts...)
 	if err != nil {
 		return nil, err
 	}
-	return &OracleNode{
+
+	stakeCache := staking.NewStakeCache(stakeCacheTTL)
+	node := &OracleNode{
 		Host:          host,
-		PrivKey:       ecdsaPrivKey,
 		Protocol:      oracleProtocol,
 		multiAddrs:    myNetwork.GetMultiAddressesForHostQuiet(host),
 		Context:       ctx,
 		PeerChan:      make(chan myNetwork.PeerEvent),
 		NodeTracker:   pubsub2.NewNodeEventTracker(),
+		KeyRotations:  pubsub2.NewKeyRotationHandler(),
 		PubSubManager: subscriptionManager,
+		AdStore:       newAdStore(),
+		StakeCache:    stakeCache,
+		AdRateLimiter: newAdRateLimiter(stakeCache),
+		Events:        events.NewBus(events.DefaultHistorySize),
+		BanList:       banList,
+		Capabilities:  capabilities,
 		IsStaked:      isStaked,
-	}, nil
+		bridgePeers:   bridgePeers,
+	}
+	for _, opt := range opts {
+		opt(node)
+	}
+	if node.Signer == nil {
+		ecdsaPrivKey, err := crypto2.Libp2pPrivateKeyToEcdsa(privKey)
+		if err != nil {
+			return nil, err
+		}
+		node.Signer = signer.NewPrivateKeySigner(ecdsaPrivKey)
+	}
+	node.NodeTracker.IsStaked = stakeCache.IsStaked
+	node.KeyRotations.OnRotation = node.handleKeyRotation
+	node.publishEvents()
+	if webhooksFile := os.Getenv(WebhooksFile); webhooksFile != "" {
+		subscriptions, err := webhook.LoadSubscriptions(webhooksFile)
+		if err != nil {
+			return nil, err
+		}
+		node.Webhooks = webhook.NewNotifier(node.Events, subscriptions, webhook.Config{QueuePath: os.Getenv(WebhookQueuePath)})
+		logrus.Infof("Notifying %d webhooks", len(subscriptions))
+	}
+	return node, nil
+}
+
+// publishEvents publishes the changes of the node trackers and stores on the node's event bus.
+func (node *OracleNode) publishEvents() {
+	node.NodeTracker.OnNodeData = func(nodeData pubsub2.NodeData) {
+		node.Events.Publish(events.TopicNodeData, nodeData)
+	}
+	node.AdStore.OnAdd = func(ad ad.Ad) {
+		node.Events.Publish(events.TopicAd, ad)
+	}
+	node.StakeCache.OnChange = func(address string, amount *big.Int) {
+		node.Events.Publish(events.TopicStake, map[string]string{"address": address, "amount": amount.String()})
+	}
+}
+
+// newAdStore creates the ad store from the adStoreMaxSize, adStoreTTL and adStorePath environment variables.
+func newAdStore() *ad.Store {
+	maxSize, err := strconv.Atoi(os.Getenv(AdStoreMaxSize))
+	if err != nil {
+		maxSize = ad.DefaultMaxAds
+	}
+	ttl, err := time.ParseDuration(os.Getenv(AdStoreTTL))
+	if err != nil {
+		ttl = ad.DefaultAdTTL
+	}
+	return ad.NewStore(maxSize, ttl, os.Getenv(AdStorePath))
+}
+
+// newPubSubTracer creates the pubsub event tracer writing to path, rotated at pubsubTraceMaxSize megabytes keeping
+// pubsubTraceFiles old files.
+func newPubSubTracer(path string) (*pubsub2.FileTracer, error) {
+	maxSize, _ := strconv.ParseInt(os.Getenv(PubSubTraceMaxSize), 10, 64)
+	files, _ := strconv.Atoi(os.Getenv(PubSubTraceFiles))
+	return pubsub2.NewFileTracer(path, maxSize<<20, files)
+}
+
+// newAdRateLimiter creates the per publisher ad limits from the adRateLimit (ads per minute), adRateBurst,
+// adDailyQuota, adStakeUnit (whole tokens) and adMaxStakeMultiplier environment variables.
+func newAdRateLimiter(stakeCache *staking.StakeCache) *ad.RateLimiter {
+	config := ad.RateLimitConfig{}
+	config.AdsPerMinute, _ = strconv.ParseFloat(os.Getenv(AdRateLimit), 64)
+	config.Burst, _ = strconv.Atoi(os.Getenv(AdRateBurst))
+	config.DailyQuota, _ = strconv.Atoi(os.Getenv(AdDailyQuota))
+	config.MaxStakeMultiplier, _ = strconv.ParseFloat(os.Getenv(AdMaxStakeMultiplier), 64)
+	if unit, ok := new(big.Int).SetString(os.Getenv(AdStakeUnit), 10); ok {
+		config.StakeUnit = unit.Mul(unit, big.NewInt(1e18))
+	}
+	return ad.NewRateLimiter(config, stakeCache.GetStake)
 }
 
 func (node *OracleNode) Start() (err error) {
@@ -121,6 +275,7 @@ func (node *OracleNode) Start() (err error) {
 	node.Host.SetStreamHandler(node.Protocol, node.handleStream)
 	node.Host.SetStreamHandler(NodeDataSyncProtocol, node.ReceiveNodeData)
 	node.Host.SetStreamHandler(NodeGossipTopic, node.GossipNodeData)
+	node.Host.SetStreamHandler(bridge.Protocol, node.handleBridgeStream)
 
 	node.Host.Network().Notify(node.NodeTracker)
 
@@ -136,24 +291,104 @@ func (node *OracleNode) Start() (err error) {
 	if err != nil {
 		return err
 	}
+	node.bootnodesMutex.Lock()
+	node.bootnodes = bootNodeAddrs
+	node.bootnodesMutex.Unlock()
 
-	node.DHT, err = myNetwork.WithDht(node.Context, node.Host, bootNodeAddrs, oracleProtocol, masaPrefix, node.PeerChan)
+	// the /masa namespace only stores records signed by staked nodes
+	recordValidator := dht.NamespacedValidator(records.Namespace, records.NewValidator(node.StakeCache.IsStaked))
+	node.DHT, err = myNetwork.WithDht(node.Context, node.Host, bootNodeAddrs, oracleProtocol, masaPrefix, node.PeerChan, recordValidator)
 	if err != nil {
 		return err
 	}
 
+	go node.AdStore.Run(node.Context, time.Minute)
+	go node.closeEpochs(node.Context, epochDuration())
+	if node.Webhooks != nil {
+		go node.Webhooks.Run(node.Context)
+	}
+
 	go myNetwork.Discover(node.Context, node.Host, node.DHT, node.Protocol, node.GetMultiAddrs())
+	myNetwork.AdvertiseCapabilities(node.Context, node.DHT, node.Capabilities)
 
 	// Subscribe to a topics
 	err = node.PubSubManager.AddSubscription(NodeGossipTopic, node.NodeTracker)
 	if err != nil {
 		return err
 	}
-	err = node.PubSubManager.AddSubscription(AdTopic, &ad.SubscriptionHandler{})
+	adValidator := ad.NewValidator(node.StakeCache.IsStaked)
+	adValidator.RateLimiter = node.AdRateLimiter
+	adValidator.LocalPeer = node.Host.ID()
+	err = node.PubSubManager.AddValidator(AdTopic, adValidator.Validate)
+	if err != nil {
+		return err
+	}
+	err = node.PubSubManager.AddSubscription(AdTopic, ad.NewSubscriptionHandler(node.AdStore))
+	if err != nil {
+		return err
+	}
+	err = node.PubSubManager.AddSubscription(KeyRotationTopic, node.KeyRotations)
+	if err != nil {
+		return err
+	}
+	go node.announceKeyRotation()
 
 	return nil
 }
 
+// announceKeyRotation periodically publishes the pending key rotation written by `masa-node keys rotate` so that
+// peers learn about the new identity. It stops once the announcement window has passed, but announces on start up
+// and once more after an interval, when peers have connected, however old the rotation is.
+func (node *OracleNode) announceKeyRotation() {
+	rotation, err := crypto2.ReadKeyRotation(os.Getenv(KeyFileKey))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.Errorf("Error reading key rotation: %v", err)
+		}
+		return
+	}
+	if rotation.NewPeerId != node.Host.ID().String() {
+		logrus.Warnf("Ignoring key rotation for %s, it does not match this node", rotation.NewPeerId)
+		return
+	}
+	data, err := json.Marshal(rotation)
+	if err != nil {
+		logrus.Errorf("Error marshaling key rotation: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(keyRotationInterval)
+	defer ticker.Stop()
+	for announced := 0; announced < 2 || time.Since(rotation.Timestamp) < keyRotationWindow; announced++ {
+		if err := node.PubSubManager.Publish(KeyRotationTopic, data); err != nil {
+			logrus.Errorf("Error publishing key rotation: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-node.Context.Done():
+			return
+		}
+	}
+}
+
+// handleKeyRotation moves the node data and addresses of a peer that rotated its key to its new peer ID.
+func (node *OracleNode) handleKeyRotation(rotation crypto2.KeyRotation) {
+	oldPeerId, err := peer.Decode(rotation.OldPeerId)
+	if err != nil {
+		logrus.Errorf("Invalid peer ID in key rotation: %v", err)
+		return
+	}
+	newPeerId, err := peer.Decode(rotation.NewPeerId)
+	if err != nil {
+		logrus.Errorf("Invalid peer ID in key rotation: %v", err)
+		return
+	}
+	node.NodeTracker.RotateNode(oldPeerId, newPeerId, rotation.NewEthAddress)
+	if addrs := node.Host.Peerstore().Addrs(oldPeerId); len(addrs) > 0 {
+		node.Host.Peerstore().AddAddrs(newPeerId, addrs, peerstore.RecentlyConnectedAddrTTL)
+	}
+}
+
 func (node *OracleNode) handleDiscoveredPeers() {
 	for {
 		select {
@@ -0,0 +1,132 @@
+package masa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/sirupsen/logrus"
+
+	myNetwork "github.com/masa-finance/masa-oracle/pkg/network"
+)
+
+// ErrNotStarted is returned by the operations that need the DHT before the node started.
+var ErrNotStarted = errors.New("the node has not started")
+
+// ConnectPeer dials the peer of an address ending in /p2p/<peer ID>.
+func (node *OracleNode) ConnectPeer(ctx context.Context, addr multiaddr.Multiaddr) (peer.AddrInfo, error) {
+	info, err := peer.AddrInfoFromP2pAddr(addr)
+	if err != nil {
+		return peer.AddrInfo{}, err
+	}
+	if info.ID == node.Host.ID() {
+		return *info, myNetwork.ErrSelf
+	}
+	if node.BanList != nil && node.BanList.IsBanned(info.ID) {
+		return *info, fmt.Errorf("peer %s is banned", info.ID)
+	}
+	return *info, node.Host.Connect(ctx, *info)
+}
+
+// DisconnectPeer closes the connections to a peer. It may connect again, BanPeer keeps it away.
+func (node *OracleNode) DisconnectPeer(id peer.ID) error {
+	return node.Host.Network().ClosePeer(id)
+}
+
+// BanPeer bans a peer for duration, closes its connections and removes it from the routing table. A ban that can not
+// be saved is not applied and the peer stays connected.
+func (node *OracleNode) BanPeer(id peer.ID, duration time.Duration, reason string) (myNetwork.Ban, error) {
+	ban, err := node.BanList.Ban(id, duration, reason)
+	if err != nil {
+		return ban, err
+	}
+	logrus.WithFields(logrus.Fields{"peer": id, "until": ban.Expires, "reason": reason}).Warn("Banned peer")
+	if err := node.DisconnectPeer(id); err != nil {
+		logrus.Errorf("Failed to disconnect banned peer %s: %v", id, err)
+	}
+	if node.DHT != nil {
+		node.DHT.RoutingTable().RemovePeer(id)
+	}
+	return ban, nil
+}
+
+// UnbanPeer lifts the ban of a peer, reporting whether it was banned.
+func (node *OracleNode) UnbanPeer(id peer.ID) (bool, error) {
+	return node.BanList.Unban(id)
+}
+
+// Bootnodes returns the bootnodes the node joined the network through and the ones added since.
+func (node *OracleNode) Bootnodes() []multiaddr.Multiaddr {
+	node.bootnodesMutex.Lock()
+	defer node.bootnodesMutex.Unlock()
+	return append([]multiaddr.Multiaddr{}, node.bootnodes...)
+}
+
+// AddBootnode connects to a bootnode and keeps it, so RefreshDHT reconnects to it. The bootnodes added at runtime
+// are not saved, they are forgotten on restart.
+func (node *OracleNode) AddBootnode(ctx context.Context, addr multiaddr.Multiaddr) error {
+	if node.DHT == nil {
+		return ErrNotStarted
+	}
+	if err := myNetwork.ConnectBootnode(ctx, node.Host, node.DHT, addr, node.Protocol); err != nil {
+		return err
+	}
+	node.bootnodesMutex.Lock()
+	defer node.bootnodesMutex.Unlock()
+	for _, bootnode := range node.bootnodes {
+		if bootnode.Equal(addr) {
+			return nil
+		}
+	}
+	node.bootnodes = append(node.bootnodes, addr)
+	logrus.Infof("Added bootnode %s", addr)
+	return nil
+}
+
+// RemoveBootnode forgets a bootnode, reporting whether it was one. It stays connected.
+func (node *OracleNode) RemoveBootnode(addr multiaddr.Multiaddr) bool {
+	node.bootnodesMutex.Lock()
+	defer node.bootnodesMutex.Unlock()
+	for i, bootnode := range node.bootnodes {
+		if bootnode.Equal(addr) {
+			node.bootnodes = append(node.bootnodes[:i], node.bootnodes[i+1:]...)
+			logrus.Infof("Removed bootnode %s", addr)
+			return true
+		}
+	}
+	return false
+}
+
+// RefreshDHT reconnects to the bootnodes the node lost the connection to and refreshes the routing table of the
+// DHT. It returns the number of connected bootnodes.
+func (node *OracleNode) RefreshDHT(ctx context.Context) (int, error) {
+	if node.DHT == nil {
+		return 0, ErrNotStarted
+	}
+	connected := 0
+	for _, addr := range node.Bootnodes() {
+		info, err := peer.AddrInfoFromP2pAddr(addr)
+		if err != nil || info.ID == node.Host.ID() {
+			continue
+		}
+		if node.Host.Network().Connectedness(info.ID) == network.Connected {
+			connected++
+			continue
+		}
+		if err := myNetwork.ConnectBootnode(ctx, node.Host, node.DHT, addr, node.Protocol); err != nil {
+			logrus.Warnf("Failed to reconnect to bootnode %s: %v", addr, err)
+			continue
+		}
+		connected++
+	}
+	select {
+	case err := <-node.DHT.ForceRefresh():
+		return connected, err
+	case <-ctx.Done():
+		return connected, ctx.Err()
+	}
+}
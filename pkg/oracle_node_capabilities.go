@@ -0,0 +1,20 @@
+package masa
+
+import (
+	"context"
+	"time"
+
+	myNetwork "github.com/masa-finance/masa-oracle/pkg/network"
+)
+
+// probeTimeout bounds the dial of each provider probed by FindProviders.
+const probeTimeout = 10 * time.Second
+
+// FindProviders looks up at most limit nodes advertising the capability, dialing the ones the node is not connected
+// to when probe is set.
+func (node *OracleNode) FindProviders(ctx context.Context, capability myNetwork.Capability, limit int, probe bool) ([]myNetwork.Provider, error) {
+	if node.DHT == nil {
+		return nil, ErrNotStarted
+	}
+	return myNetwork.FindProviders(ctx, node.Host, node.DHT, capability, limit, probe, probeTimeout)
+}
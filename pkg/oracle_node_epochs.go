@@ -0,0 +1,44 @@
+package masa
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/masa-finance/masa-oracle/pkg/events"
+)
+
+// DefaultEpochDuration makes an epoch a UTC day, the day the daily ad quota is counted over.
+const DefaultEpochDuration = 24 * time.Hour
+
+// EpochClosed is the data of an epoch.closed event. Epochs are consecutive periods of the same length counted from
+// the Unix epoch, Epoch is the number of the closed one.
+type EpochClosed struct {
+	Epoch int64     `json:"epoch"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// epochDuration returns the epoch length set in the epochDuration environment variable, DefaultEpochDuration when it
+// is not a positive duration.
+func epochDuration() time.Duration {
+	duration, err := time.ParseDuration(os.Getenv(EpochDuration))
+	if err != nil || duration <= 0 {
+		return DefaultEpochDuration
+	}
+	return duration
+}
+
+// closeEpochs publishes an epoch.closed event at the end of every epoch of the given length until ctx is done.
+func (node *OracleNode) closeEpochs(ctx context.Context, length time.Duration) {
+	for {
+		epoch := time.Now().UnixNano() / int64(length)
+		end := time.Unix(0, (epoch+1)*int64(length))
+		select {
+		case <-time.After(time.Until(end)):
+		case <-ctx.Done():
+			return
+		}
+		node.Events.Publish(events.TopicEpochClosed, EpochClosed{Epoch: epoch, Start: end.Add(-length).UTC(), End: end.UTC()})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"math"
 
@@ -12,13 +13,27 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/sirupsen/logrus"
 
+	"github.com/masa-finance/masa-oracle/pkg/bridge"
+	"github.com/masa-finance/masa-oracle/pkg/events"
 	pubsub2 "github.com/masa-finance/masa-oracle/pkg/pubsub"
 )
 
+type peerEvent struct {
+	PeerId     peer.ID                 `json:"peerId"`
+	EthAddress string                  `json:"ethAddress"`
+	Multiaddrs []pubsub2.JSONMultiaddr `json:"multiaddrs"`
+}
+
 func (node *OracleNode) ListenToNodeTracker() {
 	for {
 		select {
 		case nodeData := <-node.NodeTracker.NodeDataChan:
+			topic := events.TopicPeerConnected
+			if nodeData.Activity == pubsub2.ActivityLeft {
+				topic = events.TopicPeerDisconnected
+			}
+			node.Events.Publish(topic, peerEvent{PeerId: nodeData.PeerId, EthAddress: nodeData.EthAddress, Multiaddrs: nodeData.Multiaddrs})
+			node.Events.Publish(events.TopicNodeData, *nodeData)
 			// Marshal the nodeData into JSON
 			jsonData, err := json.Marshal(nodeData)
 			if err != nil {
@@ -58,40 +73,21 @@ type NodeDataPage struct {
 	TotalRecords int                `json:"totalRecords"`
 }
 
+// SendNodeDataPage writes a page of PageSize node data to the stream, pages are numbered from 0.
 func (node *OracleNode) SendNodeDataPage(stream network.Stream, pageNumber int) {
-	allNodeData := node.NodeTracker.GetAllNodeData()
-	totalRecords := len(allNodeData)
-	totalPages := int(math.Ceil(float64(totalRecords) / PageSize))
-
-	startIndex := pageNumber * PageSize
-	endIndex := startIndex + PageSize
-	if endIndex > totalRecords {
-		endIndex = totalRecords
-	}
-	nodeDataPage := NodeDataPage{
-		Data:         allNodeData[startIndex:endIndex],
-		PageNumber:   pageNumber,
-		TotalPages:   totalPages,
-		TotalRecords: totalRecords,
-	}
-
-	jsonData, err := json.Marshal(nodeDataPage)
+	page, err := node.NodeTracker.Query(pubsub2.NodeQuery{Offset: pageNumber * PageSize, Limit: PageSize})
 	if err != nil {
-		logrus.Errorf("Failed to marshal NodeDataPage: %v", err)
+		logrus.Errorf("Failed to query node data: %v", err)
 		return
 	}
-
-	_, err = stream.Write(append(jsonData, '\n'))
-	if err != nil {
+	if err := writeNodeDataPage(stream, page, pageNumber); err != nil {
 		logrus.Errorf("Failed to send NodeDataPage: %v", err)
 	}
 }
 
+// SendNodeData sends all the node data to a peer over the sync protocol, following the cursors of the tracker's
+// query so nodes added or removed meanwhile do not shift the pages.
 func (node *OracleNode) SendNodeData(peerID peer.ID) {
-	allNodeData := node.NodeTracker.GetAllNodeData()
-	totalRecords := len(allNodeData)
-	totalPages := int(math.Ceil(float64(totalRecords) / float64(PageSize)))
-
 	stream, err := node.Host.NewStream(node.Context, peerID, NodeDataSyncProtocol)
 	if err != nil {
 		logrus.Errorf("Failed to open stream to %s: %v", peerID, err)
@@ -99,9 +95,36 @@ func (node *OracleNode) SendNodeData(peerID peer.ID) {
 	}
 	defer stream.Close() // Ensure the stream is closed after sending the data
 
-	for pageNumber := 0; pageNumber < totalPages; pageNumber++ {
-		node.SendNodeDataPage(stream, pageNumber)
+	query := pubsub2.NodeQuery{Limit: PageSize}
+	for pageNumber := 0; ; pageNumber++ {
+		page, err := node.NodeTracker.Query(query)
+		if err != nil {
+			logrus.Errorf("Failed to query node data: %v", err)
+			return
+		}
+		if err := writeNodeDataPage(stream, page, pageNumber); err != nil {
+			logrus.Errorf("Failed to send NodeDataPage to %s: %v", peerID, err)
+			return
+		}
+		if page.NextCursor == "" {
+			return
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func writeNodeDataPage(stream network.Stream, page pubsub2.NodePage, pageNumber int) error {
+	jsonData, err := json.Marshal(NodeDataPage{
+		Data:         page.Nodes,
+		PageNumber:   pageNumber,
+		TotalPages:   int(math.Ceil(float64(page.Total) / PageSize)),
+		TotalRecords: page.Total,
+	})
+	if err != nil {
+		return err
 	}
+	_, err = stream.Write(append(jsonData, '\n'))
+	return err
 }
 
 func (node *OracleNode) ReceiveNodeData(stream network.Stream) {
@@ -168,3 +191,31 @@ func (node *OracleNode) handleStreamData(stream network.Stream) []byte {
 	}
 	return buffer.Bytes()
 }
+
+type bridgeEvent struct {
+	From     peer.ID         `json:"from"`
+	Envelope bridge.Envelope `json:"envelope"`
+}
+
+// handleBridgeStream receives a webhook forwarded by a bridge, publishes it on the event bus and acknowledges it.
+// Only the bridges listed in bridgePeers are accepted.
+func (node *OracleNode) handleBridgeStream(stream network.Stream) {
+	defer stream.Close()
+	remotePeerID := stream.Conn().RemotePeer()
+	if !node.bridgePeers[remotePeerID] {
+		logrus.Warnf("Rejected bridge envelope from %s, it is not in %s", remotePeerID, BridgePeers)
+		_ = bridge.WriteAck(stream, "", errors.New("not an allowed bridge"))
+		return
+	}
+	envelope, err := bridge.ReadEnvelope(stream)
+	if err != nil {
+		logrus.Errorf("Invalid bridge envelope from %s: %v", remotePeerID, err)
+		_ = bridge.WriteAck(stream, envelope.ID, err)
+		return
+	}
+	logrus.WithFields(logrus.Fields{"id": envelope.ID, "route": envelope.Route, "from": remotePeerID}).Debug("Received bridge envelope")
+	node.Events.Publish(events.TopicBridge, bridgeEvent{From: remotePeerID, Envelope: envelope})
+	if err := bridge.WriteAck(stream, envelope.ID, nil); err != nil {
+		logrus.Errorf("Failed to acknowledge bridge envelope to %s: %v", remotePeerID, err)
+	}
+}
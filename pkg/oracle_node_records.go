@@ -0,0 +1,78 @@
+package masa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	kb "github.com/libp2p/go-libp2p-kbucket"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/routing"
+	"github.com/sirupsen/logrus"
+
+	"github.com/masa-finance/masa-oracle/pkg/records"
+)
+
+// ErrStaleVersion is returned when a record is put with a lower version than the stored one.
+var ErrStaleVersion = errors.New("the version is lower than the stored one")
+
+// PutRecord signs a record of the node with its key and signer and stores it on the DHT for ttl, DefaultTTL when zero. A zero
+// version is the version after the stored record's, a record replaces the stored one of the same version.
+func (node *OracleNode) PutRecord(ctx context.Context, name string, value json.RawMessage, version uint64, ttl time.Duration) (*records.Record, error) {
+	if node.DHT == nil {
+		return nil, ErrNotStarted
+	}
+	if !records.ValidName(name) {
+		return nil, records.ErrInvalidName
+	}
+	current, err := node.GetRecord(ctx, node.Host.ID(), name)
+	if err != nil && !errors.Is(err, routing.ErrNotFound) {
+		return nil, err
+	}
+	switch {
+	case current == nil:
+		if version == 0 {
+			version = 1
+		}
+	case version == 0:
+		version = current.Version + 1
+	case version < current.Version:
+		return nil, fmt.Errorf("%w %d", ErrStaleVersion, current.Version)
+	}
+	record, err := records.NewRecord(node.Host.Peerstore().PrivKey(node.Host.ID()), node.Signer, name, value, version, ttl)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	err = node.DHT.PutValue(ctx, record.Key, data)
+	if errors.Is(err, kb.ErrLookupFailure) {
+		// the record is stored locally, peers get it when they look it up
+		logrus.Warnf("No peers to store record %s on", record.Key)
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("Put record %s version %d until %s", record.Key, record.Version, record.Expires)
+	return record, nil
+}
+
+// GetRecord looks up the record name of a peer on the DHT, returning routing.ErrNotFound when there is no valid one.
+func (node *OracleNode) GetRecord(ctx context.Context, id peer.ID, name string) (*records.Record, error) {
+	if node.DHT == nil {
+		return nil, ErrNotStarted
+	}
+	if !records.ValidName(name) {
+		return nil, records.ErrInvalidName
+	}
+	data, err := node.DHT.GetValue(ctx, records.Key(id, name))
+	if err != nil {
+		return nil, err
+	}
+	return records.Unmarshal(data)
+}
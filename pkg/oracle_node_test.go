@@ -1,13 +1,17 @@
 package masa
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"encoding/hex"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/masa-finance/masa-oracle/pkg/events"
 )
 
 func TestNodeSignature(t *testing.T) {
@@ -39,3 +43,29 @@ func TestNodeSignature(t *testing.T) {
 		t.Errorf("Expected node to be a publisher, but it's not")
 	}
 }
+
+func TestCloseEpochs(t *testing.T) {
+	node := &OracleNode{Events: events.NewBus(0)}
+	sub, _ := node.Events.Subscribe([]string{events.TopicEpochClosed}, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	length := 20 * time.Millisecond
+	go node.closeEpochs(ctx, length)
+
+	var previous EpochClosed
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-sub.C:
+			closed := event.Data.(EpochClosed)
+			if closed.End.Sub(closed.Start) != length || closed.Start.UnixNano() != closed.Epoch*int64(length) {
+				t.Errorf("unexpected epoch %+v", closed)
+			}
+			if i > 0 && closed.Epoch != previous.Epoch+1 {
+				t.Errorf("epoch %d closed after %d", closed.Epoch, previous.Epoch)
+			}
+			previous = closed
+		case <-time.After(time.Second):
+			t.Fatal("no epoch closed")
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package pubsub
+
+import (
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	DefaultBufferSize = 100
+	// MaxBufferSize bounds the messages a BufferHandler keeps, clients choose the size of topics joined through the API.
+	MaxBufferSize = 10000
+)
+
+// ReceivedMessage is a message kept by a BufferHandler.
+type ReceivedMessage struct {
+	From         peer.ID   `json:"from"`
+	ReceivedFrom peer.ID   `json:"receivedFrom"`
+	Message      string    `json:"message"`
+	ReceivedAt   time.Time `json:"receivedAt"`
+}
+
+// BufferHandler is a SubscriptionHandler that keeps the most recent messages of a topic, so that topics can be
+// read without a handler of their own.
+type BufferHandler struct {
+	mutex    sync.RWMutex
+	messages []ReceivedMessage
+	next     int
+	full     bool
+}
+
+// NewBufferHandler creates a handler keeping the last size messages, DefaultBufferSize when size is not positive and
+// at most MaxBufferSize.
+func NewBufferHandler(size int) *BufferHandler {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	if size > MaxBufferSize {
+		size = MaxBufferSize
+	}
+	return &BufferHandler{messages: make([]ReceivedMessage, size)}
+}
+
+func (handler *BufferHandler) HandleMessage(msg *pubsub.Message) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	handler.messages[handler.next] = ReceivedMessage{
+		From:         msg.GetFrom(),
+		ReceivedFrom: msg.ReceivedFrom,
+		Message:      string(msg.Data),
+		ReceivedAt:   time.Now().UTC(),
+	}
+	handler.next = (handler.next + 1) % len(handler.messages)
+	if handler.next == 0 {
+		handler.full = true
+	}
+}
+
+// Messages returns up to limit of the most recent messages, oldest first. A limit that is not positive returns
+// all buffered messages.
+func (handler *BufferHandler) Messages(limit int) []ReceivedMessage {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	count := handler.next
+	if handler.full {
+		count = len(handler.messages)
+	}
+	if limit <= 0 || limit > count {
+		limit = count
+	}
+	messages := make([]ReceivedMessage, 0, limit)
+	for i := count - limit; i < count; i++ {
+		// the oldest buffered message is at next once the buffer wrapped around
+		index := i
+		if handler.full {
+			index = (handler.next + i) % len(handler.messages)
+		}
+		messages = append(messages, handler.messages[index])
+	}
+	return messages
+}
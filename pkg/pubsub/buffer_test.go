@@ -0,0 +1,32 @@
+package pubsub
+
+import (
+	"fmt"
+	"testing"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+)
+
+func TestBufferHandler(t *testing.T) {
+	handler := NewBufferHandler(3)
+	if messages := handler.Messages(0); len(messages) != 0 {
+		t.Fatalf("expected an empty buffer, got %v", messages)
+	}
+	for i := 1; i <= 5; i++ {
+		handler.HandleMessage(&pubsub.Message{Message: &pb.Message{Data: []byte(fmt.Sprint(i))}})
+	}
+	check := func(limit int, expected ...string) {
+		messages := handler.Messages(limit)
+		got := make([]string, len(messages))
+		for i, message := range messages {
+			got[i] = message.Message
+		}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("Messages(%d) = %v, expected %v", limit, got, expected)
+		}
+	}
+	check(0, "3", "4", "5")
+	check(2, "4", "5")
+	check(10, "3", "4", "5")
+}
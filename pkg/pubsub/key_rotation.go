@@ -0,0 +1,56 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"sync"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/sirupsen/logrus"
+
+	"github.com/masa-finance/masa-oracle/pkg/crypto"
+)
+
+// KeyRotationHandler keeps the verified key rotation announcements received from other nodes, keyed by old peer ID.
+type KeyRotationHandler struct {
+	// OnRotation is called with each new verified rotation, the node moves the identity of the old peer to the new one
+	OnRotation func(crypto.KeyRotation)
+	rotations  map[string]crypto.KeyRotation
+	mutex      sync.RWMutex
+}
+
+func NewKeyRotationHandler() *KeyRotationHandler {
+	return &KeyRotationHandler{
+		rotations: make(map[string]crypto.KeyRotation),
+	}
+}
+
+func (handler *KeyRotationHandler) HandleMessage(msg *pubsub.Message) {
+	var rotation crypto.KeyRotation
+	if err := json.Unmarshal(msg.Data, &rotation); err != nil {
+		logrus.Errorf("failed to unmarshal key rotation: %v", err)
+		return
+	}
+	if err := rotation.Verify(); err != nil {
+		logrus.Warnf("rejected key rotation for %s: %v", rotation.OldPeerId, err)
+		return
+	}
+	handler.mutex.Lock()
+	if existing, ok := handler.rotations[rotation.OldPeerId]; ok && !rotation.Timestamp.After(existing.Timestamp) {
+		handler.mutex.Unlock()
+		return
+	}
+	handler.rotations[rotation.OldPeerId] = rotation
+	handler.mutex.Unlock()
+	logrus.Infof("Node %s rotated its key to %s", rotation.OldPeerId, rotation.NewPeerId)
+	if handler.OnRotation != nil {
+		handler.OnRotation(rotation)
+	}
+}
+
+// GetRotation returns the latest verified key rotation announced for oldPeerId.
+func (handler *KeyRotationHandler) GetRotation(oldPeerId string) (crypto.KeyRotation, bool) {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	rotation, ok := handler.rotations[oldPeerId]
+	return rotation, ok
+}
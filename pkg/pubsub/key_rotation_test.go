@@ -0,0 +1,69 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	libp2pCrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+
+	"github.com/masa-finance/masa-oracle/pkg/crypto"
+)
+
+func TestKeyRotationMovesNodeData(t *testing.T) {
+	oldKey, _, err := libp2pCrypto.GenerateKeyPair(libp2pCrypto.Secp256k1, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, _, err := libp2pCrypto.GenerateKeyPair(libp2pCrypto.Secp256k1, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotation, err := crypto.NewKeyRotation(oldKey, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldPeerId, _ := peer.Decode(rotation.OldPeerId)
+	newPeerId, _ := peer.Decode(rotation.NewPeerId)
+
+	tracker := &NodeEventTracker{nodeData: make(map[string]*NodeData)}
+	nodeData := NewNodeData(multiaddr.StringCast("/ip4/10.0.0.1/tcp/4001"), oldPeerId, rotation.OldEthAddress, ActivityLeft)
+	nodeData.AccumulatedUptime = time.Hour
+	tracker.nodeData[oldPeerId.String()] = nodeData
+
+	handler := NewKeyRotationHandler()
+	handler.OnRotation = func(rotation crypto.KeyRotation) {
+		tracker.RotateNode(oldPeerId, newPeerId, rotation.NewEthAddress)
+	}
+	data, err := json.Marshal(rotation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.HandleMessage(&pubsub.Message{Message: &pb.Message{Data: data}})
+
+	if _, ok := handler.GetRotation(rotation.OldPeerId); !ok {
+		t.Error("expected the rotation to be kept")
+	}
+	if _, ok := tracker.GetNodeData(oldPeerId.String()); ok {
+		t.Error("expected the node data of the old peer ID to be removed")
+	}
+	moved, ok := tracker.GetNodeData(newPeerId.String())
+	if !ok {
+		t.Fatal("expected the node data to be moved to the new peer ID")
+	}
+	if moved.PeerId != newPeerId || moved.EthAddress != rotation.NewEthAddress || moved.AccumulatedUptime != time.Hour {
+		t.Errorf("unexpected moved node data %+v", moved)
+	}
+
+	forged := *rotation
+	forged.NewPeerId = oldPeerId.String()
+	data, _ = json.Marshal(forged)
+	handler.OnRotation = func(crypto.KeyRotation) {
+		t.Error("expected a forged rotation to be rejected")
+	}
+	handler.HandleMessage(&pubsub.Message{Message: &pb.Message{Data: data}})
+}
@@ -3,40 +3,96 @@ package pubsub
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/sirupsen/logrus"
 )
 
+// ErrAlreadySubscribed is returned by AddSubscription for a topic that already has a subscription.
+var ErrAlreadySubscribed = errors.New("topic is already subscribed")
+
 type SubscriptionHandler interface {
 	HandleMessage(msg *pubsub.Message)
 }
 
+// SubscriptionOption configures how the messages of a subscription are handled.
+type SubscriptionOption func(*subscription)
+
+// WithWorkers hands the messages to the handler from a pool of workers through a queue of queueSize messages.
+// Messages arriving while the queue is full are dropped, so a slow handler can not hold up the subscription.
+func WithWorkers(workers, queueSize int) SubscriptionOption {
+	return func(s *subscription) {
+		if workers < 1 {
+			workers = 1
+		}
+		if queueSize < 1 {
+			queueSize = 1
+		}
+		s.workers = workers
+		s.queue = make(chan *pubsub.Message, queueSize)
+	}
+}
+
+type subscription struct {
+	topic   string
+	sub     *pubsub.Subscription
+	handler SubscriptionHandler
+	cancel  context.CancelFunc
+	done    sync.WaitGroup
+	workers int
+	queue   chan *pubsub.Message
+	dropped atomic.Uint64
+}
+
+// Manager joins topics and dispatches their messages to handlers. It is safe for concurrent use.
 type Manager struct {
 	ctx           context.Context
+	mutex         sync.RWMutex
 	topics        map[string]*pubsub.Topic
-	subscriptions map[string]*pubsub.Subscription
-	handlers      map[string]SubscriptionHandler
+	subscriptions map[string]*subscription
 	gossipSub     *pubsub.PubSub
 	host          host.Host
+	tracer        *FileTracer
 }
 
-func NewPubSubManager(ctx context.Context, host host.Host) (*Manager, error) {
-	gossipSub, err := pubsub.NewGossipSub(ctx, host)
-	if err != nil {
-		return nil, err
+// ManagerOption configures a Manager.
+type ManagerOption func(*Manager)
+
+// WithTracer records the gossipsub events with tracer. The manager closes the tracer when it is closed.
+func WithTracer(tracer *FileTracer) ManagerOption {
+	return func(sm *Manager) {
+		sm.tracer = tracer
 	}
+}
+
+func NewPubSubManager(ctx context.Context, host host.Host, opts ...ManagerOption) (*Manager, error) {
 	manager := &Manager{
 		ctx:           ctx,
-		subscriptions: make(map[string]*pubsub.Subscription),
+		subscriptions: make(map[string]*subscription),
 		topics:        make(map[string]*pubsub.Topic),
-		handlers:      make(map[string]SubscriptionHandler),
-		gossipSub:     gossipSub,
 		host:          host,
 	}
+	for _, opt := range opts {
+		opt(manager)
+	}
+	var gossipOpts []pubsub.Option
+	if manager.tracer != nil {
+		gossipOpts = append(gossipOpts, pubsub.WithEventTracer(manager.tracer))
+	}
+	gossipSub, err := pubsub.NewGossipSub(ctx, host, gossipOpts...)
+	if err != nil {
+		return nil, err
+	}
+	manager.gossipSub = gossipSub
 	return manager, nil
 }
 
@@ -44,7 +100,11 @@ func NewPubSubManager(ctx context.Context, host host.Host) (*Manager, error) {
 func (sm *Manager) SetUpSubscriptions() {
 }
 
-func (sm *Manager) createTopic(topicName string) (*pubsub.Topic, error) {
+// joinTopic returns the topic, joining it the first time. The caller holds the lock.
+func (sm *Manager) joinTopic(topicName string) (*pubsub.Topic, error) {
+	if topic, ok := sm.topics[topicName]; ok {
+		return topic, nil
+	}
 	topic, err := sm.gossipSub.Join(topicName)
 	if err != nil {
 		return nil, err
@@ -53,8 +113,23 @@ func (sm *Manager) createTopic(topicName string) (*pubsub.Topic, error) {
 	return topic, nil
 }
 
-func (sm *Manager) AddSubscription(topicName string, handler SubscriptionHandler) error {
-	topic, err := sm.createTopic(topicName)
+// JoinTopic joins a topic to publish on it without subscribing to its messages.
+func (sm *Manager) JoinTopic(topicName string) error {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	_, err := sm.joinTopic(topicName)
+	return err
+}
+
+// AddSubscription subscribes handler to the topic, joining it if needed. It returns ErrAlreadySubscribed when the
+// topic already has a subscription, remove it first to change the handler.
+func (sm *Manager) AddSubscription(topicName string, handler SubscriptionHandler, opts ...SubscriptionOption) error {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	if _, ok := sm.subscriptions[topicName]; ok {
+		return fmt.Errorf("%w: %s", ErrAlreadySubscribed, topicName)
+	}
+	topic, err := sm.joinTopic(topicName)
 	if err != nil {
 		return err
 	}
@@ -62,63 +137,182 @@ func (sm *Manager) AddSubscription(topicName string, handler SubscriptionHandler
 	if err != nil {
 		return err
 	}
-	sm.subscriptions[topicName] = sub
-	sm.handlers[topicName] = handler
+	ctx, cancel := context.WithCancel(sm.ctx)
+	s := &subscription{topic: topicName, sub: sub, handler: handler, cancel: cancel}
+	for _, opt := range opts {
+		opt(s)
+	}
+	sm.subscriptions[topicName] = s
 
-	go func() {
-		for {
-			msg, err := sub.Next(sm.ctx)
-			if err != nil {
-				logrus.Errorf("Error reading from topic: %v", err)
-				continue
-			}
-			// Skip messages from the same node
-			if msg.ReceivedFrom == sm.host.ID() {
-				continue
+	for i := 0; i < s.workers; i++ {
+		s.done.Add(1)
+		go sm.work(s)
+	}
+	s.done.Add(1)
+	go sm.read(ctx, s)
+	return nil
+}
+
+// read receives the messages of a subscription until it is removed or the manager's context is done.
+func (sm *Manager) read(ctx context.Context, s *subscription) {
+	defer s.done.Done()
+	if s.queue != nil {
+		defer close(s.queue)
+	}
+	for {
+		msg, err := s.sub.Next(ctx)
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, pubsub.ErrSubscriptionCancelled) {
+				logrus.Errorf("Error reading from topic %s: %v", s.topic, err)
 			}
-			// Use the handler to process the message
-			handler.HandleMessage(msg)
+			return
+		}
+		// Skip messages from the same node
+		if msg.ReceivedFrom == sm.host.ID() {
+			continue
+		}
+		if s.queue == nil {
+			sm.handle(s, msg)
+			continue
+		}
+		select {
+		case s.queue <- msg:
+		default:
+			logrus.Warnf("Dropped message on topic %s, its handler is behind (%d dropped)", s.topic, s.dropped.Add(1))
+		}
+	}
+}
+
+func (sm *Manager) work(s *subscription) {
+	defer s.done.Done()
+	for msg := range s.queue {
+		sm.handle(s, msg)
+	}
+}
+
+// handle passes a message to the handler of the subscription, a panicking handler is logged and the subscription
+// carries on.
+func (sm *Manager) handle(s *subscription, msg *pubsub.Message) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Errorf("Handler of topic %s panicked: %v\n%s", s.topic, r, debug.Stack())
 		}
 	}()
+	s.handler.HandleMessage(msg)
+}
 
-	return nil
+// AddValidator registers a validator that every message on the topic, including our own, has to pass before it is
+// delivered or forwarded.
+func (sm *Manager) AddValidator(topicName string, validator pubsub.ValidatorEx) error {
+	return sm.gossipSub.RegisterTopicValidator(topicName, validator)
 }
 
+// RemoveSubscription cancels the subscription of the topic, waits for its handler to finish and leaves the topic.
 func (sm *Manager) RemoveSubscription(topic string) error {
-	sub, ok := sm.subscriptions[topic]
+	sm.mutex.Lock()
+	s, ok := sm.subscriptions[topic]
 	if !ok {
+		sm.mutex.Unlock()
 		return fmt.Errorf("no subscription for topic %s", topic)
 	}
-	// Close the subscription
-	sub.Cancel()
-
-	// Remove the subscription and handler
 	delete(sm.subscriptions, topic)
-	delete(sm.handlers, topic)
+	t := sm.topics[topic]
+	delete(sm.topics, topic)
+	sm.mutex.Unlock()
+
+	s.cancel()
+	s.sub.Cancel()
+	s.done.Wait()
+	if err := t.Close(); err != nil {
+		return fmt.Errorf("error closing topic %s: %w", topic, err)
+	}
 	return nil
 }
+
+// Close removes every subscription, leaves all topics, including the ones joined without a subscription, and closes
+// the tracer.
+func (sm *Manager) Close() error {
+	sm.mutex.RLock()
+	topics := make([]string, 0, len(sm.subscriptions))
+	for topic := range sm.subscriptions {
+		topics = append(topics, topic)
+	}
+	sm.mutex.RUnlock()
+
+	var errs []error
+	for _, topic := range topics {
+		if err := sm.RemoveSubscription(topic); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	sm.mutex.Lock()
+	joined := sm.topics
+	sm.topics = make(map[string]*pubsub.Topic)
+	sm.mutex.Unlock()
+	for name, topic := range joined {
+		if err := topic.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("error closing topic %s: %w", name, err))
+		}
+	}
+	if sm.tracer != nil {
+		if err := sm.tracer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (sm *Manager) GetSubscription(topic string) (*pubsub.Subscription, error) {
-	sub, ok := sm.subscriptions[topic]
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	s, ok := sm.subscriptions[topic]
 	if !ok {
 		return nil, fmt.Errorf("no subscription for topic %s", topic)
 	}
-	return sub, nil
+	return s.sub, nil
 }
 
 func (sm *Manager) Publish(topic string, data []byte) error {
+	sm.mutex.RLock()
 	t, ok := sm.topics[topic]
+	sm.mutex.RUnlock()
 	if !ok {
 		return fmt.Errorf("no topic named %s", topic)
 	}
 	return t.Publish(sm.ctx, data)
 }
 
+// ListTopics returns the names of the joined topics.
+func (sm *Manager) ListTopics() []string {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	topics := make([]string, 0, len(sm.topics))
+	for name := range sm.topics {
+		topics = append(topics, name)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// ListPeers returns the peers we know to be subscribed to the topic.
+func (sm *Manager) ListPeers(topic string) ([]peer.ID, error) {
+	sm.mutex.RLock()
+	t, ok := sm.topics[topic]
+	sm.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no topic named %s", topic)
+	}
+	return t.ListPeers(), nil
+}
+
 func (sm *Manager) GetHandler(topic string) (SubscriptionHandler, error) {
-	handler, ok := sm.handlers[topic]
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	s, ok := sm.subscriptions[topic]
 	if !ok {
 		return nil, fmt.Errorf("no handler for topic %s", topic)
 	}
-	return handler, nil
+	return s.handler, nil
 }
 
 func StreamConsoleTo(ctx context.Context, topic *pubsub.Topic) {
@@ -0,0 +1,111 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+type handlerFunc func(msg *pubsub.Message)
+
+func (f handlerFunc) HandleMessage(msg *pubsub.Message) { f(msg) }
+
+func newTestManager(t *testing.T, ctx context.Context) *Manager {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	manager, err := NewPubSubManager(ctx, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manager
+}
+
+func TestManagerLifecycle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	publisher := newTestManager(t, ctx)
+	receiver := newTestManager(t, ctx)
+	if err := receiver.host.Connect(ctx, peer.AddrInfo{ID: publisher.host.ID(), Addrs: publisher.host.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+
+	const topic = "test"
+	var handled atomic.Int32
+	received := make(chan struct{}, 1)
+	// the single worker has to survive the panics
+	if err := receiver.AddSubscription(topic, handlerFunc(func(msg *pubsub.Message) {
+		if string(msg.Data) == "ok" {
+			handled.Add(1)
+			select {
+			case received <- struct{}{}:
+			default:
+			}
+			return
+		}
+		panic("handler failure")
+	}), WithWorkers(1, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := receiver.AddSubscription(topic, NewBufferHandler(1)); !errors.Is(err, ErrAlreadySubscribed) {
+		t.Errorf("expected a second subscription to be refused, got %v", err)
+	}
+	if err := publisher.AddSubscription(topic, NewBufferHandler(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// wait for the peers to see each other on the topic
+	deadline := time.Now().Add(10 * time.Second)
+	for peers, _ := publisher.ListPeers(topic); len(peers) == 0; peers, _ = publisher.ListPeers(topic) {
+		if time.Now().After(deadline) {
+			t.Fatal("peers did not meet on the topic")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	// the mesh forms on the next heartbeat, publish until the message arrives
+	for delivered := false; !delivered; {
+		for _, data := range []string{"panic", "ok"} {
+			if err := publisher.Publish(topic, []byte(data)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		select {
+		case <-received:
+			delivered = true
+		case <-time.After(200 * time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatal("expected the message after a panicking handler to be handled")
+			}
+		}
+	}
+
+	if err := receiver.RemoveSubscription(topic); err != nil {
+		t.Fatal(err)
+	}
+	if topics := receiver.ListTopics(); len(topics) != 0 {
+		t.Errorf("expected the topic to be left, got %v", topics)
+	}
+	if err := receiver.AddSubscription(topic, NewBufferHandler(1)); err != nil {
+		t.Errorf("expected the topic to be joined again, got %v", err)
+	}
+	if err := receiver.JoinTopic("joined"); err != nil {
+		t.Fatal(err)
+	}
+	if err := receiver.Close(); err != nil {
+		t.Error(err)
+	}
+	if topics := receiver.ListTopics(); len(topics) != 0 {
+		t.Errorf("expected every topic to be left on close, got %v", topics)
+	}
+	if handled.Load() == 0 {
+		t.Error("expected messages to be handled")
+	}
+}
@@ -3,6 +3,7 @@ package pubsub
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -114,3 +115,29 @@ func (n *NodeData) UpdateAccumulatedUptime() {
 		n.AccumulatedUptime += time.Since(n.LastJoined)
 	}
 }
+
+// LastSeen returns now for an active node, and otherwise the last time it left or was updated.
+func (n *NodeData) LastSeen(now time.Time) time.Time {
+	if n.IsActive {
+		return now
+	}
+	if n.LastLeft.After(n.LastUpdated) {
+		return n.LastLeft
+	}
+	return n.LastUpdated
+}
+
+// HasTransport reports whether one of the multiaddresses of the node uses the protocol, case insensitive.
+func (n *NodeData) HasTransport(transport string) bool {
+	for _, addr := range n.Multiaddrs {
+		if addr.Multiaddr == nil {
+			continue
+		}
+		for _, protocol := range addr.Protocols() {
+			if strings.EqualFold(protocol.Name, transport) {
+				return true
+			}
+		}
+	}
+	return false
+}
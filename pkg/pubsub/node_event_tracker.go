@@ -19,9 +19,13 @@ import (
 
 type NodeEventTracker struct {
 	NodeDataChan chan *NodeData
-	nodeData     map[string]*NodeData
-	dataMutex    sync.RWMutex
-	changes      int
+	// OnNodeData is called with the node data received from other nodes once it is merged
+	OnNodeData func(NodeData)
+	// IsStaked checks the stake of an ETH address for the staked filter of Query
+	IsStaked  func(ethAddress string) (bool, error)
+	nodeData  map[string]*NodeData
+	dataMutex sync.RWMutex
+	changes   int
 }
 
 func NewNodeEventTracker() *NodeEventTracker {
@@ -86,8 +90,8 @@ func (net *NodeEventTracker) Connected(n network.Network, c network.Conn) {
 			nodeData.Multiaddrs = append(nodeData.Multiaddrs, JSONMultiaddr{c.RemoteMultiaddr()})
 		}
 	}
-	net.NodeDataChan <- nodeData
 	nodeData.Joined()
+	net.NodeDataChan <- nodeData
 }
 
 func (net *NodeEventTracker) Disconnected(n network.Network, c network.Conn) {
@@ -108,8 +112,8 @@ func (net *NodeEventTracker) Disconnected(n network.Network, c network.Conn) {
 		logrus.Warnf("Node data does not exist for disconnected node: %s", peerID)
 		nodeData = NewNodeData(c.RemoteMultiaddr(), c.RemotePeer(), pubKeyHex, ActivityLeft)
 	}
-	net.NodeDataChan <- nodeData
 	nodeData.Left()
+	net.NodeDataChan <- nodeData
 
 	net.dataMutex.Unlock()
 }
@@ -137,6 +141,9 @@ func (net *NodeEventTracker) HandleNodeData(data NodeData) {
 		// Otherwise, add it
 		logrus.Debugf("Adding new node data: %s", data.PeerId.String())
 		net.nodeData[data.PeerId.String()] = &data
+		if net.OnNodeData != nil {
+			net.OnNodeData(data)
+		}
 		return
 	}
 	// Handle discrepancies for existing nodes
@@ -148,10 +155,41 @@ func (net *NodeEventTracker) HandleNodeData(data NodeData) {
 	}
 	// Update accumulated uptime
 	//existingData.AccumulatedUptime = existingData.GetAccumulatedUptime()
+	if net.OnNodeData != nil {
+		net.OnNodeData(*existingData)
+	}
+}
+
+// RotateNode moves the node data of oldPeerId to newPeerId after the node rotated its key. When the node already
+// joined with its new identity, the uptime accumulated under the old one is added to it.
+func (net *NodeEventTracker) RotateNode(oldPeerId, newPeerId peer.ID, newEthAddress string) {
+	net.dataMutex.Lock()
+	defer net.dataMutex.Unlock()
+	oldData, ok := net.nodeData[oldPeerId.String()]
+	if !ok {
+		return
+	}
+	delete(net.nodeData, oldPeerId.String())
+	newData, ok := net.nodeData[newPeerId.String()]
+	if ok {
+		newData.AccumulatedUptime += oldData.GetAccumulatedUptime()
+	} else {
+		newData = oldData
+		newData.PeerId = newPeerId
+		net.nodeData[newPeerId.String()] = newData
+	}
+	newData.EthAddress = newEthAddress
+	newData.LastUpdated = time.Now()
+	logrus.Infof("Moved node data of %s to %s", oldPeerId, newPeerId)
+	if net.OnNodeData != nil {
+		net.OnNodeData(*newData)
+	}
 }
 
 func (net *NodeEventTracker) GetAllNodeData() []NodeData {
 	logrus.Debug("Getting all node data")
+	net.dataMutex.RLock()
+	defer net.dataMutex.RUnlock()
 	// Convert the map to a slice
 	nodeDataSlice := make([]NodeData, 0, len(net.nodeData))
 	for _, nodeData := range net.nodeData {
@@ -170,6 +208,22 @@ func (net *NodeEventTracker) GetAllNodeData() []NodeData {
 	return nodeDataSlice
 }
 
+// GetNodeData returns the node data of a peer with its current uptimes.
+func (net *NodeEventTracker) GetNodeData(peerID string) (NodeData, bool) {
+	net.dataMutex.RLock()
+	defer net.dataMutex.RUnlock()
+	nodeData, ok := net.nodeData[peerID]
+	if !ok {
+		return NodeData{}, false
+	}
+	nd := *nodeData
+	nd.CurrentUptime = nodeData.GetCurrentUptime()
+	nd.AccumulatedUptime = nodeData.GetAccumulatedUptime()
+	nd.CurrentUptimeStr = prettyDuration(nd.CurrentUptime)
+	nd.AccumulatedUptimeStr = prettyDuration(nd.AccumulatedUptime)
+	return nd, true
+}
+
 func (net *NodeEventTracker) DumpNodeData() {
 	// Lock the nodeData map for concurrent read
 	net.dataMutex.RLock()
@@ -0,0 +1,205 @@
+package pubsub
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The fields node data can be sorted by, named like their JSON fields.
+const (
+	SortNodesByLastUpdated       = "lastUpdated"
+	SortNodesByLastJoined        = "lastJoined"
+	SortNodesByLastLeft          = "lastLeft"
+	SortNodesByCurrentUptime     = "currentUptime"
+	SortNodesByAccumulatedUptime = "accumulatedUptime"
+	SortNodesByPeerId            = "peerId"
+	SortNodesByEthAddress        = "ethAddress"
+	SortNodesByIsActive          = "isActive"
+)
+
+// NodeQuery selects node data from the tracker. Empty fields do not filter.
+type NodeQuery struct {
+	// Active matches nodes that are, or are not, currently connected
+	Active *bool
+	// Staked matches nodes whose ETH address has, or has not, a stake. It needs the tracker's IsStaked.
+	Staked *bool
+	// EthAddress matches the ETH address of the node, case insensitive
+	EthAddress string
+	// MinAccumulatedUptime matches nodes that were up at least this long in total
+	MinAccumulatedUptime time.Duration
+	// LastSeenSince matches nodes that are active or left after this time
+	LastSeenSince time.Time
+	// Transport matches nodes with a multiaddress using the protocol, like tcp, udp, quic-v1 or ws
+	Transport string
+	// SortBy is one of the SortNodesBy constants, SortNodesByLastUpdated by default
+	SortBy     string
+	Descending bool
+	// Cursor continues after the last node of a previous page, Offset skips nodes when there is no cursor
+	Cursor string
+	Offset int
+	Limit  int
+}
+
+// NodePage is one page of node data query results. NextCursor is empty on the last page.
+type NodePage struct {
+	Nodes      []NodeData
+	NextCursor string
+	Total      int
+}
+
+type nodeCursor struct {
+	Key    string `json:"k"`
+	PeerId string `json:"p"`
+}
+
+// Query returns the node data matching q, sorted and paginated. The uptimes are those at the time of the query, so
+// a cursor on an uptime continues from the uptime the last node had then.
+func (net *NodeEventTracker) Query(q NodeQuery) (NodePage, error) {
+	keyFn, err := nodeSortKey(q.SortBy)
+	if err != nil {
+		return NodePage{}, err
+	}
+	if q.Staked != nil && net.IsStaked == nil {
+		return NodePage{}, errors.New("this node does not check stakes, cannot filter on staked")
+	}
+	var after *nodeCursor
+	if q.Cursor != "" {
+		after, err = decodeNodeCursor(q.Cursor)
+		if err != nil {
+			return NodePage{}, err
+		}
+	}
+
+	now := time.Now()
+	matches := make([]NodeData, 0)
+	for _, nodeData := range net.GetAllNodeData() {
+		if q.matches(nodeData, now) && net.matchesStake(q, nodeData) {
+			matches = append(matches, nodeData)
+		}
+	}
+	less := func(keyA, idA, keyB, idB string) bool {
+		if q.Descending {
+			keyA, idA, keyB, idB = keyB, idB, keyA, idA
+		}
+		if keyA != keyB {
+			return keyA < keyB
+		}
+		return idA < idB
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return less(keyFn(matches[i]), matches[i].PeerId.String(), keyFn(matches[j]), matches[j].PeerId.String())
+	})
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(matches), func(i int) bool {
+			return less(after.Key, after.PeerId, keyFn(matches[i]), matches[i].PeerId.String())
+		})
+	} else if q.Offset > 0 {
+		start = q.Offset
+	}
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := len(matches)
+	if q.Limit > 0 && start+q.Limit < end {
+		end = start + q.Limit
+	}
+	page := NodePage{
+		Nodes: matches[start:end],
+		Total: len(matches),
+	}
+	if end < len(matches) {
+		last := matches[end-1]
+		page.NextCursor = encodeNodeCursor(nodeCursor{Key: keyFn(last), PeerId: last.PeerId.String()})
+	}
+	return page, nil
+}
+
+// matches reports whether the node data passes the filters of the query that do not need the stake.
+func (q *NodeQuery) matches(nodeData NodeData, now time.Time) bool {
+	if q.Active != nil && nodeData.IsActive != *q.Active {
+		return false
+	}
+	if q.EthAddress != "" && !strings.EqualFold(q.EthAddress, nodeData.EthAddress) {
+		return false
+	}
+	if nodeData.AccumulatedUptime < q.MinAccumulatedUptime {
+		return false
+	}
+	if !q.LastSeenSince.IsZero() && nodeData.LastSeen(now).Before(q.LastSeenSince) {
+		return false
+	}
+	if q.Transport != "" && !nodeData.HasTransport(q.Transport) {
+		return false
+	}
+	return true
+}
+
+func (net *NodeEventTracker) matchesStake(q NodeQuery, nodeData NodeData) bool {
+	if q.Staked == nil {
+		return true
+	}
+	staked := false
+	if nodeData.EthAddress != "" {
+		var err error
+		staked, err = net.IsStaked(nodeData.EthAddress)
+		if err != nil {
+			// a node whose stake cannot be checked does not count as staked
+			staked = false
+		}
+	}
+	return staked == *q.Staked
+}
+
+// nodeSortKey returns a function mapping node data to a string that sorts in the requested order.
+func nodeSortKey(sortBy string) (func(NodeData) string, error) {
+	// fixed width so the formatted times and durations sort lexically
+	const sortableTime = "2006-01-02T15:04:05.000000000Z"
+	sortableDuration := func(d time.Duration) string { return fmt.Sprintf("%020d", int64(d)) }
+	switch sortBy {
+	case "", SortNodesByLastUpdated:
+		return func(n NodeData) string { return n.LastUpdated.UTC().Format(sortableTime) }, nil
+	case SortNodesByLastJoined:
+		return func(n NodeData) string { return n.LastJoined.UTC().Format(sortableTime) }, nil
+	case SortNodesByLastLeft:
+		return func(n NodeData) string { return n.LastLeft.UTC().Format(sortableTime) }, nil
+	case SortNodesByCurrentUptime:
+		return func(n NodeData) string { return sortableDuration(n.CurrentUptime) }, nil
+	case SortNodesByAccumulatedUptime:
+		return func(n NodeData) string { return sortableDuration(n.AccumulatedUptime) }, nil
+	case SortNodesByPeerId:
+		return func(n NodeData) string { return n.PeerId.String() }, nil
+	case SortNodesByEthAddress:
+		return func(n NodeData) string { return strings.ToLower(n.EthAddress) }, nil
+	case SortNodesByIsActive:
+		return func(n NodeData) string { return fmt.Sprint(n.IsActive) }, nil
+	default:
+		return nil, fmt.Errorf("cannot sort by %q, expected one of %s", sortBy, strings.Join([]string{
+			SortNodesByLastUpdated, SortNodesByLastJoined, SortNodesByLastLeft, SortNodesByCurrentUptime,
+			SortNodesByAccumulatedUptime, SortNodesByPeerId, SortNodesByEthAddress, SortNodesByIsActive,
+		}, ", "))
+	}
+}
+
+func encodeNodeCursor(c nodeCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeNodeCursor(s string) (*nodeCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var c nodeCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &c, nil
+}
@@ -0,0 +1,89 @@
+package pubsub
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+func TestNodeQueryFilterSortAndPaginate(t *testing.T) {
+	tracker := &NodeEventTracker{nodeData: make(map[string]*NodeData)}
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		addr := multiaddr.StringCast(fmt.Sprintf("/ip4/10.0.0.%d/tcp/4001", i))
+		if i%3 == 0 {
+			addr = multiaddr.StringCast(fmt.Sprintf("/ip4/10.0.0.%d/udp/4001/quic-v1", i))
+		}
+		nodeData := NewNodeData(addr, peer.ID(fmt.Sprintf("peer-%d", i)), fmt.Sprintf("0xAB%d", i), ActivityLeft)
+		nodeData.AccumulatedUptime = time.Duration(i) * time.Hour
+		nodeData.LastLeft = now.Add(-time.Duration(i) * time.Minute)
+		nodeData.LastUpdated = nodeData.LastLeft
+		if i%2 == 0 {
+			nodeData.Activity = ActivityJoined
+			nodeData.IsActive = true
+			nodeData.LastJoined = now
+		}
+		tracker.nodeData[nodeData.PeerId.String()] = nodeData
+	}
+
+	active := true
+	query := NodeQuery{Active: &active, MinAccumulatedUptime: 2 * time.Hour, SortBy: SortNodesByAccumulatedUptime, Descending: true, Limit: 2}
+	var peers []string
+	for {
+		page, err := tracker.Query(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 4 {
+			t.Fatalf("expected 4 matching nodes, got %d", page.Total)
+		}
+		for _, nodeData := range page.Nodes {
+			peers = append(peers, string(nodeData.PeerId))
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	expected := []string{"peer-8", "peer-6", "peer-4", "peer-2"}
+	if fmt.Sprint(peers) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, peers)
+	}
+
+	page, err := tracker.Query(NodeQuery{Transport: "QUIC-V1", LastSeenSince: now.Add(-5 * time.Minute), SortBy: SortNodesByPeerId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// peer-0 and peer-6 are active, peer-3 left 3 minutes ago and peer-9 too long ago
+	if page.Total != 3 || string(page.Nodes[0].PeerId) != "peer-0" || string(page.Nodes[1].PeerId) != "peer-3" {
+		t.Errorf("expected the transport and last seen filters to match peer-0, peer-3 and peer-6, got %v", page.Nodes)
+	}
+
+	page, err = tracker.Query(NodeQuery{EthAddress: "0xab7", Offset: 0, Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Nodes) != 1 || page.Nodes[0].EthAddress != "0xAB7" {
+		t.Errorf("expected the ETH address filter to match one node, got %v", page.Nodes)
+	}
+	page, err = tracker.Query(NodeQuery{Offset: 20, Limit: 5})
+	if err != nil || len(page.Nodes) != 0 || page.Total != 10 {
+		t.Errorf("expected an empty page past the end, got %v, %v", page, err)
+	}
+
+	staked := true
+	if _, err := tracker.Query(NodeQuery{Staked: &staked}); err == nil {
+		t.Error("expected the staked filter to need a stake check")
+	}
+	tracker.IsStaked = func(address string) (bool, error) { return address == "0xAB1", nil }
+	page, err = tracker.Query(NodeQuery{Staked: &staked})
+	if err != nil || page.Total != 1 {
+		t.Errorf("expected one staked node, got %v, %v", page, err)
+	}
+	if _, err := tracker.Query(NodeQuery{SortBy: "multiaddrs"}); err == nil {
+		t.Error("expected an unknown sort field to be rejected")
+	}
+}
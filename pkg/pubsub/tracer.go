@@ -0,0 +1,254 @@
+package pubsub
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/sirupsen/logrus"
+)
+
+// Trace event types recorded by the FileTracer.
+const (
+	TracePublish   = "publish"
+	TraceDeliver   = "deliver"
+	TraceReject    = "reject"
+	TraceDuplicate = "duplicate"
+	TraceGraft     = "graft"
+	TracePrune     = "prune"
+)
+
+const (
+	DefaultTraceFileSize  = 100 << 20
+	DefaultTraceFileCount = 5
+	traceBuffer           = 4096
+	// traceRetryDelay is how long the tracer waits to retry a failed rotation or reopening of the trace file
+	traceRetryDelay = time.Minute
+)
+
+// TraceRecord is one line of a trace file.
+type TraceRecord struct {
+	Type string `json:"type"`
+	// Peer is the node that recorded the event
+	Peer string `json:"peer"`
+	// Timestamp is in nanoseconds since the epoch
+	Timestamp    int64  `json:"timestamp"`
+	Topic        string `json:"topic,omitempty"`
+	MessageID    string `json:"messageId,omitempty"`
+	ReceivedFrom string `json:"receivedFrom,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+	// Remote is the peer grafted or pruned
+	Remote string `json:"remote,omitempty"`
+}
+
+// FileTracer is a gossipsub event tracer writing the publish, deliver, reject, duplicate, graft and prune events to
+// a JSON lines file. The file is rotated once it reaches maxSize, keeping maxFiles old files as path.1, path.2 and so
+// on. Events are written in the background and dropped when the writer falls behind, tracing never slows gossip.
+// When rotating fails the tracer keeps appending to path and retries later.
+type FileTracer struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	records  chan TraceRecord
+	dropped  atomic.Uint64
+	file     *os.File
+	writer   *bufio.Writer
+	size     int64
+	// retryAt is when a failed rotation or reopening of the file is tried again
+	retryAt time.Time
+	done    sync.WaitGroup
+	mutex   sync.RWMutex
+	closed  bool
+}
+
+// NewFileTracer opens the trace file at path for appending. Zero sizes select the defaults.
+func NewFileTracer(path string, maxSize int64, maxFiles int) (*FileTracer, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultTraceFileSize
+	}
+	if maxFiles <= 0 {
+		maxFiles = DefaultTraceFileCount
+	}
+	tracer := &FileTracer{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+		records:  make(chan TraceRecord, traceBuffer),
+	}
+	if err := tracer.open(); err != nil {
+		return nil, err
+	}
+	tracer.done.Add(1)
+	go tracer.write()
+	return tracer, nil
+}
+
+// Trace implements pubsub.EventTracer.
+func (t *FileTracer) Trace(evt *pb.TraceEvent) {
+	record, ok := newTraceRecord(evt)
+	if !ok {
+		return
+	}
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if t.closed {
+		return
+	}
+	select {
+	case t.records <- record:
+	default:
+		if dropped := t.dropped.Add(1); dropped%1000 == 1 {
+			logrus.Warnf("Pubsub tracer is behind, %d events dropped", dropped)
+		}
+	}
+}
+
+// Close writes the pending events and closes the trace file.
+func (t *FileTracer) Close() error {
+	t.mutex.Lock()
+	if t.closed {
+		t.mutex.Unlock()
+		return nil
+	}
+	t.closed = true
+	close(t.records)
+	t.mutex.Unlock()
+	t.done.Wait()
+	if t.file == nil {
+		return nil
+	}
+	return t.file.Close()
+}
+
+func (t *FileTracer) open() error {
+	file, err := os.OpenFile(t.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	t.file = file
+	t.writer = bufio.NewWriter(file)
+	t.size = info.Size()
+	return nil
+}
+
+func (t *FileTracer) write() {
+	defer t.done.Done()
+	for record := range t.records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			continue
+		}
+		line = append(line, '\n')
+		retry := !time.Now().Before(t.retryAt)
+		if t.file != nil && t.size+int64(len(line)) > t.maxSize && t.size > 0 && retry {
+			if err := t.rotate(); err != nil {
+				logrus.Errorf("Error rotating pubsub trace file, retrying in %s: %v", traceRetryDelay, err)
+				t.retryAt = time.Now().Add(traceRetryDelay)
+			}
+		} else if t.file == nil && retry {
+			if err := t.open(); err != nil {
+				logrus.Errorf("Error reopening pubsub trace file, retrying in %s: %v", traceRetryDelay, err)
+				t.retryAt = time.Now().Add(traceRetryDelay)
+			}
+		}
+		if t.file == nil {
+			t.dropped.Add(1)
+			continue
+		}
+		n, err := t.writer.Write(line)
+		t.size += int64(n)
+		if err != nil {
+			logrus.Errorf("Error writing pubsub trace: %v", err)
+		}
+		// flush when idle so the file is current while the node runs
+		if len(t.records) == 0 {
+			_ = t.writer.Flush()
+		}
+	}
+	if t.file != nil {
+		_ = t.writer.Flush()
+	}
+}
+
+// rotate shifts path.N to path.N+1, dropping the oldest file, and starts a new file at path. When the files could
+// not be shifted the tracer appends to the file at path again, when that file can not be opened either it has no file
+// until write reopens it.
+func (t *FileTracer) rotate() error {
+	if err := t.writer.Flush(); err != nil {
+		return err
+	}
+	err := t.file.Close()
+	if err == nil {
+		err = t.shift()
+	}
+	if openErr := t.open(); openErr != nil {
+		t.file, t.writer = nil, nil
+		return errors.Join(err, openErr)
+	}
+	return err
+}
+
+// shift renames path.N to path.N+1 and path to path.1.
+func (t *FileTracer) shift() error {
+	for i := t.maxFiles - 1; i > 0; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", t.path, i), fmt.Sprintf("%s.%d", t.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(t.path, t.path+".1")
+}
+
+func newTraceRecord(evt *pb.TraceEvent) (TraceRecord, bool) {
+	record := TraceRecord{Peer: peerString(evt.GetPeerID()), Timestamp: evt.GetTimestamp()}
+	switch evt.GetType() {
+	case pb.TraceEvent_PUBLISH_MESSAGE:
+		m := evt.GetPublishMessage()
+		record.Type, record.Topic, record.MessageID = TracePublish, m.GetTopic(), hex.EncodeToString(m.GetMessageID())
+	case pb.TraceEvent_DELIVER_MESSAGE:
+		m := evt.GetDeliverMessage()
+		record.Type, record.Topic, record.MessageID = TraceDeliver, m.GetTopic(), hex.EncodeToString(m.GetMessageID())
+		record.ReceivedFrom = peerString(m.GetReceivedFrom())
+	case pb.TraceEvent_REJECT_MESSAGE:
+		m := evt.GetRejectMessage()
+		record.Type, record.Topic, record.MessageID = TraceReject, m.GetTopic(), hex.EncodeToString(m.GetMessageID())
+		record.ReceivedFrom, record.Reason = peerString(m.GetReceivedFrom()), m.GetReason()
+	case pb.TraceEvent_DUPLICATE_MESSAGE:
+		m := evt.GetDuplicateMessage()
+		record.Type, record.Topic, record.MessageID = TraceDuplicate, m.GetTopic(), hex.EncodeToString(m.GetMessageID())
+		record.ReceivedFrom = peerString(m.GetReceivedFrom())
+	case pb.TraceEvent_GRAFT:
+		m := evt.GetGraft()
+		record.Type, record.Topic, record.Remote = TraceGraft, m.GetTopic(), peerString(m.GetPeerID())
+	case pb.TraceEvent_PRUNE:
+		m := evt.GetPrune()
+		record.Type, record.Topic, record.Remote = TracePrune, m.GetTopic(), peerString(m.GetPeerID())
+	default:
+		return record, false
+	}
+	return record, true
+}
+
+func peerString(id []byte) string {
+	if len(id) == 0 {
+		return ""
+	}
+	peerId, err := peer.IDFromBytes(id)
+	if err != nil {
+		return hex.EncodeToString(id)
+	}
+	return peerId.String()
+}
@@ -0,0 +1,96 @@
+package pubsub
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+)
+
+func readTraceFile(t *testing.T, path string) []TraceRecord {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []TraceRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record TraceRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestFileTracer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	tracer, err := NewFileTracer(path, 200, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	topic := "test"
+	publish := pb.TraceEvent_PUBLISH_MESSAGE
+	ignored := pb.TraceEvent_ADD_PEER
+	for i := int64(0); i < 10; i++ {
+		timestamp := i
+		tracer.Trace(&pb.TraceEvent{
+			Type:           &publish,
+			Timestamp:      &timestamp,
+			PublishMessage: &pb.TraceEvent_PublishMessage{MessageID: []byte{byte(i)}, Topic: &topic},
+		})
+		tracer.Trace(&pb.TraceEvent{Type: &ignored, Timestamp: &timestamp})
+	}
+	if err := tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// events traced after closing are ignored
+	tracer.Trace(&pb.TraceEvent{Type: &publish, PublishMessage: &pb.TraceEvent_PublishMessage{Topic: &topic}})
+
+	current := readTraceFile(t, path)
+	if len(current) == 0 || current[len(current)-1].Timestamp != 9 {
+		t.Fatalf("expected the latest events in the trace file, got %+v", current)
+	}
+	if record := current[0]; record.Type != TracePublish || record.Topic != topic || record.MessageID == "" {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if _, err := os.Stat(path + ".2"); err != nil {
+		t.Errorf("expected two rotated files: %v", err)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("expected the oldest trace file to be removed")
+	}
+}
+
+func TestFileTracerKeepsWritingWhenRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	// a directory that is not empty can not be replaced by the rotated file
+	if err := os.MkdirAll(filepath.Join(path+".1", "keep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	tracer, err := NewFileTracer(path, 200, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	topic := "test"
+	publish := pb.TraceEvent_PUBLISH_MESSAGE
+	for i := int64(0); i < 10; i++ {
+		timestamp := i
+		tracer.Trace(&pb.TraceEvent{
+			Type:           &publish,
+			Timestamp:      &timestamp,
+			PublishMessage: &pb.TraceEvent_PublishMessage{MessageID: []byte{byte(i)}, Topic: &topic},
+		})
+	}
+	if err := tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if records := readTraceFile(t, path); len(records) != 10 {
+		t.Errorf("expected the 10 events in the trace file after the failed rotation, got %d", len(records))
+	}
+}
@@ -0,0 +1,176 @@
+package records
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/masa-finance/masa-oracle/pkg/signer"
+)
+
+const (
+	// Namespace is the DHT namespace of the records, their keys are /masa/<peer ID>/<name>
+	Namespace = "masa"
+	// DefaultTTL is the lifetime of records put without one
+	DefaultTTL = 24 * time.Hour
+	// MaxTTL is the longest lifetime of a record, the DHT forgets records 36 hours after it received them
+	MaxTTL = 36 * time.Hour
+	// MaxValueSize is the largest value, in bytes, of a record
+	MaxValueSize = 8 << 10
+	// MaxClockSkew is how far in the future the creation time of a record may be
+	MaxClockSkew = 5 * time.Minute
+)
+
+var (
+	ErrInvalidName = errors.New("record names are 1 to 64 letters, digits, dots, dashes and underscores")
+	ErrExpired     = errors.New("the record has expired")
+	ErrNotStaked   = errors.New("the record is not signed by a staked node")
+	namePattern    = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+)
+
+// Record is a JSON value a node stores on the DHT under its own peer ID. It is signed with the node key, so only
+// that node can write its records, and by the node's signer, whose address must be staked. The version decides which
+// record wins when peers return different ones.
+type Record struct {
+	Key       string          `json:"key"`
+	Value     json.RawMessage `json:"value"`
+	Version   uint64          `json:"version"`
+	Created   time.Time       `json:"created"`
+	Expires   time.Time       `json:"expires"`
+	Signer    string          `json:"signer"`
+	Signature []byte          `json:"signature"`
+	// SignerSignature is the personal_sign signature of the record by Signer
+	SignerSignature []byte `json:"signerSignature"`
+}
+
+// Key returns the DHT key of the record name of a peer.
+func Key(id peer.ID, name string) string {
+	return "/" + Namespace + "/" + id.String() + "/" + name
+}
+
+// ParseKey returns the peer and the record name of a DHT key.
+func ParseKey(key string) (peer.ID, string, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 4 || parts[0] != "" || parts[1] != Namespace {
+		return "", "", fmt.Errorf("invalid record key %q", key)
+	}
+	id, err := peer.Decode(parts[2])
+	if err != nil {
+		return "", "", fmt.Errorf("invalid peer ID in record key: %w", err)
+	}
+	if !namePattern.MatchString(parts[3]) {
+		return "", "", ErrInvalidName
+	}
+	return id, parts[3], nil
+}
+
+// ValidName reports whether name can name a record.
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// NewRecord creates the record name of the node with privKey and signs it with privKey and s. A zero ttl is
+// DefaultTTL.
+func NewRecord(privKey crypto.PrivKey, s signer.Signer, name string, value json.RawMessage, version uint64, ttl time.Duration) (*Record, error) {
+	if !ValidName(name) {
+		return nil, ErrInvalidName
+	}
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if ttl < 0 || ttl > MaxTTL {
+		return nil, fmt.Errorf("the ttl must be positive and at most %s", MaxTTL)
+	}
+	id, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	record := &Record{
+		Key:     Key(id, name),
+		Value:   value,
+		Version: version,
+		Created: now,
+		Expires: now.Add(ttl),
+		Signer:  s.Address().Hex(),
+	}
+	if err := record.checkValue(); err != nil {
+		return nil, err
+	}
+	payload, err := record.signingPayload()
+	if err != nil {
+		return nil, err
+	}
+	record.Signature, err = privKey.Sign(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign record: %w", err)
+	}
+	record.SignerSignature, err = s.SignText(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign record: %w", err)
+	}
+	return record, nil
+}
+
+// Verify checks that the record was signed by the peer of its key and by its signer.
+func (r *Record) Verify() error {
+	id, _, err := ParseKey(r.Key)
+	if err != nil {
+		return err
+	}
+	if err := r.checkValue(); err != nil {
+		return err
+	}
+	if !common.IsHexAddress(r.Signer) {
+		return fmt.Errorf("invalid signer address %q", r.Signer)
+	}
+	pubKey, err := id.ExtractPublicKey()
+	if err != nil {
+		return fmt.Errorf("no public key in peer ID %s: %w", id, err)
+	}
+	payload, err := r.signingPayload()
+	if err != nil {
+		return err
+	}
+	if ok, err := pubKey.Verify(payload, r.Signature); err != nil || !ok {
+		return errors.New("invalid record signature")
+	}
+	if !signer.VerifyText(common.HexToAddress(r.Signer), payload, r.SignerSignature) {
+		return errors.New("invalid record signer signature")
+	}
+	return nil
+}
+
+// Name returns the name of the record from its key.
+func (r *Record) Name() string {
+	return r.Key[strings.LastIndex(r.Key, "/")+1:]
+}
+
+// Expired reports whether the record has expired at now.
+func (r *Record) Expired(now time.Time) bool {
+	return !now.Before(r.Expires)
+}
+
+func (r *Record) checkValue() error {
+	if len(r.Value) > MaxValueSize {
+		return fmt.Errorf("the value is larger than %d bytes", MaxValueSize)
+	}
+	if !json.Valid(r.Value) {
+		return errors.New("the value must be JSON")
+	}
+	return nil
+}
+
+func (r *Record) signingPayload() ([]byte, error) {
+	unsigned := *r
+	unsigned.Signature = nil
+	unsigned.SignerSignature = nil
+	return json.Marshal(unsigned)
+}
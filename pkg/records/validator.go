@@ -0,0 +1,80 @@
+package records
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Validator is the DHT record validator of the /masa namespace. It accepts the records signed by the peer of their
+// key and by a staked signer, and selects the highest version, the most recently created one on a tie.
+type Validator struct {
+	isStaked func(address string) (bool, error)
+}
+
+// NewValidator creates the record validator, isStaked is used to check the signer address of each record.
+func NewValidator(isStaked func(address string) (bool, error)) *Validator {
+	return &Validator{isStaked: isStaked}
+}
+
+// Validate implements record.Validator.
+func (v *Validator) Validate(key string, value []byte) error {
+	record, err := Unmarshal(value)
+	if err != nil {
+		return err
+	}
+	if record.Key != key {
+		return fmt.Errorf("the record of %s is stored under %s", record.Key, key)
+	}
+	if err := record.Verify(); err != nil {
+		return err
+	}
+	now := time.Now()
+	if record.Expired(now) {
+		return ErrExpired
+	}
+	if record.Created.Sub(now) > MaxClockSkew {
+		return errors.New("the record was created in the future")
+	}
+	if record.Expires.Sub(record.Created) > MaxTTL {
+		return fmt.Errorf("the record lives longer than %s", MaxTTL)
+	}
+	staked, err := v.isStaked(record.Signer)
+	if err != nil {
+		return fmt.Errorf("could not check the stake of %s: %w", record.Signer, err)
+	}
+	if !staked {
+		return ErrNotStaked
+	}
+	return nil
+}
+
+// Select implements record.Validator.
+func (v *Validator) Select(key string, values [][]byte) (int, error) {
+	best := -1
+	var bestRecord *Record
+	for i, value := range values {
+		record, err := Unmarshal(value)
+		if err != nil {
+			continue
+		}
+		if bestRecord == nil || record.Version > bestRecord.Version ||
+			(record.Version == bestRecord.Version && record.Created.After(bestRecord.Created)) {
+			best, bestRecord = i, record
+		}
+	}
+	if best < 0 {
+		return 0, errors.New("no valid record to select")
+	}
+	return best, nil
+}
+
+// Unmarshal decodes a record stored on the DHT.
+func Unmarshal(value []byte) (*Record, error) {
+	var record Record
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, fmt.Errorf("malformed record: %w", err)
+	}
+	return &record, nil
+}
@@ -0,0 +1,119 @@
+package records
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p/core/crypto"
+
+	"github.com/masa-finance/masa-oracle/pkg/signer"
+)
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func newTestSigner(t *testing.T) signer.Signer {
+	key, err := ethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer.NewPrivateKeySigner(key)
+}
+
+func TestValidator(t *testing.T) {
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the signer holds the staking key, which is not the node key
+	nodeSigner := newTestSigner(t)
+	address := nodeSigner.Address().Hex()
+	staked := map[string]bool{address: true}
+	validator := NewValidator(func(address string) (bool, error) {
+		return staked[address], nil
+	})
+
+	record, err := NewRecord(privKey, nodeSigner, "profile", json.RawMessage(`{"name": "node"}`), 1, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := mustMarshal(t, record)
+	if err := validator.Validate(record.Key, value); err != nil {
+		t.Fatalf("expected a record signed by a staked node to be valid, got %v", err)
+	}
+	if err := validator.Validate(record.Key+"2", value); err == nil {
+		t.Error("expected a record stored under another key to be invalid")
+	}
+
+	tampered := *record
+	tampered.Value = json.RawMessage(`{"name": "other"}`)
+	if err := validator.Validate(record.Key, mustMarshal(t, tampered)); err == nil {
+		t.Error("expected a tampered record to be invalid")
+	}
+
+	otherKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := NewRecord(otherKey, nodeSigner, "profile", json.RawMessage(`{}`), 2, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.Key = record.Key
+	if err := validator.Validate(record.Key, mustMarshal(t, forged)); err == nil {
+		t.Error("expected a record signed by another peer to be invalid")
+	}
+
+	otherSigner := newTestSigner(t)
+	staked[otherSigner.Address().Hex()] = true
+	claimed := *record
+	claimed.Signer = otherSigner.Address().Hex()
+	if err := validator.Validate(record.Key, mustMarshal(t, claimed)); err == nil {
+		t.Error("expected a record claiming another signer to be invalid")
+	}
+
+	expired := *record
+	expired.Expires = time.Now().Add(-time.Minute)
+	if err := validator.Validate(record.Key, mustMarshal(t, expired)); err == nil {
+		t.Error("expected an expired record to be invalid")
+	}
+
+	staked[address] = false
+	if err := validator.Validate(record.Key, value); err != ErrNotStaked {
+		t.Errorf("expected a record of an unstaked node to be invalid, got %v", err)
+	}
+}
+
+func TestValidatorSelect(t *testing.T) {
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Secp256k1, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeSigner := newTestSigner(t)
+	validator := NewValidator(nil)
+	newValue := func(version uint64) []byte {
+		record, err := NewRecord(privKey, nodeSigner, "endpoint", json.RawMessage(`"https://node.example"`), version, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mustMarshal(t, record)
+	}
+	v1, v3, v2 := newValue(1), newValue(3), newValue(2)
+	if best, err := validator.Select("", [][]byte{v1, v3, v2}); err != nil || best != 1 {
+		t.Errorf("expected the highest version to be selected, got %d, %v", best, err)
+	}
+	newer := newValue(3)
+	if best, err := validator.Select("", [][]byte{v3, newer, []byte("garbage")}); err != nil || best != 1 {
+		t.Errorf("expected the most recent record of a version to be selected, got %d, %v", best, err)
+	}
+	if _, err := validator.Select("", [][]byte{[]byte("garbage")}); err == nil {
+		t.Error("expected an error without a valid record")
+	}
+}
@@ -1,25 +1,65 @@
 package routes
 
 import (
+	"os"
+
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 
 	masa "github.com/masa-finance/masa-oracle/pkg"
 	"github.com/masa-finance/masa-oracle/pkg/api"
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/auth"
 )
 
-func SetupRoutes(node *masa.OracleNode) *gin.Engine {
+// legacyPolicy lists the unversioned routes that do not need the defaults of auth.Authenticator.Required, read for
+// GET and admin for everything else. The v1 routes declare their roles in api.Endpoints.
+var legacyPolicy = map[string]auth.Role{
+	"POST /ads":            auth.RolePublisher,
+	"POST /subscribeToAds": auth.RolePublisher,
+}
+
+func SetupRoutes(node *masa.OracleNode) (*gin.Engine, error) {
 	router := gin.Default()
 
-	api := api.NewAPI(node)
+	authenticator, err := NewAuthenticator()
+	if err != nil {
+		return nil, err
+	}
+	router.Use(authenticator.Middleware(auth.NewAuditLog(os.Getenv(masa.APIAuditLog))))
 
-	router.GET("/peers", api.GetPeersHandler())
-	router.GET("/peerAddresses", api.GetPeerAddresses())
+	nodeAPI := api.NewAPI(node)
+	nodeAPI.RegisterV1(router.Group(v1.BasePath))
 
-	router.POST("/ads", api.PostAd())
-	router.GET("/ads", api.GetAds())
-	router.POST("/subscribeToAds", api.SubscribeToAds())
+	// the routes that predate /v1 are kept as deprecated aliases for existing clients, newer routes are only
+	// served under /v1
+	router.GET("/peers", api.Deprecated("/peers"), nodeAPI.GetPeersHandler())
+	router.GET("/peerAddresses", api.Deprecated("/peers/addresses"), nodeAPI.GetPeerAddresses())
 
-	router.GET("/nodeData", api.GetNodeDataHandler())
+	router.POST("/ads", api.Deprecated("/ads"), nodeAPI.PostAd())
+	router.GET("/ads", api.Deprecated("/ads"), nodeAPI.GetAds())
+	router.POST("/subscribeToAds", api.Deprecated("/ads/subscribe"), nodeAPI.SubscribeToAds())
+
+	router.GET("/nodeData", api.Deprecated("/nodeData"), nodeAPI.GetNodeDataHandler())
+
+	return router, nil
+}
 
-	return router
+// NewAuthenticator loads the API access configuration from the apiAuthFile environment variable. Without it
+// anyone may use the read routes and nobody may change anything.
+func NewAuthenticator() (*auth.Authenticator, error) {
+	config := auth.DefaultConfig()
+	if path := os.Getenv(masa.APIAuthFile); path != "" {
+		var err error
+		if config, err = auth.LoadConfig(path); err != nil {
+			return nil, err
+		}
+	} else {
+		logrus.Warnf("No API access file set with %s, the API is read only", masa.APIAuthFile)
+	}
+	policy := api.Policy()
+	for route, role := range legacyPolicy {
+		policy[route] = role
+	}
+	return auth.NewAuthenticator(config, policy)
 }
@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/masa-finance/masa-oracle/pkg/ad"
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/pubsub"
+	"github.com/masa-finance/masa-oracle/pkg/rpc/pb"
+)
+
+// timestamp converts a time, leaving zero times unset.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func fromTimestamp(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.AsTime()
+}
+
+func nodeDataToPB(data pubsub.NodeData) *pb.NodeData {
+	message := &pb.NodeData{
+		PeerId:            data.PeerId.String(),
+		LastJoined:        timestamp(data.LastJoined),
+		LastLeft:          timestamp(data.LastLeft),
+		LastUpdated:       timestamp(data.LastUpdated),
+		CurrentUptime:     durationpb.New(data.CurrentUptime),
+		AccumulatedUptime: durationpb.New(data.AccumulatedUptime),
+		EthAddress:        data.EthAddress,
+		Activity:          int32(data.Activity),
+		IsActive:          data.IsActive,
+	}
+	for _, addr := range data.Multiaddrs {
+		if addr.Multiaddr != nil {
+			message.Multiaddrs = append(message.Multiaddrs, addr.String())
+		}
+	}
+	return message
+}
+
+func peerInfoToPB(info *v1.PeerInfo) *pb.PeerInfo {
+	message := &pb.PeerInfo{
+		Id:              info.ID,
+		Connectedness:   info.Connectedness,
+		AgentVersion:    info.AgentVersion,
+		ProtocolVersion: info.ProtocolVersion,
+		Protocols:       info.Protocols,
+		EthAddress:      info.EthAddress,
+		Streams:         make(map[string]int32),
+		Latency:         durationpb.New(info.Latency),
+	}
+	message.PublicKey, _ = hex.DecodeString(info.PublicKey)
+	for _, addr := range info.Addresses {
+		address := &pb.PeerAddress{Address: addr.Address}
+		if addr.TTL != 0 {
+			address.Ttl = durationpb.New(addr.TTL)
+		}
+		message.Addresses = append(message.Addresses, address)
+	}
+	for _, conn := range info.Connections {
+		message.Connections = append(message.Connections, &pb.PeerConnection{
+			Id:            conn.ID,
+			Direction:     conn.Direction,
+			Transport:     conn.Transport,
+			Security:      conn.Security,
+			Muxer:         conn.Muxer,
+			LocalAddress:  conn.LocalAddress,
+			RemoteAddress: conn.RemoteAddress,
+			Opened:        timestamp(conn.Opened),
+			Transient:     conn.Transient,
+			Streams:       int32(conn.Streams),
+		})
+	}
+	for protocol, count := range info.Streams {
+		message.Streams[protocol] = int32(count)
+	}
+	if info.NodeData != nil {
+		message.NodeData = nodeDataToPB(*info.NodeData)
+	}
+	return message
+}
+
+func adToPB(a ad.Ad) *pb.Ad {
+	message := &pb.Ad{
+		Version:     int32(a.Version),
+		Title:       a.Title,
+		Body:        a.Body,
+		Tags:        a.Tags,
+		StartTime:   optionalTimestamp(a.StartTime),
+		EndTime:     optionalTimestamp(a.EndTime),
+		Content:     a.Content,
+		Metadata:    a.Metadata,
+		ExpiresAt:   timestamp(a.ExpiresAt),
+		ReceivedAt:  timestamp(a.ReceivedAt),
+		ContentHash: a.ContentHash,
+		Publisher:   a.Publisher,
+	}
+	if a.Budget != nil {
+		message.Budget = &pb.Budget{Amount: a.Budget.Amount, Currency: a.Budget.Currency}
+	}
+	for _, media := range a.Media {
+		message.Media = append(message.Media, &pb.MediaRef{Hash: media.Hash, Type: media.Type, Uri: media.URI})
+	}
+	return message
+}
+
+// adFromPB converts an ad to publish, the fields set by the receiving node are left out.
+func adFromPB(message *pb.Ad) ad.Ad {
+	a := ad.Ad{
+		Version:   int(message.Version),
+		Title:     message.Title,
+		Body:      message.Body,
+		Tags:      message.Tags,
+		Content:   message.Content,
+		Metadata:  message.Metadata,
+		ExpiresAt: fromTimestamp(message.ExpiresAt),
+		Publisher: message.Publisher,
+	}
+	if message.StartTime != nil {
+		startTime := message.StartTime.AsTime()
+		a.StartTime = &startTime
+	}
+	if message.EndTime != nil {
+		endTime := message.EndTime.AsTime()
+		a.EndTime = &endTime
+	}
+	if message.Budget != nil {
+		a.Budget = &ad.Budget{Amount: message.Budget.Amount, Currency: message.Budget.Currency}
+	}
+	for _, media := range message.Media {
+		a.Media = append(a.Media, ad.MediaRef{Hash: media.Hash, Type: media.Type, URI: media.Uri})
+	}
+	return a
+}
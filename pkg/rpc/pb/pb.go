@@ -0,0 +1,4 @@
+// Package pb holds the messages and the gRPC service of the node, generated from node.proto.
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative node.proto
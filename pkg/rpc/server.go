@@ -0,0 +1,407 @@
+// Package rpc serves the operations of the node API over gRPC, for services that do not want to wrap the JSON
+// routes. It calls the same operations of pkg/api as the HTTP routes and enforces the same roles.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	masa "github.com/masa-finance/masa-oracle/pkg"
+	"github.com/masa-finance/masa-oracle/pkg/ad"
+	"github.com/masa-finance/masa-oracle/pkg/api"
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/auth"
+	"github.com/masa-finance/masa-oracle/pkg/events"
+	"github.com/masa-finance/masa-oracle/pkg/rpc/pb"
+)
+
+// APIKeyMetadata is the metadata key of the API key, the authorization key with a bearer token works too.
+const APIKeyMetadata = "x-api-key"
+
+// route is a version 1 HTTP route as the authenticator's policy names it.
+type route struct {
+	method string
+	path   string
+}
+
+// methodRoutes maps the methods to the version 1 routes of the same operations. A method requires the role the
+// authenticator's policy gives its route, so overrides in the access file apply to both transports. The ad stream
+// serves the same ads as listing them.
+var methodRoutes = map[string]route{
+	pb.Node_ListPeers_FullMethodName:         {http.MethodGet, v1.BasePath + "/peers"},
+	pb.Node_ListPeerAddresses_FullMethodName: {http.MethodGet, v1.BasePath + "/peers/addresses"},
+	pb.Node_GetPeer_FullMethodName:           {http.MethodGet, v1.BasePath + "/peers/:id"},
+	pb.Node_ListNodeData_FullMethodName:      {http.MethodGet, v1.BasePath + "/nodeData"},
+	pb.Node_PublishAd_FullMethodName:         {http.MethodPost, v1.BasePath + "/ads"},
+	pb.Node_ListAds_FullMethodName:           {http.MethodGet, v1.BasePath + "/ads"},
+	pb.Node_SubscribeToAds_FullMethodName:    {http.MethodPost, v1.BasePath + "/ads/subscribe"},
+	pb.Node_StreamEvents_FullMethodName:      {http.MethodGet, v1.BasePath + "/events"},
+	pb.Node_StreamAds_FullMethodName:         {http.MethodGet, v1.BasePath + "/ads"},
+}
+
+// errorCodes maps the API's error codes to gRPC codes.
+var errorCodes = map[string]codes.Code{
+	v1.CodeInvalidRequest: codes.InvalidArgument,
+	v1.CodeUnauthorized:   codes.Unauthenticated,
+	v1.CodeForbidden:      codes.PermissionDenied,
+	v1.CodeNotFound:       codes.NotFound,
+	v1.CodeConflict:       codes.AlreadyExists,
+	v1.CodeRateLimited:    codes.ResourceExhausted,
+	v1.CodeNotStaked:      codes.FailedPrecondition,
+	v1.CodeUnavailable:    codes.Unavailable,
+	v1.CodeInternal:       codes.Internal,
+}
+
+// Server implements the Node service.
+type Server struct {
+	pb.UnimplementedNodeServer
+	api           *api.API
+	authenticator *auth.Authenticator
+	audit         *auth.AuditLog
+}
+
+// NewServer returns a gRPC server with the Node service, authenticating calls with authenticator and recording
+// the calls that change something in audit.
+func NewServer(nodeAPI *api.API, authenticator *auth.Authenticator, audit *auth.AuditLog, opts ...grpc.ServerOption) *grpc.Server {
+	s := &Server{api: nodeAPI, authenticator: authenticator, audit: audit}
+	opts = append(opts, grpc.ChainUnaryInterceptor(s.unaryInterceptor), grpc.ChainStreamInterceptor(s.streamInterceptor))
+	server := grpc.NewServer(opts...)
+	pb.RegisterNodeServer(server, s)
+	return server
+}
+
+func (s *Server) ListPeers(ctx context.Context, _ *pb.ListPeersRequest) (*pb.ListPeersResponse, error) {
+	peers, err := s.api.Peers()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	response := &pb.ListPeersResponse{}
+	for _, p := range peers {
+		response.Peers = append(response.Peers, &pb.Peer{Id: p.ID})
+	}
+	return response, nil
+}
+
+func (s *Server) ListPeerAddresses(ctx context.Context, _ *pb.ListPeerAddressesRequest) (*pb.ListPeerAddressesResponse, error) {
+	peers, err := s.api.ConnectedPeers()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	response := &pb.ListPeerAddressesResponse{}
+	for _, p := range peers {
+		response.Peers = append(response.Peers, &pb.PeerAddresses{Id: p.ID, Addresses: p.Addresses})
+	}
+	return response, nil
+}
+
+func (s *Server) GetPeer(ctx context.Context, request *pb.GetPeerRequest) (*pb.PeerInfo, error) {
+	info, err := s.api.PeerInfo(request.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return peerInfoToPB(info), nil
+}
+
+func (s *Server) ListNodeData(ctx context.Context, request *pb.ListNodeDataRequest) (*pb.ListNodeDataResponse, error) {
+	params := v1.NodeDataParams{
+		Active:     request.Active,
+		Staked:     request.Staked,
+		EthAddress: request.EthAddress,
+		Transport:  request.Transport,
+		Sort:       request.Sort,
+		Cursor:     request.Cursor,
+		Page:       int(request.Page),
+		PageSize:   int(request.PageSize),
+	}
+	if params.PageSize == 0 {
+		params.PageSize = masa.PageSize
+	}
+	if request.MinUptime != nil {
+		params.MinUptime = request.MinUptime.AsDuration()
+	}
+	if request.LastSeenSince != nil {
+		params.LastSeenSince = request.LastSeenSince.AsTime()
+	}
+	if request.Descending {
+		params.Order = "desc"
+	}
+	data, meta, err := s.api.NodeDataPage(params)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	response := &pb.ListNodeDataResponse{TotalCount: int32(meta.TotalCount), TotalPages: int32(meta.TotalPages), NextCursor: meta.NextCursor}
+	for _, nodeData := range data {
+		response.NodeData = append(response.NodeData, nodeDataToPB(nodeData))
+	}
+	return response, nil
+}
+
+func (s *Server) PublishAd(ctx context.Context, request *pb.PublishAdRequest) (*pb.PublishAdResponse, error) {
+	if request.Ad == nil {
+		return nil, status.Error(codes.InvalidArgument, "ad is required")
+	}
+	// the ad goes through the same JSON schema validation as ads posted to the HTTP API
+	body, err := json.Marshal(adFromPB(request.Ad))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	signedAd, err := s.api.PublishAdJSON(body)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.PublishAdResponse{
+		Publisher: signedAd.Publisher,
+		Timestamp: timestamppb.New(signedAd.Timestamp),
+		Nonce:     signedAd.Nonce,
+		Signature: signedAd.Signature,
+	}, nil
+}
+
+func (s *Server) ListAds(ctx context.Context, request *pb.ListAdsRequest) (*pb.ListAdsResponse, error) {
+	params := v1.AdsParams{
+		Publisher: request.Publisher,
+		Q:         request.Q,
+		Sort:      request.Sort,
+		Cursor:    request.Cursor,
+		Limit:     int(request.Limit),
+	}
+	for key, value := range request.Metadata {
+		params.Metadata = append(params.Metadata, key+":"+value)
+	}
+	if request.Since != nil {
+		params.Since = request.Since.AsTime()
+	}
+	if request.Until != nil {
+		params.Until = request.Until.AsTime()
+	}
+	if request.Descending {
+		params.Order = "desc"
+	}
+	page, err := s.api.QueryAds(params)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	response := &pb.ListAdsResponse{NextCursor: page.NextCursor, TotalCount: int32(page.Total)}
+	for _, a := range page.Ads {
+		response.Ads = append(response.Ads, adToPB(a))
+	}
+	return response, nil
+}
+
+func (s *Server) SubscribeToAds(ctx context.Context, _ *pb.SubscribeToAdsRequest) (*pb.SubscribeToAdsResponse, error) {
+	if err := s.api.SubscribeAdTopic(); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.SubscribeToAdsResponse{}, nil
+}
+
+// StreamEvents ends with codes.Unavailable when the client falls behind, it resumes with since set to the last
+// sequence it received. The topics gated by the scopes of /v1/events are only streamed to callers with their role.
+func (s *Server) StreamEvents(request *pb.StreamEventsRequest, stream pb.Node_StreamEventsServer) error {
+	principal, _ := PrincipalFromContext(stream.Context())
+	r := methodRoutes[pb.Node_StreamEvents_FullMethodName]
+	hidden := api.HiddenTopics(func(scope string) bool {
+		return principal.Role.Allows(s.authenticator.Required(r.method, auth.ScopePath(r.path, scope)))
+	})
+	subscription, complete := s.api.Node.Events.Subscribe(request.Topics, request.Since)
+	defer subscription.Close()
+	if !complete {
+		data, _ := json.Marshal(map[string]uint64{"since": request.Since})
+		if err := stream.Send(&pb.Event{Topic: api.TopicMissed, Time: timestamppb.Now(), Data: data}); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case event, ok := <-subscription.C:
+			if !ok {
+				return status.Error(codes.Unavailable, "client fell behind, resume after the last sequence received")
+			}
+			if !api.Visible(event.Topic, hidden) {
+				continue
+			}
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				logrus.Errorf("Error marshaling event %d: %v", event.Sequence, err)
+				continue
+			}
+			err = stream.Send(&pb.Event{Sequence: event.Sequence, Topic: event.Topic, Time: timestamppb.New(event.Time), Data: data})
+			if err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// StreamAds streams the ads received from now on. A client falling behind is resumed after the last ad it was sent,
+// the stream ends with codes.Unavailable when ads were dropped meanwhile.
+func (s *Server) StreamAds(request *pb.StreamAdsRequest, stream pb.Node_StreamAdsServer) error {
+	query := ad.Query{Publisher: request.Publisher, Text: request.Q, Metadata: request.Metadata}
+	// start after the current sequence so there is a sequence to resume after even before the first ad
+	last := s.api.Node.Events.Sequence()
+	subscription, _ := s.api.Node.Events.Subscribe([]string{events.TopicAd}, last)
+	defer func() { subscription.Close() }()
+	for {
+		select {
+		case event, ok := <-subscription.C:
+			if !ok {
+				// the bus closes subscribers that fall behind, resume after the last ad seen
+				complete := false
+				if last > 0 {
+					subscription, complete = s.api.Node.Events.Subscribe([]string{events.TopicAd}, last)
+				}
+				if !complete {
+					return status.Error(codes.Unavailable, "client fell behind and ads were dropped, stream them again")
+				}
+				continue
+			}
+			last = event.Sequence
+			a, ok := event.Data.(ad.Ad)
+			if !ok || !query.Matches(a) {
+				continue
+			}
+			if err := stream.Send(adToPB(a)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *Server) unaryInterceptor(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	principal, err := s.authorize(ctx, info.FullMethod)
+	if err == nil {
+		ctx = context.WithValue(ctx, principalKey{}, principal)
+		var response interface{}
+		response, err = handler(ctx, request)
+		s.record(ctx, principal, info.FullMethod, err)
+		return response, err
+	}
+	s.record(ctx, principal, info.FullMethod, err)
+	return nil, err
+}
+
+func (s *Server) streamInterceptor(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	principal, err := s.authorize(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(server, &authorizedStream{ServerStream: stream, ctx: context.WithValue(stream.Context(), principalKey{}, principal)})
+}
+
+// authorizedStream is a stream whose context holds the caller.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+// principalKey is the context key of the caller.
+type principalKey struct{}
+
+// PrincipalFromContext returns the caller of a call.
+func PrincipalFromContext(ctx context.Context) (auth.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(auth.Principal)
+	return principal, ok
+}
+
+// authorize authenticates the caller of a method and checks it has the role the method requires.
+func (s *Server) authorize(ctx context.Context, method string) (auth.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := first(md.Get(APIKeyMetadata))
+	if bearer, ok := strings.CutPrefix(first(md.Get("authorization")), "Bearer "); ok && key == "" {
+		key = bearer
+	}
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	principal, err := s.authenticator.AuthenticateKey(key, remoteAddr)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"remote": remoteAddr, "method": method}).Warnf("Rejected gRPC call: %v", err)
+		return auth.Principal{ID: "unauthenticated"}, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	required := s.required(method)
+	if !principal.Role.Allows(required) {
+		code := codes.PermissionDenied
+		if principal.Role == auth.RoleNone {
+			code = codes.Unauthenticated
+		}
+		return principal, status.Errorf(code, "requires the %s role", required)
+	}
+	return principal, nil
+}
+
+// required returns the role the policy gives the route of method, methods without a route are for admins.
+func (s *Server) required(method string) auth.Role {
+	r, ok := methodRoutes[method]
+	if !ok {
+		return auth.RoleAdmin
+	}
+	return s.authenticator.Required(r.method, r.path)
+}
+
+// record adds the calls of methods that change something or require more than auth.RoleRead to the audit log.
+func (s *Server) record(ctx context.Context, principal auth.Principal, method string, err error) {
+	if r, ok := methodRoutes[method]; ok && r.method == http.MethodGet && auth.RoleRead.Allows(s.required(method)) {
+		return
+	}
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	s.audit.Record(auth.AuditRecord{
+		Time:      time.Now().UTC(),
+		Principal: principal.ID,
+		Role:      principal.Role,
+		Method:    "gRPC",
+		Path:      method,
+		Remote:    remoteAddr,
+		// the gRPC status code, 0 for success
+		Status: int(status.Code(err)),
+	})
+}
+
+// toStatus converts an error of the API operations to a gRPC status.
+func toStatus(err error) error {
+	var apiErr *v1.Error
+	if !errors.As(err, &apiErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	code, ok := errorCodes[apiErr.Code]
+	if !ok {
+		code = codes.Unknown
+	}
+	message := apiErr.Message
+	if fieldErrors, ok := apiErr.Details.([]ad.FieldError); ok {
+		for _, fieldError := range fieldErrors {
+			message += "; " + fieldError.Field + ": " + fieldError.Message
+		}
+	}
+	return status.Error(code, message)
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,243 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	masa "github.com/masa-finance/masa-oracle/pkg"
+	"github.com/masa-finance/masa-oracle/pkg/ad"
+	"github.com/masa-finance/masa-oracle/pkg/api"
+	v1 "github.com/masa-finance/masa-oracle/pkg/api/v1"
+	"github.com/masa-finance/masa-oracle/pkg/auth"
+	"github.com/masa-finance/masa-oracle/pkg/events"
+	"github.com/masa-finance/masa-oracle/pkg/rpc/pb"
+)
+
+func testAuthenticator(t *testing.T, policy map[string]auth.Role) *auth.Authenticator {
+	authenticator, err := auth.NewAuthenticator(auth.Config{
+		Keys:      []auth.APIKey{{ID: "publisher", Key: "publisher-key", Role: auth.RolePublisher}},
+		Anonymous: auth.RoleRead,
+		Policy:    policy,
+	}, api.Policy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authenticator
+}
+
+func newTestClient(t *testing.T, node *masa.OracleNode) pb.NodeClient {
+	return newTestClientWith(t, node, testAuthenticator(t, nil))
+}
+
+func newTestClientWith(t *testing.T, node *masa.OracleNode, authenticator *auth.Authenticator) pb.NodeClient {
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(api.NewAPI(node), authenticator, nil)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewNodeClient(conn)
+}
+
+func TestAuthorization(t *testing.T) {
+	client := newTestClient(t, &masa.OracleNode{})
+	ctx := context.Background()
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, APIKeyMetadata, key)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"anonymous read reaches the node", func() error { _, err := client.ListPeers(ctx, &pb.ListPeersRequest{}); return err }, codes.Unavailable},
+		{"anonymous publish", func() error { _, err := client.SubscribeToAds(ctx, &pb.SubscribeToAdsRequest{}); return err }, codes.PermissionDenied},
+		{"invalid key", func() error { _, err := client.ListPeers(withKey("wrong"), &pb.ListPeersRequest{}); return err }, codes.Unauthenticated},
+		{"publisher", func() error { _, err := client.PublishAd(withKey("publisher-key"), &pb.PublishAdRequest{}); return err }, codes.InvalidArgument},
+		{"invalid page", func() error {
+			_, err := client.ListNodeData(ctx, &pb.ListNodeDataRequest{PageSize: 1000})
+			return err
+		}, codes.InvalidArgument},
+	}
+	for _, test := range tests {
+		if code := status.Code(test.call()); code != test.code {
+			t.Errorf("%s: got %s, want %s", test.name, code, test.code)
+		}
+	}
+}
+
+func TestMethodRoutes(t *testing.T) {
+	policy := api.Policy()
+	for _, method := range pb.Node_ServiceDesc.Methods {
+		if _, ok := methodRoutes["/"+pb.Node_ServiceDesc.ServiceName+"/"+method.MethodName]; !ok {
+			t.Errorf("%s has no route", method.MethodName)
+		}
+	}
+	for _, stream := range pb.Node_ServiceDesc.Streams {
+		if _, ok := methodRoutes["/"+pb.Node_ServiceDesc.ServiceName+"/"+stream.StreamName]; !ok {
+			t.Errorf("%s has no route", stream.StreamName)
+		}
+	}
+	for method, r := range methodRoutes {
+		if _, ok := policy[r.method+" "+r.path]; !ok {
+			t.Errorf("%s maps to the unknown route %s %s", method, r.method, r.path)
+		}
+	}
+}
+
+func TestPolicyOverride(t *testing.T) {
+	// the access file makes subscribing to ads an admin operation
+	authenticator := testAuthenticator(t, map[string]auth.Role{"POST " + v1.BasePath + "/ads/subscribe": auth.RoleAdmin})
+	nodeAPI := api.NewAPI(&masa.OracleNode{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(authenticator.Middleware(nil))
+	nodeAPI.RegisterV1(router.Group(v1.BasePath))
+	req := httptest.NewRequest(http.MethodPost, v1.BasePath+"/ads/subscribe", nil)
+	req.Header.Set(auth.APIKeyHeader, "publisher-key")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("HTTP: publisher subscribing got %d, want %d", recorder.Code, http.StatusForbidden)
+	}
+
+	client := newTestClientWith(t, &masa.OracleNode{}, authenticator)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), APIKeyMetadata, "publisher-key")
+	if _, err := client.SubscribeToAds(ctx, &pb.SubscribeToAdsRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("gRPC: publisher subscribing got %v, want %s", err, codes.PermissionDenied)
+	}
+}
+
+func TestStreamEventsHidesScopedTopics(t *testing.T) {
+	node := &masa.OracleNode{Events: events.NewBus(16)}
+	// the access file lets publishers see the bridge events, readers still do not
+	client := newTestClientWith(t, node, testAuthenticator(t, map[string]auth.Role{
+		"GET " + auth.ScopePath(v1.BasePath+"/events", events.TopicBridge): auth.RolePublisher,
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, test := range []struct {
+		ctx    context.Context
+		bridge bool
+	}{
+		{ctx, false},
+		{metadata.AppendToOutgoingContext(ctx, APIKeyMetadata, "publisher-key"), true},
+	} {
+		stream, err := client.StreamEvents(test.ctx, &pb.StreamEventsRequest{Topics: []string{events.TopicBridge, events.TopicStake}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the subscription starts when the stream is set up on the server, keep publishing until events arrive
+		streamCtx, stop := context.WithCancel(ctx)
+		go func() {
+			for streamCtx.Err() == nil {
+				node.Events.Publish(events.TopicBridge, "payload")
+				node.Events.Publish(events.TopicStake, "amount")
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+		bridge := false
+		for i := 0; i < 4; i++ {
+			event, err := stream.Recv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			bridge = bridge || event.Topic == events.TopicBridge
+		}
+		stop()
+		if bridge != test.bridge {
+			t.Errorf("bridge events streamed: %v, want %v", bridge, test.bridge)
+		}
+	}
+}
+
+func TestStreamAds(t *testing.T) {
+	node := &masa.OracleNode{Events: events.NewBus(16)}
+	client := newTestClient(t, node)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamAds(ctx, &pb.StreamAdsRequest{Metadata: map[string]string{"campaign": "a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the subscription starts when the stream is set up on the server, keep publishing until an ad arrives
+	go func() {
+		for ctx.Err() == nil {
+			node.Events.Publish(events.TopicAd, ad.Ad{Content: "other", Metadata: map[string]string{"campaign": "b"}})
+			node.Events.Publish(events.TopicAd, ad.Ad{Content: "match", Metadata: map[string]string{"campaign": "a"}})
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		received, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if received.Content != "match" {
+			t.Fatalf("received ad %q not matching the filter", received.Content)
+		}
+	}
+}
+
+// blockingAdStream holds the first ad sent until release is closed.
+type blockingAdStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	sending chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingAdStream) Context() context.Context { return s.ctx }
+
+func (s *blockingAdStream) Send(*pb.Ad) error {
+	select {
+	case s.sending <- struct{}{}:
+		<-s.release
+	default:
+	}
+	return nil
+}
+
+func TestStreamAdsFallingBehind(t *testing.T) {
+	node := &masa.OracleNode{Events: events.NewBus(1)}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream := &blockingAdStream{ctx: ctx, sending: make(chan struct{}, 1), release: make(chan struct{})}
+	done := make(chan error, 1)
+	go func() { done <- (&Server{api: api.NewAPI(node)}).StreamAds(&pb.StreamAdsRequest{}, stream) }()
+
+	// publish until the stream is stuck on its first ad, then overflow its buffer and the history
+	for len(stream.sending) == 0 && ctx.Err() == nil {
+		node.Events.Publish(events.TopicAd, ad.Ad{Content: "first"})
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 1000; i++ {
+		node.Events.Publish(events.TopicAd, ad.Ad{Content: "dropped"})
+	}
+	close(stream.release)
+	if err := <-done; status.Code(err) != codes.Unavailable {
+		t.Errorf("got %v, want the stream to end with Unavailable", err)
+	}
+}
@@ -0,0 +1,154 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// ExternalSigner delegates signing to an external signer speaking the Clef JSON-RPC API, usually over the local IPC
+// socket, so the key never enters the node process. Every request has to be approved by the signer's rules.
+type ExternalSigner struct {
+	client  *rpc.Client
+	account accounts.Account
+}
+
+// NewExternalSigner connects to the signer at endpoint. When address is empty the first account the signer
+// exposes is used.
+func NewExternalSigner(endpoint, address string) (*ExternalSigner, error) {
+	// go-ethereum's external.ExternalSigner dials its own client and cannot close it, so the Clef API is called
+	// over a single client owned by this signer.
+	client, err := rpc.DialContext(context.Background(), endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to external signer %s: %w", endpoint, err)
+	}
+	account, err := externalAccount(client, endpoint, address)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &ExternalSigner{
+		client:  client,
+		account: account,
+	}, nil
+}
+
+// externalAccount picks the account to sign with from the accounts the signer manages.
+func externalAccount(client *rpc.Client, endpoint, address string) (accounts.Account, error) {
+	var version string
+	if err := client.Call(&version, "account_version"); err != nil {
+		return accounts.Account{}, fmt.Errorf("error connecting to external signer %s: %w", endpoint, err)
+	}
+	var addresses []common.Address
+	if err := client.Call(&addresses, "account_list"); err != nil {
+		return accounts.Account{}, fmt.Errorf("error listing accounts of external signer %s: %w", endpoint, err)
+	}
+	if len(addresses) == 0 {
+		return accounts.Account{}, fmt.Errorf("external signer %s has no accounts", endpoint)
+	}
+	if address == "" {
+		return accounts.Account{Address: addresses[0]}, nil
+	}
+	if !common.IsHexAddress(address) {
+		return accounts.Account{}, fmt.Errorf("invalid signer address %s", address)
+	}
+	want := common.HexToAddress(address)
+	for _, addr := range addresses {
+		if addr == want {
+			return accounts.Account{Address: addr}, nil
+		}
+	}
+	return accounts.Account{}, fmt.Errorf("external signer %s does not manage %s", endpoint, address)
+}
+
+func (s *ExternalSigner) Address() common.Address {
+	return s.account.Address
+}
+
+// SignTx asks the signer to sign tx, following go-ethereum's external backend.
+func (s *ExternalSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	data := hexutil.Bytes(tx.Data())
+	var to *common.MixedcaseAddress
+	if tx.To() != nil {
+		t := common.NewMixedcaseAddress(*tx.To())
+		to = &t
+	}
+	args := &apitypes.SendTxArgs{
+		Data:  &data,
+		Nonce: hexutil.Uint64(tx.Nonce()),
+		Value: hexutil.Big(*tx.Value()),
+		Gas:   hexutil.Uint64(tx.Gas()),
+		To:    to,
+		From:  common.NewMixedcaseAddress(s.account.Address),
+	}
+	switch tx.Type() {
+	case types.LegacyTxType, types.AccessListTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		return nil, fmt.Errorf("unsupported tx type %d", tx.Type())
+	}
+	if chainID != nil && chainID.Sign() != 0 {
+		args.ChainID = (*hexutil.Big)(chainID)
+	}
+	if tx.Type() != types.LegacyTxType {
+		if tx.ChainId().Sign() != 0 {
+			args.ChainID = (*hexutil.Big)(tx.ChainId())
+		}
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	}
+	var res struct {
+		Raw hexutil.Bytes      `json:"raw"`
+		Tx  *types.Transaction `json:"tx"`
+	}
+	if err := s.client.Call(&res, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+	return res.Tx, nil
+}
+
+// SignHash is not supported, Clef refuses to sign opaque hashes.
+func (s *ExternalSigner) SignHash(hash []byte) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *ExternalSigner) SignText(text []byte) ([]byte, error) {
+	var sig hexutil.Bytes
+	address := common.NewMixedcaseAddress(s.account.Address)
+	if err := s.client.Call(&sig, "account_signData", accounts.MimetypeTextPlain, &address, hexutil.Encode(text)); err != nil {
+		return nil, err
+	}
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("external signer returned a signature of %d bytes", len(sig))
+	}
+	// Clef may already return the signature in the 27/28 form
+	if sig[64] == 27 || sig[64] == 28 {
+		sig[64] -= 27
+	}
+	return sig, nil
+}
+
+func (s *ExternalSigner) SignTypedData(data apitypes.TypedData) ([]byte, error) {
+	var sig hexutil.Bytes
+	address := common.NewMixedcaseAddress(s.account.Address)
+	if err := s.client.Call(&sig, "account_signTypedData", &address, data); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// Close closes the connection to the external signer.
+func (s *ExternalSigner) Close() error {
+	s.client.Close()
+	return nil
+}
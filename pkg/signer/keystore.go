@@ -0,0 +1,92 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// KeystoreSigner signs with a key from an encrypted V3 keystore. The key is only decrypted for the duration of a
+// signing operation and cleared afterwards.
+type KeystoreSigner struct {
+	keyJSON    []byte
+	passphrase string
+	address    common.Address
+}
+
+// NewKeystoreSigner reads the keystore at path and checks that passphrase decrypts it.
+func NewKeystoreSigner(path, passphrase string) (*KeystoreSigner, error) {
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &KeystoreSigner{
+		keyJSON:    keyJSON,
+		passphrase: passphrase,
+	}
+	// decrypt once up front so a wrong passphrase fails at start up instead of on the first signature
+	err = s.withKey(func(key *ecdsa.PrivateKey) error {
+		s.address = crypto.PubkeyToAddress(key.PublicKey)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting keystore %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *KeystoreSigner) withKey(fn func(key *ecdsa.PrivateKey) error) error {
+	key, err := keystore.DecryptKey(s.keyJSON, s.passphrase)
+	if err != nil {
+		return err
+	}
+	defer zeroKey(key.PrivateKey)
+	return fn(key.PrivateKey)
+}
+
+func (s *KeystoreSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *KeystoreSigner) SignTx(tx *types.Transaction, chainID *big.Int) (signed *types.Transaction, err error) {
+	err = s.withKey(func(key *ecdsa.PrivateKey) error {
+		signed, err = types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
+		return err
+	})
+	return signed, err
+}
+
+func (s *KeystoreSigner) SignHash(hash []byte) (sig []byte, err error) {
+	err = s.withKey(func(key *ecdsa.PrivateKey) error {
+		sig, err = crypto.Sign(hash, key)
+		return err
+	})
+	return sig, err
+}
+
+func (s *KeystoreSigner) SignText(text []byte) ([]byte, error) {
+	return s.SignHash(accounts.TextHash(text))
+}
+
+func (s *KeystoreSigner) SignTypedData(data apitypes.TypedData) ([]byte, error) {
+	hash, err := typedDataHash(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.SignHash(hash)
+}
+
+func zeroKey(key *ecdsa.PrivateKey) {
+	b := key.D.Bits()
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,68 @@
+package signer
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// ErrNotSupported is returned by signers that refuse an operation, e.g. Clef does not sign raw hashes.
+var ErrNotSupported = errors.New("operation not supported by signer")
+
+// Signer signs on-chain transactions and off-chain messages for the node without exposing the key itself.
+type Signer interface {
+	// Address is the Ethereum address of the signing key.
+	Address() common.Address
+	// SignTx signs a transaction for the given chain.
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	// SignHash signs a 32 byte hash and returns a 65 byte [R || S || V] signature with V being 0 or 1.
+	SignHash(hash []byte) ([]byte, error)
+	// SignText signs text using the EIP-191 personal message format (personal_sign).
+	SignText(text []byte) ([]byte, error)
+	// SignTypedData signs EIP-712 typed data.
+	SignTypedData(data apitypes.TypedData) ([]byte, error)
+}
+
+// NewTransactor returns bind.TransactOpts that sign contract transactions with s.
+func NewTransactor(s Signer, chainID *big.Int) (*bind.TransactOpts, error) {
+	if chainID == nil {
+		return nil, bind.ErrNoChainID
+	}
+	return &bind.TransactOpts{
+		From: s.Address(),
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != s.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return s.SignTx(tx, chainID)
+		},
+	}, nil
+}
+
+// VerifyText checks that sig is a personal_sign signature over text made by address.
+func VerifyText(address common.Address, text, sig []byte) bool {
+	if len(sig) != crypto.SignatureLength {
+		return false
+	}
+	normalized := make([]byte, len(sig))
+	copy(normalized, sig)
+	if normalized[crypto.RecoveryIDOffset] >= 27 {
+		normalized[crypto.RecoveryIDOffset] -= 27
+	}
+	pubKey, err := crypto.SigToPub(accounts.TextHash(text), normalized)
+	if err != nil {
+		return false
+	}
+	return crypto.PubkeyToAddress(*pubKey) == address
+}
+
+func typedDataHash(data apitypes.TypedData) ([]byte, error) {
+	hash, _, err := apitypes.TypedDataAndHash(data)
+	return hash, err
+}
@@ -0,0 +1,135 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/google/uuid"
+)
+
+func TestSigners(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{
+		Id:         uuid.New(),
+		Address:    crypto.PubkeyToAddress(key.PublicKey),
+		PrivateKey: key,
+	}, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(keyFile, keyJSON, 0600); err != nil {
+		t.Fatal(err)
+	}
+	keystoreSigner, err := NewKeystoreSigner(keyFile, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, s := range map[string]Signer{"memory": NewPrivateKeySigner(key), "keystore": keystoreSigner} {
+		t.Run(name, func(t *testing.T) {
+			if s.Address() != crypto.PubkeyToAddress(key.PublicKey) {
+				t.Fatalf("unexpected address %s", s.Address().Hex())
+			}
+			sig, err := s.SignText([]byte("hello masa"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyText(s.Address(), []byte("hello masa"), sig) {
+				t.Error("expected text signature to verify")
+			}
+
+			chainID := big.NewInt(11155111)
+			opts, err := NewTransactor(s, chainID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+			signed, err := opts.Signer(s.Address(), tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sender != s.Address() {
+				t.Errorf("transaction signed by %s, expected %s", sender.Hex(), s.Address().Hex())
+			}
+		})
+	}
+
+	if _, err := NewKeystoreSigner(keyFile, "wrong"); err == nil {
+		t.Error("expected the wrong passphrase to be rejected")
+	}
+}
+
+// fakeClef serves the parts of the Clef API the external signer needs for text signatures.
+type fakeClef struct {
+	key *ecdsa.PrivateKey
+}
+
+func (c *fakeClef) Version() string {
+	return "6.0.0"
+}
+
+func (c *fakeClef) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(c.key.PublicKey)}
+}
+
+func (c *fakeClef) SignData(contentType string, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
+	sig, err := crypto.Sign(accounts.TextHash(data), c.key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+func TestExternalSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", &fakeClef{key: key}); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	clef := httptest.NewServer(server)
+	defer clef.Close()
+
+	s, err := NewExternalSigner(clef.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.Address() != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("unexpected address %s", s.Address().Hex())
+	}
+	sig, err := s.SignText([]byte("hello masa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyText(s.Address(), []byte("hello masa"), sig) {
+		t.Error("expected text signature to verify")
+	}
+
+	if _, err := NewExternalSigner(clef.URL, common.Address{1}.Hex()); err == nil {
+		t.Error("expected an account the signer does not manage to be rejected")
+	}
+}
@@ -0,0 +1,99 @@
+package staking
+
+import (
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultFailureTTL is how long a failed stake lookup is cached before the address is looked up again.
+const DefaultFailureTTL = 30 * time.Second
+
+type stakeEntry struct {
+	amount    *big.Int
+	err       error
+	fetchedAt time.Time
+}
+
+// StakeCache caches the stake of addresses so that checks on every received message do not each hit the RPC
+// endpoint. Entries are refreshed after ttl, failed lookups after failureTTL, and concurrent lookups of the same
+// address share one RPC call.
+type StakeCache struct {
+	// OnChange is called when a refreshed stake differs from the cached one
+	OnChange   func(address string, amount *big.Int)
+	mutex      sync.Mutex
+	entries    map[string]stakeEntry
+	ttl        time.Duration
+	failureTTL time.Duration
+	group      singleflight.Group
+	lookup     func(address string) (*big.Int, error)
+}
+
+func NewStakeCache(ttl time.Duration) *StakeCache {
+	return &StakeCache{
+		entries:    make(map[string]stakeEntry),
+		ttl:        ttl,
+		failureTTL: DefaultFailureTTL,
+		lookup:     GetStake,
+	}
+}
+
+// GetStake returns the staked amount of address, using the cached value or error while it is fresh.
+func (c *StakeCache) GetStake(address string) (*big.Int, error) {
+	key := strings.ToLower(address)
+	c.mutex.Lock()
+	entry, ok := c.entries[key]
+	c.mutex.Unlock()
+	if ok && c.fresh(entry) {
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		return entry.amount, nil
+	}
+
+	amount, err, _ := c.group.Do(key, func() (interface{}, error) {
+		return c.refresh(address, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return amount.(*big.Int), nil
+}
+
+func (c *StakeCache) fresh(entry stakeEntry) bool {
+	if entry.err != nil {
+		return time.Since(entry.fetchedAt) < c.failureTTL
+	}
+	return time.Since(entry.fetchedAt) < c.ttl
+}
+
+// refresh looks up the stake of address and caches the amount or the error under key.
+func (c *StakeCache) refresh(address, key string) (*big.Int, error) {
+	amount, err := c.lookup(address)
+	c.mutex.Lock()
+	previous := c.entries[key]
+	if err != nil {
+		// the last known amount is kept to report a change once the lookups succeed again
+		c.entries[key] = stakeEntry{amount: previous.amount, err: err, fetchedAt: time.Now()}
+		c.mutex.Unlock()
+		return nil, err
+	}
+	c.entries[key] = stakeEntry{amount: amount, fetchedAt: time.Now()}
+	c.mutex.Unlock()
+	if previous.amount != nil && previous.amount.Cmp(amount) != 0 && c.OnChange != nil {
+		c.OnChange(address, amount)
+	}
+	return amount, nil
+}
+
+// IsStaked reports whether address has a positive stake.
+func (c *StakeCache) IsStaked(address string) (bool, error) {
+	amount, err := c.GetStake(address)
+	if err != nil {
+		return false, err
+	}
+	return amount.Sign() > 0, nil
+}
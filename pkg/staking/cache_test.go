@@ -0,0 +1,66 @@
+package staking
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStakeCacheMergesLookups(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	cache := NewStakeCache(time.Hour)
+	cache.lookup = func(address string) (*big.Int, error) {
+		calls.Add(1)
+		<-release
+		return big.NewInt(1), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if staked, err := cache.IsStaked("0xAbC"); err != nil || !staked {
+				t.Errorf("staked %v, %v", staked, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if _, err := cache.GetStake("0xabc"); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("%d lookups, want 1", n)
+	}
+}
+
+func TestStakeCacheCachesFailures(t *testing.T) {
+	var calls atomic.Int32
+	cache := NewStakeCache(time.Hour)
+	cache.failureTTL = 50 * time.Millisecond
+	cache.lookup = func(address string) (*big.Int, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("unreachable")
+		}
+		return big.NewInt(2), nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.GetStake("0xabc"); err == nil {
+			t.Fatal("expected the failed lookup to be cached")
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("%d lookups, want 1", n)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if amount, err := cache.GetStake("0xabc"); err != nil || amount.Int64() != 2 {
+		t.Errorf("stake %v, %v after the failure expired", amount, err)
+	}
+}
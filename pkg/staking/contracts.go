@@ -2,7 +2,6 @@ package staking
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -14,8 +13,9 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/masa-finance/masa-oracle/pkg/signer"
 )
 
 const (
@@ -28,8 +28,8 @@ var OracleNodeStakingContractAddress = common.HexToAddress("0xd925bc5d3eCd899a3F
 
 // Client StakingClient holds the necessary details to interact with the Ethereum contracts
 type Client struct {
-	EthClient  *ethclient.Client
-	PrivateKey *ecdsa.PrivateKey
+	EthClient *ethclient.Client
+	Signer    signer.Signer
 }
 
 func getStakingContractABI(jsonPath string) (abi.ABI, error) {
@@ -55,14 +55,14 @@ func getStakingContractABI(jsonPath string) (abi.ABI, error) {
 }
 
 // NewClient creates a new StakingClient using the Sepolia RPC endpoint
-func NewClient(privateKey *ecdsa.PrivateKey) (*Client, error) {
+func NewClient(s signer.Signer) (*Client, error) {
 	client, err := ethclient.Dial(rpcURL) // Use the Sepolia RPC URL
 	if err != nil {
 		return nil, err
 	}
 	return &Client{
-		EthClient:  client,
-		PrivateKey: privateKey,
+		EthClient: client,
+		Signer:    s,
 	}, nil
 }
 
@@ -75,8 +75,8 @@ func (sc *Client) Approve(amount *big.Int) (string, error) {
 		return "", err
 	}
 
-	// Retrieve the sender's address from the private key
-	fromAddress := crypto.PubkeyToAddress(sc.PrivateKey.PublicKey)
+	// Retrieve the sender's address from the signer
+	fromAddress := sc.Signer.Address()
 
 	// Get the nonce for the sender's address
 	nonce, err := sc.EthClient.PendingNonceAt(context.Background(), fromAddress)
@@ -118,7 +118,7 @@ func (sc *Client) Approve(amount *big.Int) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to get network ID: %v", err)
 	}
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), sc.PrivateKey)
+	signedTx, err := sc.Signer.SignTx(tx, chainID)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign transaction: %v", err)
 	}
@@ -151,9 +151,9 @@ func (sc *Client) Stake(amount *big.Int) (string, error) {
 	}
 
 	// Create an authenticated session
-	auth, err := bind.NewKeyedTransactorWithChainID(sc.PrivateKey, chainID)
+	auth, err := signer.NewTransactor(sc.Signer, chainID)
 	if err != nil {
-		return "", fmt.Errorf("failed to create keyed transactor: %v", err)
+		return "", fmt.Errorf("failed to create transactor: %v", err)
 	}
 
 	// Parse the ABI
@@ -9,6 +9,7 @@ import (
 	"math/big"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -40,26 +41,46 @@ func getContractABI() []interface{} {
 	return contract.ABI
 }
 
-func VerifyStakingEvent(userAddress string) (bool, error) {
-	client, err := ethclient.Dial(infuraURL)
+var (
+	stakeClientMutex sync.Mutex
+	stakeClient      *ethclient.Client
+)
+
+// getClient returns the client of the staking RPC endpoint shared by all lookups, dialing it on first use.
+func getClient() (*ethclient.Client, error) {
+	stakeClientMutex.Lock()
+	defer stakeClientMutex.Unlock()
+	if stakeClient == nil {
+		c, err := ethclient.Dial(infuraURL)
+		if err != nil {
+			return nil, err
+		}
+		stakeClient = c
+	}
+	return stakeClient, nil
+}
+
+// GetStake returns the amount staked by userAddress in the staking contract.
+func GetStake(userAddress string) (*big.Int, error) {
+	client, err := getClient()
 	if err != nil {
-		return false, errors.New(fmt.Sprintf("Failed to connect to the Ethereum client: %v", err))
+		return nil, errors.New(fmt.Sprintf("Failed to connect to the Ethereum client: %v", err))
 	}
 
 	contractABI := getContractABI()
 	abiJSON, err := json.Marshal(contractABI)
 	if err != nil {
-		return false, errors.New(fmt.Sprintf("Failed to marshal contract ABI: %v", err))
+		return nil, errors.New(fmt.Sprintf("Failed to marshal contract ABI: %v", err))
 	}
 	parsedABI, err := abi.JSON(strings.NewReader(string(abiJSON)))
 	if err != nil {
-		return false, errors.New(fmt.Sprintf("Failed to parse contract ABI: %v", err))
+		return nil, errors.New(fmt.Sprintf("Failed to parse contract ABI: %v", err))
 	}
 
 	address := common.HexToAddress(userAddress)
 	stake, err := parsedABI.Pack("stakes", address)
 	if err != nil {
-		return false, errors.New(fmt.Sprintf("Failed to pack data for stakes call: %v", err))
+		return nil, errors.New(fmt.Sprintf("Failed to pack data for stakes call: %v", err))
 	}
 
 	// Address correction
@@ -72,17 +93,25 @@ func VerifyStakingEvent(userAddress string) (bool, error) {
 
 	result, err := client.CallContract(context.Background(), callMsg, nil)
 	if err != nil {
-		return false, errors.New(fmt.Sprintf("Failed to call stakes function: %v", err))
+		return nil, errors.New(fmt.Sprintf("Failed to call stakes function: %v", err))
 	}
 
 	stakesAmountInterfaces, err := parsedABI.Unpack("stakes", result)
 	if err != nil {
-		return false, errors.New(fmt.Sprintf("Failed to unpack stakes: %v", err))
+		return nil, errors.New(fmt.Sprintf("Failed to unpack stakes: %v", err))
 	}
 
 	stakesAmount, ok := stakesAmountInterfaces[0].(*big.Int)
 	if !ok {
-		return false, errors.New("failed to assert type: stakesAmount is not *big.Int")
+		return nil, errors.New("failed to assert type: stakesAmount is not *big.Int")
+	}
+	return stakesAmount, nil
+}
+
+func VerifyStakingEvent(userAddress string) (bool, error) {
+	stakesAmount, err := GetStake(userAddress)
+	if err != nil {
+		return false, err
 	}
 	return stakesAmount.Cmp(big.NewInt(0)) > 0, nil
 }
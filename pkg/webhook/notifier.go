@@ -0,0 +1,466 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/masa-finance/masa-oracle/pkg/atomicfile"
+	"github.com/masa-finance/masa-oracle/pkg/bridge"
+	"github.com/masa-finance/masa-oracle/pkg/events"
+)
+
+// Headers of a notification besides the bridge's timestamp and signature headers, so a bridge can verify
+// notifications of a node with the subscription's secret.
+const (
+	DeliveryHeader = "X-Masa-Delivery"
+	EventHeader    = "X-Masa-Event"
+)
+
+const (
+	DefaultMaxAttempts  = 10
+	DefaultRetryBackoff = 5 * time.Second
+	DefaultMaxBackoff   = time.Hour
+	DefaultLogSize      = 1000
+	DefaultMaxPending   = 1000
+	DefaultMaxInFlight  = 8
+	requestTimeout      = 10 * time.Second
+	// dispatchInterval is how often deliveries waiting for their backoff are checked and the queue is saved
+	dispatchInterval = time.Second
+)
+
+// Outcomes of a delivery attempt.
+const (
+	StatusDelivered = "delivered"
+	StatusRetrying  = "retrying"
+	StatusFailed    = "failed"
+	StatusDropped   = "dropped"
+)
+
+// Config configures a Notifier. Zero values select the defaults, without a QueuePath pending notifications are lost
+// when the node stops. MaxPending bounds the notifications queued per subscription, the oldest is dropped when a
+// subscription is over it, and MaxInFlight bounds the posts sent at the same time.
+type Config struct {
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+	QueuePath    string
+	LogSize      int
+	MaxPending   int
+	MaxInFlight  int
+}
+
+// Notification is the JSON body posted to a subscription.
+type Notification struct {
+	ID           string       `json:"id"`
+	Subscription string       `json:"subscription"`
+	Event        events.Event `json:"event"`
+}
+
+// delivery is a notification waiting to be delivered, persisted in the queue file.
+type delivery struct {
+	ID           string          `json:"id"`
+	Subscription string          `json:"subscription"`
+	Topic        string          `json:"topic"`
+	Body         json.RawMessage `json:"body"`
+	Attempts     int             `json:"attempts"`
+	Queued       time.Time       `json:"queued"`
+	NextAttempt  time.Time       `json:"nextAttempt"`
+	inFlight     bool
+}
+
+// LogEntry records a delivery attempt.
+type LogEntry struct {
+	Delivery     string        `json:"delivery"`
+	Subscription string        `json:"subscription"`
+	Topic        string        `json:"topic"`
+	Attempt      int           `json:"attempt"`
+	Status       string        `json:"status"`
+	StatusCode   int           `json:"statusCode,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	Time         time.Time     `json:"time"`
+	Duration     time.Duration `json:"duration"`
+}
+
+// Notifier posts signed notifications of the events on the bus to the subscriptions. Notifications are sent as soon
+// as they are queued or a post finishes, failed deliveries are retried with exponential backoff from a queue
+// persisted to QueuePath at most once per dispatch interval, and every attempt is kept in a delivery log.
+type Notifier struct {
+	bus           *events.Bus
+	subscriptions map[string]Subscription
+	order         []string
+	config        Config
+	client        *http.Client
+	mutex         sync.Mutex
+	queue         map[string]*delivery
+	log           []LogEntry
+	sending       sync.WaitGroup
+	// dirty is set when the queue changed since it was last saved
+	dirty bool
+	// pending counts the queued deliveries of each subscription, inFlight the posts being sent
+	pending  map[string]int
+	inFlight int
+	// wake asks Run to dispatch when a post finished
+	wake chan struct{}
+}
+
+func NewNotifier(bus *events.Bus, subscriptions []Subscription, config Config) *Notifier {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = DefaultRetryBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+	if config.LogSize <= 0 {
+		config.LogSize = DefaultLogSize
+	}
+	if config.MaxPending <= 0 {
+		config.MaxPending = DefaultMaxPending
+	}
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = DefaultMaxInFlight
+	}
+	n := &Notifier{
+		bus:           bus,
+		subscriptions: make(map[string]Subscription),
+		config:        config,
+		client:        &http.Client{Timeout: requestTimeout},
+		queue:         make(map[string]*delivery),
+		pending:       make(map[string]int),
+		wake:          make(chan struct{}, 1),
+	}
+	for _, s := range subscriptions {
+		n.subscriptions[s.ID] = s
+		n.order = append(n.order, s.ID)
+	}
+	return n
+}
+
+// Subscriptions returns the subscriptions without their secrets.
+func (n *Notifier) Subscriptions() []Subscription {
+	subscriptions := make([]Subscription, 0, len(n.order))
+	for _, id := range n.order {
+		s := n.subscriptions[id]
+		s.Secret = ""
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions
+}
+
+// Log returns the most recent delivery attempts of a subscription, of all subscriptions when it is empty, newest
+// first.
+func (n *Notifier) Log(subscription string, limit int) []LogEntry {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	entries := make([]LogEntry, 0)
+	for i := len(n.log) - 1; i >= 0 && (limit <= 0 || len(entries) < limit); i-- {
+		if subscription == "" || n.log[i].Subscription == subscription {
+			entries = append(entries, n.log[i])
+		}
+	}
+	return entries
+}
+
+// Pending returns how many notifications wait to be delivered.
+func (n *Notifier) Pending() int {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return len(n.queue)
+}
+
+// Run queues the notifications of the events on the bus and delivers them until ctx is done. The queue left by a
+// previous run is delivered first.
+func (n *Notifier) Run(ctx context.Context) {
+	if err := n.load(); err != nil {
+		logrus.Errorf("Error loading webhook queue: %v", err)
+	}
+	sub, _ := n.bus.Subscribe(nil, 0)
+	var last uint64
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-sub.C:
+			if !ok {
+				// the bus closes subscribers that fall behind, resume after the last event seen
+				var complete bool
+				sub, complete = n.bus.Subscribe(nil, last)
+				if !complete {
+					logrus.Warn("Webhook notifier fell behind, some events were not notified")
+				}
+				continue
+			}
+			last = event.Sequence
+			n.enqueue(event)
+			n.dispatch(ctx)
+		case <-n.wake:
+			n.dispatch(ctx)
+		case <-ticker.C:
+			n.dispatch(ctx)
+			n.flush()
+		case <-ctx.Done():
+			sub.Close()
+			n.sending.Wait()
+			n.flush()
+			return
+		}
+	}
+}
+
+func (n *Notifier) enqueue(event events.Event) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for _, id := range n.order {
+		s := n.subscriptions[id]
+		if !s.Matches(event) {
+			continue
+		}
+		notification := Notification{ID: uuid.New().String(), Subscription: s.ID, Event: event}
+		body, err := json.Marshal(notification)
+		if err != nil {
+			logrus.Errorf("Error marshaling webhook notification: %v", err)
+			continue
+		}
+		now := time.Now()
+		n.add(&delivery{
+			ID:           notification.ID,
+			Subscription: s.ID,
+			Topic:        event.Topic,
+			Body:         body,
+			Queued:       now,
+			NextAttempt:  now,
+		})
+	}
+}
+
+// add queues d, dropping the oldest delivery of its subscription that is not being sent when the subscription has
+// MaxPending deliveries queued. The caller holds the mutex.
+func (n *Notifier) add(d *delivery) {
+	if n.pending[d.Subscription] >= n.config.MaxPending {
+		var oldest *delivery
+		for _, queued := range n.queue {
+			if queued.Subscription == d.Subscription && !queued.inFlight && (oldest == nil || queued.Queued.Before(oldest.Queued)) {
+				oldest = queued
+			}
+		}
+		if oldest != nil {
+			n.remove(oldest)
+			n.appendLog(LogEntry{
+				Delivery:     oldest.ID,
+				Subscription: oldest.Subscription,
+				Topic:        oldest.Topic,
+				Attempt:      oldest.Attempts,
+				Status:       StatusDropped,
+				Time:         time.Now().UTC(),
+			})
+			logrus.WithFields(logrus.Fields{"webhook": oldest.Subscription, "delivery": oldest.ID}).Warnf("Webhook queue holds %d notifications, dropping the oldest", n.config.MaxPending)
+		}
+	}
+	n.queue[d.ID] = d
+	n.pending[d.Subscription]++
+	n.dirty = true
+}
+
+// remove takes d off the queue. The caller holds the mutex.
+func (n *Notifier) remove(d *delivery) {
+	if _, ok := n.queue[d.ID]; !ok {
+		return
+	}
+	delete(n.queue, d.ID)
+	if n.pending[d.Subscription]--; n.pending[d.Subscription] <= 0 {
+		delete(n.pending, d.Subscription)
+	}
+	n.dirty = true
+}
+
+// dispatch sends the deliveries that are due, the oldest first and at most MaxInFlight at a time.
+func (n *Notifier) dispatch(ctx context.Context) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	now := time.Now()
+	due := make([]*delivery, 0)
+	for _, d := range n.queue {
+		if d.inFlight || d.NextAttempt.After(now) {
+			continue
+		}
+		if _, ok := n.subscriptions[d.Subscription]; !ok {
+			// the subscription was removed from the configuration since the notification was queued
+			n.remove(d)
+			continue
+		}
+		due = append(due, d)
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].Queued.Before(due[j].Queued)
+	})
+	for _, d := range due {
+		if n.inFlight >= n.config.MaxInFlight {
+			// the rest is sent on the next dispatch
+			return
+		}
+		d.inFlight = true
+		n.inFlight++
+		n.sending.Add(1)
+		go n.send(ctx, n.subscriptions[d.Subscription], d)
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, s Subscription, d *delivery) {
+	defer n.sending.Done()
+	start := time.Now()
+	statusCode, err := n.post(ctx, s, d)
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	d.inFlight = false
+	n.inFlight--
+	if ctx.Err() != nil {
+		// stopping, the delivery stays queued as it was
+		return
+	}
+	d.Attempts++
+	entry := LogEntry{
+		Delivery:     d.ID,
+		Subscription: d.Subscription,
+		Topic:        d.Topic,
+		Attempt:      d.Attempts,
+		StatusCode:   statusCode,
+		Time:         start.UTC(),
+		Duration:     time.Since(start),
+	}
+	switch {
+	case err == nil:
+		entry.Status = StatusDelivered
+		n.remove(d)
+	case d.Attempts >= n.config.MaxAttempts:
+		entry.Status, entry.Error = StatusFailed, err.Error()
+		n.remove(d)
+		logrus.WithFields(logrus.Fields{"webhook": s.ID, "delivery": d.ID}).Errorf("Giving up webhook delivery after %d attempts: %v", d.Attempts, err)
+	default:
+		entry.Status, entry.Error = StatusRetrying, err.Error()
+		d.NextAttempt = time.Now().Add(n.backoff(d.Attempts))
+		logrus.WithFields(logrus.Fields{"webhook": s.ID, "delivery": d.ID}).Warnf("Webhook delivery failed, retrying: %v", err)
+	}
+	n.dirty = true
+	n.appendLog(entry)
+	// a slot is free for the next due delivery
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// appendLog adds entry to the delivery log, keeping the last LogSize entries. The caller holds the mutex.
+func (n *Notifier) appendLog(entry LogEntry) {
+	n.log = append(n.log, entry)
+	if len(n.log) > n.config.LogSize {
+		n.log = n.log[len(n.log)-n.config.LogSize:]
+	}
+}
+
+// backoff returns the wait after the given number of failed attempts.
+func (n *Notifier) backoff(attempts int) time.Duration {
+	backoff := n.config.RetryBackoff
+	for i := 1; i < attempts && backoff < n.config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > n.config.MaxBackoff {
+		backoff = n.config.MaxBackoff
+	}
+	return backoff
+}
+
+// post sends the notification signed with the subscription's secret, any status but 2xx is a failure.
+func (n *Notifier) post(ctx context.Context, s Subscription, d *delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, d.ID)
+	req.Header.Set(EventHeader, d.Topic)
+	req.Header.Set(bridge.TimestampHeader, timestamp)
+	req.Header.Set(bridge.SignatureHeader, "sha256="+hex.EncodeToString(bridge.Sign(s.Secret, timestamp, d.Body)))
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("%s answered %s", s.URL, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// flush saves the queue when it changed since the last save. The file is written without holding the lock, only Run
+// calls it so saves do not overlap.
+func (n *Notifier) flush() {
+	n.mutex.Lock()
+	if !n.dirty || n.config.QueuePath == "" {
+		n.mutex.Unlock()
+		return
+	}
+	deliveries := make([]*delivery, 0, len(n.queue))
+	for _, d := range n.queue {
+		deliveries = append(deliveries, d)
+	}
+	data, err := json.Marshal(deliveries)
+	n.dirty = false
+	n.mutex.Unlock()
+	if err == nil {
+		err = atomicfile.WriteFile(n.config.QueuePath, data, 0600)
+	}
+	if err != nil {
+		logrus.Errorf("Error saving webhook queue: %v", err)
+		n.mutex.Lock()
+		n.dirty = true
+		n.mutex.Unlock()
+	}
+}
+
+func (n *Notifier) load() error {
+	if n.config.QueuePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(n.config.QueuePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var deliveries []*delivery
+	if err := json.Unmarshal(data, &deliveries); err != nil {
+		return err
+	}
+	// the oldest are added first, so the newest are kept when a subscription has more than MaxPending
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].Queued.Before(deliveries[j].Queued)
+	})
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for _, d := range deliveries {
+		n.add(d)
+	}
+	if len(deliveries) > 0 {
+		logrus.Infof("Loaded %d queued webhook notifications", len(deliveries))
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/masa-finance/masa-oracle/pkg/ad"
+	"github.com/masa-finance/masa-oracle/pkg/bridge"
+	"github.com/masa-finance/masa-oracle/pkg/events"
+)
+
+func TestNotifierDelivery(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature := strings.TrimPrefix(r.Header.Get(bridge.SignatureHeader), "sha256=")
+		expected := hex.EncodeToString(bridge.Sign("secret", r.Header.Get(bridge.TimestampHeader), body))
+		if signature != expected {
+			t.Errorf("invalid signature %s", signature)
+		}
+		var notification Notification
+		if err := json.Unmarshal(body, &notification); err != nil || notification.Event.Topic != events.TopicStake {
+			t.Errorf("unexpected notification %s", body)
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	queuePath := filepath.Join(t.TempDir(), "queue.json")
+	config := Config{RetryBackoff: time.Nanosecond, QueuePath: queuePath}
+	subscriptions := []Subscription{
+		{ID: "stake", URL: server.URL, Secret: "secret", Topics: []string{events.TopicStake}},
+	}
+	n := NewNotifier(events.NewBus(0), subscriptions, config)
+	n.enqueue(events.Event{Sequence: 1, Topic: events.TopicPeerConnected})
+	n.enqueue(events.Event{Sequence: 2, Topic: events.TopicStake, Data: map[string]string{"amount": "1"}})
+	if n.Pending() != 1 {
+		t.Fatalf("%d notifications queued, want 1", n.Pending())
+	}
+	n.flush()
+
+	// a restarted notifier delivers the persisted queue
+	n = NewNotifier(events.NewBus(0), subscriptions, config)
+	if err := n.load(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		time.Sleep(time.Millisecond)
+		n.dispatch(ctx)
+		n.sending.Wait()
+	}
+	if n.Pending() != 0 {
+		t.Fatalf("%d notifications pending after the retry", n.Pending())
+	}
+	log := n.Log("stake", 0)
+	if len(log) != 2 || log[0].Status != StatusDelivered || log[1].Status != StatusRetrying || log[1].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unexpected delivery log %+v", log)
+	}
+}
+
+func TestNotifierGivesUp(t *testing.T) {
+	n := NewNotifier(events.NewBus(0), []Subscription{{ID: "down", URL: "http://127.0.0.1:1", Secret: "s"}},
+		Config{MaxAttempts: 2, RetryBackoff: time.Nanosecond})
+	n.enqueue(events.Event{Sequence: 1, Topic: events.TopicStake})
+	for i := 0; i < 2; i++ {
+		time.Sleep(time.Millisecond)
+		n.dispatch(context.Background())
+		n.sending.Wait()
+	}
+	if n.Pending() != 0 {
+		t.Fatal("failed notification is still queued")
+	}
+	if log := n.Log("", 1); len(log) != 1 || log[0].Status != StatusFailed || log[0].Attempt != 2 {
+		t.Errorf("unexpected delivery log %+v", log)
+	}
+}
+
+func TestNotifierMaxPending(t *testing.T) {
+	n := NewNotifier(events.NewBus(0), []Subscription{{ID: "all", URL: "http://127.0.0.1:1", Secret: "s"}},
+		Config{MaxPending: 2})
+	for i := uint64(1); i <= 3; i++ {
+		n.enqueue(events.Event{Sequence: i, Topic: events.TopicStake})
+	}
+	if n.Pending() != 2 {
+		t.Fatalf("%d notifications queued, want 2", n.Pending())
+	}
+	for _, d := range n.queue {
+		var notification Notification
+		if err := json.Unmarshal(d.Body, &notification); err != nil || notification.Event.Sequence == 1 {
+			t.Errorf("expected the oldest notification to be dropped, found %s", d.Body)
+		}
+	}
+	if log := n.Log("all", 0); len(log) != 1 || log[0].Status != StatusDropped {
+		t.Errorf("unexpected delivery log %+v", log)
+	}
+}
+
+func TestNotifierMaxInFlight(t *testing.T) {
+	var sending, most atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := sending.Add(1)
+		defer sending.Add(-1)
+		for {
+			seen := most.Load()
+			if current <= seen || most.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		<-release
+	}))
+	defer server.Close()
+
+	n := NewNotifier(events.NewBus(0), []Subscription{{ID: "all", URL: server.URL, Secret: "s"}}, Config{MaxInFlight: 2})
+	for i := uint64(1); i <= 5; i++ {
+		n.enqueue(events.Event{Sequence: i, Topic: events.TopicStake})
+	}
+	ctx := context.Background()
+	n.dispatch(ctx)
+	n.dispatch(ctx)
+	n.mutex.Lock()
+	inFlight := n.inFlight
+	n.mutex.Unlock()
+	if inFlight != 2 {
+		t.Errorf("%d posts in flight, want 2", inFlight)
+	}
+	close(release)
+	for i := 0; i < 5 && n.Pending() > 0; i++ {
+		n.sending.Wait()
+		n.dispatch(ctx)
+	}
+	n.sending.Wait()
+	if n.Pending() != 0 {
+		t.Fatalf("%d notifications pending", n.Pending())
+	}
+	if most.Load() > 2 {
+		t.Errorf("%d posts were sent at the same time, want at most 2", most.Load())
+	}
+}
+
+func TestNotifierRunDispatchesWithoutWaiting(t *testing.T) {
+	var delivered atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered.Add(1)
+	}))
+	defer server.Close()
+
+	bus := events.NewBus(0)
+	n := NewNotifier(bus, []Subscription{{ID: "all", URL: server.URL, Secret: "s"}}, Config{MaxInFlight: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go n.Run(ctx)
+	// let Run subscribe to the bus
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < 40; i++ {
+		bus.Publish(events.TopicStake, i)
+	}
+	// dispatching only once per interval would take 20 intervals for 40 notifications two at a time
+	deadline := time.Now().Add(dispatchInterval / 2)
+	for delivered.Load() < 40 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if delivered.Load() != 40 {
+		t.Errorf("%d of 40 notifications delivered within %s", delivered.Load(), dispatchInterval/2)
+	}
+}
+
+func TestSubscriptionMatches(t *testing.T) {
+	s := Subscription{Topics: []string{"peer", events.TopicAd}, AdFilter: &AdFilter{Metadata: map[string]string{"category": "news"}}}
+	for _, test := range []struct {
+		event events.Event
+		match bool
+	}{
+		{events.Event{Topic: events.TopicPeerConnected}, true},
+		{events.Event{Topic: events.TopicStake}, false},
+		{events.Event{Topic: events.TopicAd, Data: ad.Ad{Metadata: map[string]string{"category": "news"}}}, true},
+		{events.Event{Topic: events.TopicAd, Data: ad.Ad{Metadata: map[string]string{"category": "sport"}}}, false},
+	} {
+		if s.Matches(test.event) != test.match {
+			t.Errorf("%+v: match is %v", test.event, !test.match)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/masa-finance/masa-oracle/pkg/ad"
+	"github.com/masa-finance/masa-oracle/pkg/events"
+)
+
+// AdFilter selects the ads notified to a subscription. Empty fields do not filter.
+type AdFilter struct {
+	Publisher string            `json:"publisher,omitempty"`
+	Text      string            `json:"text,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// Subscription is an operator URL notified of the events of its topics, all topics when none are given. Topics
+// select their sub topics like on the event stream, "peer" selects "peer.connected" and "peer.disconnected".
+type Subscription struct {
+	ID       string    `json:"id"`
+	URL      string    `json:"url"`
+	Secret   string    `json:"secret,omitempty"`
+	Topics   []string  `json:"topics,omitempty"`
+	AdFilter *AdFilter `json:"adFilter,omitempty"`
+}
+
+// LoadSubscriptions reads the subscriptions from a JSON file holding an array of subscriptions.
+func LoadSubscriptions(path string) ([]Subscription, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		logrus.Warnf("Webhooks file %s is readable by other users, restrict it with chmod 600", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var subscriptions []Subscription
+	if err := json.Unmarshal(data, &subscriptions); err != nil {
+		return nil, fmt.Errorf("invalid webhooks file %s: %w", path, err)
+	}
+	ids := make(map[string]bool)
+	for _, s := range subscriptions {
+		if err := s.validate(); err != nil {
+			return nil, err
+		}
+		if ids[s.ID] {
+			return nil, fmt.Errorf("duplicate webhook %s", s.ID)
+		}
+		ids[s.ID] = true
+	}
+	return subscriptions, nil
+}
+
+func (s *Subscription) validate() error {
+	if s.ID == "" {
+		return errors.New("webhook without id")
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook %s has an invalid url", s.ID)
+	}
+	if s.Secret == "" {
+		return fmt.Errorf("webhook %s has no secret to sign its notifications", s.ID)
+	}
+	return nil
+}
+
+// Matches reports whether the event is notified to the subscription.
+func (s *Subscription) Matches(event events.Event) bool {
+	if len(s.Topics) > 0 {
+		matched := false
+		for _, topic := range s.Topics {
+			if topic == event.Topic || strings.HasPrefix(event.Topic, topic+".") {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if s.AdFilter != nil && event.Topic == events.TopicAd {
+		a, ok := event.Data.(ad.Ad)
+		if !ok {
+			return false
+		}
+		query := ad.Query{Publisher: s.AdFilter.Publisher, Text: s.AdFilter.Text, Metadata: s.AdFilter.Metadata}
+		return query.Matches(a)
+	}
+	return true
+}